@@ -11,6 +11,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // for blocking schedules time zones
 
 	"github.com/qdm12/dns/internal/config"
 	"github.com/qdm12/dns/internal/health"
@@ -18,6 +19,7 @@ import (
 	"github.com/qdm12/dns/internal/splash"
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/dns/pkg/check"
+	"github.com/qdm12/dns/pkg/localdata"
 	"github.com/qdm12/dns/pkg/nameserver"
 	"github.com/qdm12/dns/pkg/unbound"
 	"github.com/qdm12/golibs/command"
@@ -129,11 +131,26 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	wg.Add(1)
 	go healthServer.Run(ctx, wg)
 
+	// Unbound always listens on the loopback address of each enabled IP version.
 	localIP := net.IP{127, 0, 0, 1}
+	if !settings.Unbound.IPv4 {
+		localIP = net.IPv6loopback
+	}
 	logger.Info("using DNS address " + localIP.String() + " internally")
 	nameserver.UseDNSInternally(localIP) // use Unbound
+	localDataChanges := make(chan localdata.Settings)
+	if len(settings.LocalData.Filepaths()) > 0 {
+		const localDataCheckPeriod = 10 * time.Second
+		watcher := localdata.NewWatcher(settings.LocalData, localDataCheckPeriod, logger)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watcher.Run(ctx, localDataChanges)
+		}()
+	}
+
 	wg.Add(1)
-	go unboundRunLoop(ctx, wg, settings, logger, dnsConf, client, crashed)
+	go unboundRunLoop(ctx, wg, settings, logger, dnsConf, client, localDataChanges, crashed)
 
 	select {
 	case <-ctx.Done():
@@ -145,29 +162,44 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 }
 
 func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Settings, //nolint:gocognit
-	logger logging.Logger, dnsConf unbound.Configurator, client *http.Client, crashed chan<- error,
+	logger logging.Logger, dnsConf unbound.Configurator, client *http.Client,
+	localDataChanges <-chan localdata.Settings, crashed chan<- error,
 ) {
 	defer wg.Done()
 	defer logger.Info("unbound loop exited")
+
+	localData, err := settings.LocalData.Load()
+	if err != nil {
+		crashed <- err
+		return
+	}
+
 	timer := time.NewTimer(time.Hour)
+	scheduleTimer := time.NewTimer(time.Hour)
+	scheduleTimer.Stop()
 
 	firstRun := true
+	scheduleChanged := false
+	localDataChanged := false
+	var blacklistSettings blacklist.Settings
 
 	var (
 		unboundCtx               context.Context
 		unboundCancel            context.CancelFunc
 		waitError                chan error
 		stdoutLines, stderrLines chan string
-		err                      error
 	)
 
 	for ctx.Err() == nil {
-		timer.Stop()
-		if settings.UpdatePeriod > 0 {
-			timer.Reset(settings.UpdatePeriod)
+		if !scheduleChanged && !localDataChanged {
+			timer.Stop()
+			if settings.UpdatePeriod > 0 {
+				timer.Reset(settings.UpdatePeriod)
+			}
 		}
 
-		if !firstRun {
+		periodicUpdate := !firstRun && !scheduleChanged && !localDataChanged
+		if periodicUpdate {
 			logger.Info("downloading DNSSEC root hints and named root")
 			if err := dnsConf.SetupFiles(ctx); err != nil {
 				logAndWait(ctx, logger, err)
@@ -183,36 +215,79 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 			logger.Info(strconv.Itoa(len(blockedHostnames)) + " hostnames blocked overall")
 			logger.Info(strconv.Itoa(len(blockedIPs)) + " IP addresses blocked overall")
 			logger.Info(strconv.Itoa(len(blockedIPPrefixes)) + " IP networks blocked overall")
-			settings.Unbound.Blacklist = blacklist.Settings{
+			groups, errs := blacklistBuilder.Groups(ctx, settings.Blacklist.Groups)
+			for _, err := range errs {
+				logger.Warn(err.Error())
+			}
+			for _, group := range groups {
+				logger.Info("client group " + group.Clients.Name + ": " +
+					strconv.Itoa(len(group.Blacklist.FqdnHostnames)) + " hostnames blocked")
+			}
+			scheduled, errs := blacklistBuilder.Scheduled(ctx, settings.Blacklist.Scheduled)
+			for _, err := range errs {
+				logger.Warn(err.Error())
+			}
+			blacklistSettings = blacklist.Settings{
 				FqdnHostnames: blockedHostnames,
 				IPs:           blockedIPs,
 				IPPrefixes:    blockedIPPrefixes,
+				SafeSearch:    settings.Blacklist.SafeSearch,
+				Groups:        groups,
+				Scheduled:     scheduled,
 			}
+			blacklistSettings.SetPrivateHostnames(settings.Blacklist.PrivateHostnames)
 		}
+		scheduleChanged, localDataChanged = false, false
+
+		now := time.Now()
+		settings.Unbound.Blacklist = blacklistSettings.Active(now)
+		settings.Unbound.LocalData = localData
 
 		logger.Info("generating Unbound configuration")
-		if err := dnsConf.MakeUnboundConf(settings.Unbound); err != nil {
+		var confChanged bool
+		confChanged, err = dnsConf.MakeUnboundConf(ctx, settings.Unbound)
+		if err != nil {
 			logAndWait(ctx, logger, err)
 			continue
 		}
 
-		if !firstRun {
-			unboundCancel()
-			<-waitError
-			close(waitError)
-			close(stdoutLines)
-			close(stderrLines)
+		restart := firstRun
+		// Reloading the response policy zones only is enough if the
+		// configuration is unchanged, except for periodic updates which
+		// also apply the new root files and include.conf changes.
+		reload := !firstRun && (confChanged || !settings.Unbound.RPZ || periodicUpdate)
+		if !firstRun && !reload {
+			logger.Info("reloading unbound response policy zones")
+			if err := dnsConf.ReloadZones(ctx, settings.Unbound); err != nil {
+				logger.Warn(err.Error() + ", reloading unbound instead")
+				reload = true
+			}
 		}
-		unboundCtx, unboundCancel = context.WithCancel(ctx)
-
-		logger.Info("starting unbound")
-		stdoutLines, stderrLines, waitError, err = dnsConf.Start(unboundCtx, settings.Unbound.VerbosityDetailsLevel)
-		if err != nil {
-			crashed <- err
-			break
+		if reload {
+			logger.Info("reloading unbound")
+			if err := dnsConf.Reload(ctx); err != nil {
+				logger.Warn(err.Error() + ", restarting unbound instead")
+				restart = true
+				unboundCancel()
+				<-waitError
+				close(waitError)
+				close(stdoutLines)
+				close(stderrLines)
+			}
 		}
 
-		go logUnboundStreams(logger, stdoutLines, stderrLines)
+		if restart {
+			unboundCtx, unboundCancel = context.WithCancel(ctx)
+
+			logger.Info("starting unbound")
+			stdoutLines, stderrLines, waitError, err = dnsConf.Start(unboundCtx, settings.Unbound.VerbosityDetailsLevel)
+			if err != nil {
+				crashed <- err
+				break
+			}
+
+			go logUnboundStreams(logger, stdoutLines, stderrLines)
+		}
 
 		if settings.CheckDNS {
 			if err := check.WaitForDNS(ctx, net.DefaultResolver); err != nil {
@@ -222,18 +297,32 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 		}
 
 		if firstRun {
-			logger.Info("restarting Unbound the first time to get updated files")
+			logger.Info("reloading Unbound the first time to get updated files")
 			firstRun = false
 			continue
 		}
 
+		if nextChange := blacklistSettings.NextChange(now); !nextChange.IsZero() {
+			stopTimer(scheduleTimer)
+			scheduleTimer.Reset(time.Until(nextChange))
+		}
+
 		select {
 		case <-timer.C:
-			logger.Info("planned restart of unbound")
+			stopTimer(scheduleTimer)
+			logger.Info("planned reload of unbound")
+		case <-scheduleTimer.C:
+			logger.Info("blocking schedule changed, reloading unbound")
+			scheduleChanged = true
+		case localData = <-localDataChanges:
+			stopTimer(scheduleTimer)
+			logger.Info("local records changed, reloading unbound")
+			localDataChanged = true
 		case <-ctx.Done():
 			if !timer.Stop() {
 				<-timer.C
 			}
+			stopTimer(scheduleTimer)
 			logger.Warn("context canceled: exiting unbound run loop")
 		case waitErr := <-waitError:
 			close(waitError)
@@ -242,6 +331,7 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 			if !timer.Stop() {
 				<-timer.C
 			}
+			stopTimer(scheduleTimer)
 			crashed <- waitErr
 			unboundCancel()
 			return
@@ -250,6 +340,18 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 	unboundCancel()
 }
 
+// stopTimer stops the timer and drains its channel if it fired
+// without being received from, so a stale tick is not received
+// after the timer is reset.
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
 func logAndWait(ctx context.Context, logger logging.Logger, err error) {
 	const wait = 10 * time.Second
 	logger.Error(err.Error() + ", retrying in " + wait.String())
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/qdm12/dns/pkg/dnscrypt"
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+// Run with the DNS stamp of a DNSCrypt server as argument.
+func main() {
+	if len(os.Args) < 2 { //nolint:gomnd
+		log.Fatal("usage: dnscrypt-resolver sdns://...")
+	}
+	dnsCryptProvider, err := provider.Parse(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	resolver := dnscrypt.NewResolver(dnscrypt.ResolverSettings{
+		DNSCryptProviders: []provider.Provider{dnsCryptProvider},
+	})
+	ips, err := resolver.LookupIPAddr(ctx, "github.com")
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("IP addresses resolved: ", ips)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/doh"
+)
+
+func main() {
+	ctx := context.Background()
+	client := doh.NewClient(doh.ResolverSettings{})
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	response, err := client.Exchange(ctx, request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("DNS response received: ", response)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/doh"
+	"github.com/qdm12/dns/pkg/dot"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/handler"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	logger := new(Logger)
+	// DNS over HTTPS with DNS over TLS as backup
+	exchanger := exchange.NewFallback(
+		doh.NewClient(doh.ResolverSettings{}),
+		dot.NewClient(dot.ResolverSettings{}),
+	)
+	dnsHandler, err := handler.New(ctx, logger, exchanger, handler.Settings{
+		Cache: cache.Settings{Type: cache.LRU},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := &dns.Server{Addr: ":53", Net: "udp", Handler: dnsHandler}
+	stopped := make(chan error)
+	go func() { stopped <- server.ListenAndServe() }()
+	select {
+	case <-ctx.Done():
+		logger.Warn("\nCaught an OS signal, terminating...")
+		_ = server.Shutdown()
+		<-stopped
+	case err := <-stopped:
+		logger.Warn("DNS server crashed: " + err.Error())
+		stop() // stop custom handling of OS signals
+		cancel()
+	}
+}
+
+type Logger struct{}
+
+func (l *Logger) Debug(s string) { log.Println(s) }
+func (l *Logger) Info(s string)  { log.Println(s) }
+func (l *Logger) Warn(s string)  { log.Println(s) }
+func (l *Logger) Error(s string) { log.Println(s) }
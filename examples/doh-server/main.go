@@ -9,15 +9,21 @@ import (
 
 	"github.com/qdm12/dns/pkg/cache"
 	"github.com/qdm12/dns/pkg/doh"
+	"github.com/qdm12/dns/pkg/handler"
 )
 
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	logger := new(Logger)
-	server := doh.NewServer(ctx, logger, doh.ServerSettings{
-		Cache: cache.Settings{Type: cache.LRU},
+	server, err := doh.NewServer(ctx, logger, doh.ServerSettings{
+		Settings: handler.Settings{
+			Cache: cache.Settings{Type: cache.LRU},
+		},
 	})
+	if err != nil {
+		log.Fatal(err)
+	}
 	stopped := make(chan error)
 	go server.Run(ctx, stopped)
 	select {
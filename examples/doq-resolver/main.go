@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/qdm12/dns/pkg/doq"
+)
+
+func main() {
+	ctx := context.Background()
+	resolver := doq.NewResolver(doq.ResolverSettings{})
+	ips, err := resolver.LookupIPAddr(ctx, "github.com")
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("IP addresses resolved: ", ips)
+}
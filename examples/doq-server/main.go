@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/qdm12/dns/pkg/doq"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	logger := new(Logger)
+	certificate, err := tls.LoadX509KeyPair("cert.pem", "key.pem")
+	if err != nil {
+		log.Fatal(err)
+	}
+	server, err := doq.NewServer(ctx, logger, doq.ServerSettings{
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS13,
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	stopped := make(chan error)
+	go server.Run(ctx, stopped)
+	select {
+	case <-ctx.Done():
+		logger.Warn("\nCaught an OS signal, terminating...")
+		<-stopped
+	case err := <-stopped:
+		logger.Warn("DoQ server crashed: " + err.Error())
+		stop() // stop custom handling of OS signals
+		cancel()
+	}
+}
+
+type Logger struct{}
+
+func (l *Logger) Debug(s string) { log.Println(s) }
+func (l *Logger) Info(s string)  { log.Println(s) }
+func (l *Logger) Warn(s string)  { log.Println(s) }
+func (l *Logger) Error(s string) { log.Println(s) }
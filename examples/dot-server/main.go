@@ -14,7 +14,10 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	logger := new(Logger)
-	server := dot.NewServer(ctx, logger, dot.ServerSettings{})
+	server, err := dot.NewServer(ctx, logger, dot.ServerSettings{})
+	if err != nil {
+		log.Fatal(err)
+	}
 	stopped := make(chan error)
 	go server.Run(ctx, stopped)
 	select {
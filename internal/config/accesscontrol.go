@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/accesscontrol"
+	"github.com/qdm12/golibs/params"
+	"inet.af/netaddr"
+)
+
+var errAccessControlRuleMalformed = errors.New("access control rule is malformed")
+
+// getAccessControl obtains the access control rules from the comma separated
+// list of the environment variable ACCESS_CONTROL, where each rule is in the
+// format <cidr>=<action>, for example 192.168.0.0/16=allow.
+// It defaults to allowing loopback and private clients only.
+func getAccessControl(reader *reader) (settings accesscontrol.Settings, err error) {
+	const defaultRules = "127.0.0.0/8=allow,10.0.0.0/8=allow,172.16.0.0/12=allow," +
+		"192.168.0.0/16=allow,::1/128=allow,fc00::/7=allow,fe80::/10=allow," +
+		"0.0.0.0/0=refuse,::/0=refuse"
+	values, err := reader.env.CSV("ACCESS_CONTROL", params.Default(defaultRules))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable ACCESS_CONTROL: %w", err)
+	}
+
+	settings.Rules = make([]accesscontrol.Rule, len(values))
+	for i, value := range values {
+		settings.Rules[i], err = parseAccessControlRule(value)
+		if err != nil {
+			return settings, fmt.Errorf("environment variable ACCESS_CONTROL: %w", err)
+		}
+	}
+	return settings, nil
+}
+
+func parseAccessControlRule(s string) (rule accesscontrol.Rule, err error) {
+	parts := strings.Split(s, "=")
+	const expectedParts = 2
+	if len(parts) != expectedParts {
+		return rule, fmt.Errorf("%w: %s", errAccessControlRuleMalformed, s)
+	}
+
+	subnet := parts[0]
+	if !strings.Contains(subnet, "/") {
+		ip, err := netaddr.ParseIP(subnet)
+		if err != nil {
+			return rule, fmt.Errorf("%w: %s", ErrInvalidIPString, err)
+		}
+		rule.Subnet = netaddr.IPPrefix{IP: ip, Bits: ip.BitLen()}
+	} else {
+		rule.Subnet, err = netaddr.ParseIPPrefix(subnet)
+		if err != nil {
+			return rule, fmt.Errorf("%w: %s", ErrInvalidIPString, err)
+		}
+		rule.Subnet = rule.Subnet.Masked()
+	}
+
+	rule.Action, err = accesscontrol.ParseAction(parts[1])
+	if err != nil {
+		return rule, err
+	}
+
+	return rule, nil
+}
@@ -10,48 +10,99 @@ import (
 )
 
 func getBlacklistSettings(reader *reader) (settings blacklist.BuilderSettings, err error) {
-	settings.BlockMalicious, err = reader.env.OnOff("BLOCK_MALICIOUS", params.Default("on"))
+	settings, err = getBuilderSettings(reader, "", "on")
 	if err != nil {
-		return settings, fmt.Errorf("environment variable BLOCK_MALICIOUS: %w", err)
+		return settings, err
 	}
-	settings.BlockSurveillance, err = reader.env.OnOff("BLOCK_SURVEILLANCE", params.Default("off"),
-		params.RetroKeys([]string{"BLOCK_NSA"}, reader.onRetroActive))
+	settings.SafeSearch, err = getSafeSearch(reader, "")
 	if err != nil {
-		return settings, fmt.Errorf("environment variable BLOCK_SURVEILLANCE: %w", err)
+		return settings, err
 	}
-	settings.BlockAds, err = reader.env.OnOff("BLOCK_ADS", params.Default("off"))
+	settings.Scheduled, err = getSchedules(reader, "")
 	if err != nil {
-		return settings, fmt.Errorf("environment variable BLOCK_SURVEILLANCE: %w", err)
+		return settings, err
 	}
-	settings.AllowedHosts, err = getAllowedHostnames(reader)
+	privateIPs, privateIPPrefixes, err := getPrivateAddresses(reader)
 	if err != nil {
 		return settings, err
 	}
-	settings.AddBlockedHosts, err = getBlockedHostnames(reader)
+	settings.AddBlockedIPs = append(settings.AddBlockedIPs, privateIPs...)
+	settings.AddBlockedIPPrefixes = append(settings.AddBlockedIPPrefixes, privateIPPrefixes...)
+	settings.PrivateHostnames, err = getPrivateDomains(reader)
 	if err != nil {
 		return settings, err
 	}
-	settings.AddBlockedIPs, settings.AddBlockedIPPrefixes, err = getBlockedIPs(reader)
+	settings.Groups, err = getClientGroups(reader, privateIPs, privateIPPrefixes)
 	if err != nil {
 		return settings, err
 	}
-	privateIPs, privateIPPrefixes, err := getPrivateAddresses(reader)
+	return settings, nil
+}
+
+// getBuilderSettings obtains the blacklist building settings from
+// the environment variables, with each key prefixed with keyPrefix.
+// The default for blocking malicious hostnames and IPs is given by
+// blockMaliciousDefault which can be "on" or "off".
+func getBuilderSettings(reader *reader, keyPrefix, blockMaliciousDefault string) (
+	settings blacklist.BuilderSettings, err error) {
+	key := keyPrefix + "BLOCK_MALICIOUS"
+	settings.BlockMalicious, err = reader.env.OnOff(key, params.Default(blockMaliciousDefault))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	key = keyPrefix + "BLOCK_SURVEILLANCE"
+	options := []params.OptionSetter{params.Default("off")}
+	if keyPrefix == "" {
+		options = append(options, params.RetroKeys([]string{"BLOCK_NSA"}, reader.onRetroActive))
+	}
+	settings.BlockSurveillance, err = reader.env.OnOff(key, options...)
+	if err != nil {
+		return settings, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	key = keyPrefix + "BLOCK_ADS"
+	settings.BlockAds, err = reader.env.OnOff(key, params.Default("off"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	settings.AllowedHosts, err = getAllowedHostnames(reader, keyPrefix)
+	if err != nil {
+		return settings, err
+	}
+	settings.AllowedIPs, settings.AllowedIPPrefixes, err = getAllowedIPs(reader, keyPrefix)
+	if err != nil {
+		return settings, err
+	}
+	settings.AddBlockedHosts, err = getBlockedHostnames(reader, keyPrefix)
+	if err != nil {
+		return settings, err
+	}
+	settings.AddBlockedIPs, settings.AddBlockedIPPrefixes, err = getBlockedIPs(reader, keyPrefix)
 	if err != nil {
 		return settings, err
 	}
-	settings.AddBlockedIPs = append(settings.AddBlockedIPs, privateIPs...)
-	settings.AddBlockedIPPrefixes = append(settings.AddBlockedIPPrefixes, privateIPPrefixes...)
 	return settings, nil
 }
 
+// getSafeSearch obtains whether to enforce safe search from
+// the environment variable <keyPrefix>SAFE_SEARCH.
+func getSafeSearch(reader *reader, keyPrefix string) (safeSearch bool, err error) {
+	key := keyPrefix + "SAFE_SEARCH"
+	safeSearch, err = reader.env.OnOff(key, params.Default("off"))
+	if err != nil {
+		return false, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	return safeSearch, nil
+}
+
 var errAllowedHostnameInvalid = errors.New("allowed hostname is invalid")
 
 // getAllowedHostnames obtains a list of hostnames to unblock from block lists
 // from the comma separated list for the environment variable UNBLOCK.
-func getAllowedHostnames(reader *reader) (hostnames []string, err error) {
-	hostnames, err = reader.env.CSV("UNBLOCK")
+func getAllowedHostnames(reader *reader, keyPrefix string) (hostnames []string, err error) {
+	key := keyPrefix + "UNBLOCK"
+	hostnames, err = reader.env.CSV(key)
 	if err != nil {
-		return nil, fmt.Errorf("environment variable UNBLOCK: %w", err)
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
 	}
 	for _, hostname := range hostnames {
 		if !reader.verifier.MatchHostname(hostname) {
@@ -61,14 +112,31 @@ func getAllowedHostnames(reader *reader) (hostnames []string, err error) {
 	return hostnames, nil
 }
 
+// getAllowedIPs obtains a list of IP addresses and IP networks to unblock from
+// block lists from the comma separated list for the environment variable UNBLOCK_IPS.
+func getAllowedIPs(reader *reader, keyPrefix string) (ips []netaddr.IP,
+	ipPrefixes []netaddr.IPPrefix, err error) {
+	key := keyPrefix + "UNBLOCK_IPS"
+	values, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	ips, ipPrefixes, err = convertStringsToIPs(values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("environment variable %s: %w: %s", key, ErrInvalidIPString, err)
+	}
+	return ips, ipPrefixes, nil
+}
+
 var errBlockedHostnameInvalid = errors.New("blocked hostname is invalid")
 
 // getBlockedHostnames obtains a list of hostnames to block from the comma
 // separated list for the environment variable BLOCK_HOSTNAMES.
-func getBlockedHostnames(reader *reader) (hostnames []string, err error) {
-	hostnames, err = reader.env.CSV("BLOCK_HOSTNAMES")
+func getBlockedHostnames(reader *reader, keyPrefix string) (hostnames []string, err error) {
+	key := keyPrefix + "BLOCK_HOSTNAMES"
+	hostnames, err = reader.env.CSV(key)
 	if err != nil {
-		return nil, fmt.Errorf("environment variable BLOCK_HOSTNAMES: %w", err)
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
 	}
 	for _, hostname := range hostnames {
 		if !reader.verifier.MatchHostname(hostname) {
@@ -80,15 +148,16 @@ func getBlockedHostnames(reader *reader) (hostnames []string, err error) {
 
 // getBlockedIPs obtains a list of IP addresses and IP networks to block from
 // the comma separated list for the environment variable BLOCK_IPS.
-func getBlockedIPs(reader *reader) (ips []netaddr.IP,
+func getBlockedIPs(reader *reader, keyPrefix string) (ips []netaddr.IP,
 	ipPrefixes []netaddr.IPPrefix, err error) {
-	values, err := reader.env.CSV("BLOCK_IPS")
+	key := keyPrefix + "BLOCK_IPS"
+	values, err := reader.env.CSV(key)
 	if err != nil {
-		return nil, nil, fmt.Errorf("environment variable BLOCK_IPS: %w", err)
+		return nil, nil, fmt.Errorf("environment variable %s: %w", key, err)
 	}
 	ips, ipPrefixes, err = convertStringsToIPs(values)
 	if err != nil {
-		return nil, nil, fmt.Errorf("environment variable BLOCK_IPS: %w: %s", ErrInvalidIPString, err)
+		return nil, nil, fmt.Errorf("environment variable %s: %w: %s", key, ErrInvalidIPString, err)
 	}
 	return ips, ipPrefixes, nil
 }
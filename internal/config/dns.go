@@ -21,3 +21,21 @@ func getPrivateAddresses(reader *reader) (privateIPs []netaddr.IP,
 	}
 	return privateIPs, privateIPPrefixes, nil
 }
+
+var errPrivateDomainInvalid = errors.New("private domain is invalid")
+
+// getPrivateDomains obtains a list of hostnames, and their subdomains, allowed
+// to resolve to private addresses from the comma separated list for the
+// environment variable PRIVATE_DOMAINS.
+func getPrivateDomains(reader *reader) (hostnames []string, err error) {
+	hostnames, err = reader.env.CSV("PRIVATE_DOMAINS")
+	if err != nil {
+		return nil, fmt.Errorf("environment variable PRIVATE_DOMAINS: %w", err)
+	}
+	for _, hostname := range hostnames {
+		if !reader.verifier.MatchHostname(hostname) {
+			return nil, fmt.Errorf("%w: %s", errPrivateDomainInvalid, hostname)
+		}
+	}
+	return hostnames, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/golibs/params"
+	"inet.af/netaddr"
+)
+
+var (
+	errDNS64PrefixInvalid     = errors.New("DNS64 prefix is invalid")
+	errDNS64IgnoreAAAAInvalid = errors.New("DNS64 hostname to ignore AAAA records for is invalid")
+)
+
+func getDNS64(reader *reader) (settings dns64.Settings, err error) {
+	settings.Enabled, err = reader.env.OnOff("DNS64", params.Default("off"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable DNS64: %w", err)
+	}
+
+	settings.Prefix, err = getDNS64Prefix(reader)
+	if err != nil {
+		return settings, err
+	}
+
+	hostnames, err := reader.env.CSV("DNS64_IGNORE_AAAA")
+	if err != nil {
+		return settings, fmt.Errorf("environment variable DNS64_IGNORE_AAAA: %w", err)
+	}
+	for _, hostname := range hostnames {
+		if !reader.verifier.MatchHostname(hostname) {
+			return settings, fmt.Errorf("environment variable DNS64_IGNORE_AAAA: %w: %s",
+				errDNS64IgnoreAAAAInvalid, hostname)
+		}
+	}
+	settings.SetIgnoreAAAAHostnames(hostnames)
+
+	err = settings.Validate()
+	if err != nil {
+		return settings, fmt.Errorf("environment variable DNS64_PREFIX: %w: %s", errDNS64PrefixInvalid, err)
+	}
+
+	return settings, nil
+}
+
+// getDNS64Prefix obtains the DNS64 IPv6 prefix from the environment
+// variable DNS64_PREFIX, which is validated with the other DNS64 settings.
+func getDNS64Prefix(reader *reader) (prefix netaddr.IPPrefix, err error) {
+	s, err := reader.env.Get("DNS64_PREFIX", params.Default("64:ff9b::/96"))
+	if err != nil {
+		return prefix, fmt.Errorf("environment variable DNS64_PREFIX: %w", err)
+	}
+
+	prefix, err = netaddr.ParseIPPrefix(s)
+	if err != nil {
+		return prefix, fmt.Errorf("environment variable DNS64_PREFIX: %w: %s", errDNS64PrefixInvalid, err)
+	}
+
+	return prefix.Masked(), nil
+}
@@ -31,6 +31,10 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 	for _, line := range s.Blacklist.Lines(indent, subSection) {
 		lines = append(lines, indent+line)
 	}
+	lines = append(lines, subSection+"Local data settings:")
+	for _, line := range s.LocalData.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
 	lines = append(lines, subSection+"Check DNS: "+checkDNS)
 	lines = append(lines, subSection+"Update: "+update)
 
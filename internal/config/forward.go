@@ -0,0 +1,206 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/forward"
+	"github.com/qdm12/golibs/params"
+	"inet.af/netaddr"
+)
+
+var (
+	errForwardZoneNameInvalid     = errors.New("forwarding zone name is invalid")
+	errForwardZoneNameDuplicate   = errors.New("forwarding zone name is duplicated")
+	errForwardZoneNoDomain        = errors.New("forwarding zone has no domain")
+	errForwardZoneDomainInvalid   = errors.New("forwarding zone domain is invalid")
+	errForwardZoneNoUpstream      = errors.New("forwarding zone has no upstream")
+	errForwardZoneUpstreamInvalid = errors.New("forwarding zone upstream is invalid")
+	errForwardZoneNoTLSName       = errors.New("forwarding zone has no TLS name")
+	errForwardZoneDoHNotSupported = errors.New("DNS over HTTPS forwarding is not supported by Unbound, " +
+		"and is only available with the Go DNS servers of the pkg/forward package")
+)
+
+// getForwardZones obtains the forwarding zones from the comma separated
+// list of zone names for the environment variable FORWARD_ZONES.
+// Each zone is then configured with environment variables prefixed
+// with FORWARD_ZONE_<NAME>_ such as FORWARD_ZONE_CORP_DOMAINS.
+func getForwardZones(reader *reader) (zones []forward.Zone, err error) {
+	names, err := reader.env.CSV("FORWARD_ZONES")
+	if err != nil {
+		return nil, fmt.Errorf("environment variable FORWARD_ZONES: %w", err)
+	}
+
+	zones = make([]forward.Zone, 0, len(names))
+	uniqueNames := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !nameRegex.MatchString(name) {
+			return nil, fmt.Errorf("environment variable FORWARD_ZONES: %w: %s", errForwardZoneNameInvalid, name)
+		}
+		if _, ok := uniqueNames[name]; ok {
+			return nil, fmt.Errorf("environment variable FORWARD_ZONES: %w: %s", errForwardZoneNameDuplicate, name)
+		}
+		uniqueNames[name] = struct{}{}
+
+		zone, err := getForwardZone(reader, name)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+func getForwardZone(reader *reader, name string) (zone forward.Zone, err error) {
+	keyPrefix := "FORWARD_ZONE_" + strings.ToUpper(name) + "_"
+
+	zone.Name = name
+	zone.FqdnDomains, err = getForwardZoneDomains(reader, keyPrefix)
+	if err != nil {
+		return zone, err
+	}
+	if len(zone.FqdnDomains) == 0 {
+		return zone, fmt.Errorf("%w: %s", errForwardZoneNoDomain, name)
+	}
+
+	key := keyPrefix + "PROTOCOL"
+	protocol, err := reader.env.Get(key, params.Default(string(forward.Plain)))
+	if err != nil {
+		return zone, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	zone.Protocol, err = forward.ParseProtocol(protocol)
+	if err != nil {
+		return zone, fmt.Errorf("environment variable %s: %w", key, err)
+	} else if zone.Protocol == forward.DoH {
+		return zone, fmt.Errorf("environment variable %s: %w", key, errForwardZoneDoHNotSupported)
+	}
+
+	zone.Upstreams, err = getForwardZoneUpstreams(reader, keyPrefix, zone.Protocol)
+	if err != nil {
+		return zone, err
+	}
+	if len(zone.Upstreams) == 0 {
+		return zone, fmt.Errorf("%w: %s", errForwardZoneNoUpstream, name)
+	}
+
+	if zone.Protocol == forward.DoT {
+		key = keyPrefix + "TLS_NAME"
+		zone.TLSName, err = reader.env.Get(key)
+		if err != nil {
+			return zone, fmt.Errorf("environment variable %s: %w", key, err)
+		} else if zone.TLSName == "" {
+			return zone, fmt.Errorf("%w: %s", errForwardZoneNoTLSName, name)
+		}
+	}
+
+	key = keyPrefix + "AUTHORITATIVE"
+	zone.Authoritative, err = reader.env.OnOff(key, params.Default("off"))
+	if err != nil {
+		return zone, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	zone.Insecure, err = getForwardZoneInsecure(reader, keyPrefix, zone.FqdnDomains)
+	if err != nil {
+		return zone, err
+	}
+
+	return zone, nil
+}
+
+// getForwardZoneInsecure obtains whether the zone domains are not validated
+// with DNSSEC, which defaults to auto to only disable the validation if all
+// the domains are private domains, such as home.arpa.
+func getForwardZoneInsecure(reader *reader, keyPrefix string, fqdnDomains []string) (
+	insecure bool, err error) {
+	key := keyPrefix + "INSECURE"
+	s, err := reader.env.Get(key, params.Default("auto"))
+	if err != nil {
+		return false, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	if s != "auto" {
+		insecure, err = reader.env.OnOff(key)
+		if err != nil {
+			return false, fmt.Errorf("environment variable %s: %w", key, err)
+		}
+		return insecure, nil
+	}
+
+	for _, fqdnDomain := range fqdnDomains {
+		if !forward.IsPrivateDomain(fqdnDomain) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func getForwardZoneDomains(reader *reader, keyPrefix string) (fqdnDomains []string, err error) {
+	key := keyPrefix + "DOMAINS"
+	domains, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	fqdnDomains = make([]string, len(domains))
+	for i, domain := range domains {
+		if !reader.verifier.MatchHostname(domain) {
+			return nil, fmt.Errorf("environment variable %s: %w: %s", key, errForwardZoneDomainInvalid, domain)
+		}
+		fqdnDomains[i] = dns.Fqdn(strings.ToLower(domain))
+	}
+	return fqdnDomains, nil
+}
+
+// getForwardZoneUpstreams obtains the upstream servers from the comma
+// separated list of IP addresses with an optional port, which defaults
+// to 53 for plain DNS and 853 for DNS over TLS.
+func getForwardZoneUpstreams(reader *reader, keyPrefix string, protocol forward.Protocol) (
+	upstreams []forward.Upstream, err error) {
+	key := keyPrefix + "UPSTREAMS"
+	values, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	defaultPort := uint16(53) //nolint:gomnd
+	if protocol == forward.DoT {
+		defaultPort = 853
+	}
+
+	upstreams = make([]forward.Upstream, len(values))
+	for i, value := range values {
+		upstreams[i], err = parseUpstream(value, defaultPort)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w: %s", key, errForwardZoneUpstreamInvalid, err)
+		}
+	}
+	return upstreams, nil
+}
+
+func parseUpstream(s string, defaultPort uint16) (upstream forward.Upstream, err error) {
+	upstream.Port = defaultPort
+	upstream.IP, err = netaddr.ParseIP(s)
+	if err == nil {
+		return upstream, nil
+	}
+
+	host, port, splitErr := net.SplitHostPort(s)
+	if splitErr != nil {
+		return upstream, err
+	}
+	upstream.IP, err = netaddr.ParseIP(host)
+	if err != nil {
+		return upstream, err
+	}
+	portUint, err := strconv.ParseUint(port, 10, 16) //nolint:gomnd
+	if err != nil {
+		return upstream, err
+	}
+	upstream.Port = uint16(portUint)
+	return upstream, nil
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/qdm12/dns/pkg/unbound"
 	"github.com/qdm12/golibs/params"
@@ -48,9 +51,165 @@ func getUnboundSettings(reader *reader) (settings unbound.Settings, err error) {
 	}
 	settings.ValidationLogLevel = uint8(validationLogLevel)
 
-	settings.AccessControl.Allowed = []netaddr.IPPrefix{
-		{IP: netaddr.IPv4(0, 0, 0, 0)},
-		{IP: netaddr.IPv6Raw([16]byte{})},
+	settings.Threads, err = getThreads(reader)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.MsgCacheSize, settings.RRSetCacheSize, settings.KeyCacheSize, err = getCacheSizes(reader)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.CacheMaxTTL, err = reader.env.Duration("CACHE_MAX_TTL", params.Default("9000s"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable CACHE_MAX_TTL: %w", err)
+	}
+
+	settings.ServeExpired, err = reader.env.OnOff("SERVE_EXPIRED", params.Default("off"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable SERVE_EXPIRED: %w", err)
+	}
+
+	settings.ListeningAddresses, err = getListeningAddresses(reader, settings.IPv4)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.QNAMEMinimisation, err = reader.env.OnOff("QNAME_MINIMISATION", params.Default("on"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable QNAME_MINIMISATION: %w", err)
+	}
+
+	settings.AggressiveNSEC, err = reader.env.OnOff("AGGRESSIVE_NSEC", params.Default("off"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable AGGRESSIVE_NSEC: %w", err)
+	}
+
+	settings.Hardened, err = reader.env.OnOff("HARDENING", params.Default("on"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable HARDENING: %w", err)
+	}
+
+	settings.RPZ, err = reader.env.OnOff("RPZ", params.Default("off"))
+	if err != nil {
+		return settings, fmt.Errorf("environment variable RPZ: %w", err)
+	}
+
+	settings.AccessControl, err = getAccessControl(reader)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.Forward.Zones, err = getForwardZones(reader)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.DNS64, err = getDNS64(reader)
+	if err != nil {
+		return settings, err
+	}
+
+	err = settings.Validate()
+	if err != nil {
+		return settings, fmt.Errorf("Unbound settings: %w", err)
 	}
+
 	return settings, nil
 }
+
+// getThreads obtains the number of Unbound threads from the environment
+// variable UNBOUND_THREADS, which defaults to auto to use the number of
+// CPUs available, capped to 8, by leaving it to 0.
+func getThreads(reader *reader) (threads uint16, err error) {
+	s, err := reader.env.Get("UNBOUND_THREADS", params.Default("auto"))
+	if err != nil {
+		return 0, fmt.Errorf("environment variable UNBOUND_THREADS: %w", err)
+	}
+	if s == "auto" {
+		return 0, nil
+	}
+	n, err := reader.env.IntRange("UNBOUND_THREADS", 1, 256) //nolint:gomnd
+	if err != nil {
+		return 0, fmt.Errorf("environment variable UNBOUND_THREADS: %w", err)
+	}
+	return uint16(n), nil
+}
+
+var errCacheMemoryTooSmall = errors.New("cache memory is too small")
+
+// getCacheSizes obtains the memory budget for the Unbound caches from the
+// environment variable CACHE_MEMORY, and splits it between the message,
+// resource record set and DNSSEC key caches with a 1:1:4 ratio.
+func getCacheSizes(reader *reader) (msgCacheSize, rrsetCacheSize, keyCacheSize uint64, err error) {
+	s, err := reader.env.Get("CACHE_MEMORY", params.Default("48m"))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("environment variable CACHE_MEMORY: %w", err)
+	}
+	budget, err := parseByteSize(s)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("environment variable CACHE_MEMORY: %w", err)
+	}
+
+	const parts = 6
+	const minimumBudget = parts * 1024 * 1024
+	if budget < minimumBudget {
+		return 0, 0, 0, fmt.Errorf("environment variable CACHE_MEMORY: %w: %s is less than 6m",
+			errCacheMemoryTooSmall, s)
+	}
+	msgCacheSize = budget / parts
+	rrsetCacheSize = budget / parts
+	keyCacheSize = budget - msgCacheSize - rrsetCacheSize
+	return msgCacheSize, rrsetCacheSize, keyCacheSize, nil
+}
+
+var errByteSizeMalformed = errors.New("byte size is malformed")
+
+// parseByteSize parses a size in bytes with an optional
+// k, m or g suffix, in the same format as Unbound.
+func parseByteSize(s string) (size uint64, err error) {
+	multipliers := map[string]uint64{
+		"k": 1 << 10, //nolint:gomnd
+		"m": 1 << 20, //nolint:gomnd
+		"g": 1 << 30, //nolint:gomnd
+	}
+	value := strings.ToLower(s)
+	multiplier := uint64(1)
+	if len(value) > 0 {
+		if m, ok := multipliers[value[len(value)-1:]]; ok {
+			multiplier = m
+			value = value[:len(value)-1]
+		}
+	}
+	size, err = strconv.ParseUint(value, 10, 64) //nolint:gomnd
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errByteSizeMalformed, s)
+	}
+	return size * multiplier, nil
+}
+
+var errListeningAddressInvalid = errors.New("listening address is invalid")
+
+// getListeningAddresses obtains the IP addresses Unbound listens on from the
+// comma separated list of the environment variable LISTENING_ADDRESSES,
+// which defaults to 0.0.0.0, or to :: if IPv4 is disabled.
+func getListeningAddresses(reader *reader, ipv4 bool) (addresses []netaddr.IP, err error) {
+	defaultAddress := "0.0.0.0"
+	if !ipv4 {
+		defaultAddress = "::"
+	}
+	values, err := reader.env.CSV("LISTENING_ADDRESSES", params.Default(defaultAddress))
+	if err != nil {
+		return nil, fmt.Errorf("environment variable LISTENING_ADDRESSES: %w", err)
+	}
+	addresses = make([]netaddr.IP, len(values))
+	for i, value := range values {
+		addresses[i], err = netaddr.ParseIP(value)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable LISTENING_ADDRESSES: %w: %s",
+				errListeningAddressInvalid, err)
+		}
+	}
+	return addresses, nil
+}
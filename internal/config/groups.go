@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"inet.af/netaddr"
+)
+
+var (
+	errGroupNameInvalid   = errors.New("client group name is invalid")
+	errGroupNameDuplicate = errors.New("client group name is duplicated")
+	errGroupNoClient      = errors.New("client group has no subnet and no MAC address")
+	errGroupSubnetInvalid = errors.New("client group subnet is invalid")
+	errGroupMACInvalid    = errors.New("client group MAC address is invalid")
+)
+
+// nameRegex matches client group and schedule names usable
+// both as environment variable key parts and as Unbound tags.
+var nameRegex = regexp.MustCompile(`^[a-z0-9_]+$`) //nolint:gochecknoglobals
+
+// getClientGroups obtains the client groups from the comma separated
+// list of group names for the environment variable CLIENT_GROUPS.
+// Each group is then configured with environment variables prefixed
+// with CLIENT_GROUP_<NAME>_ such as CLIENT_GROUP_KIDS_SUBNETS.
+// The private IP addresses and IP networks given are also blocked
+// for each client group to keep the DNS rebinding protection.
+func getClientGroups(reader *reader, privateIPs []netaddr.IP,
+	privateIPPrefixes []netaddr.IPPrefix) (groups []blacklist.GroupBuilderSettings, err error) {
+	names, err := reader.env.CSV("CLIENT_GROUPS")
+	if err != nil {
+		return nil, fmt.Errorf("environment variable CLIENT_GROUPS: %w", err)
+	}
+
+	groups = make([]blacklist.GroupBuilderSettings, 0, len(names))
+	uniqueNames := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !nameRegex.MatchString(name) || name == "default" {
+			return nil, fmt.Errorf("environment variable CLIENT_GROUPS: %w: %s", errGroupNameInvalid, name)
+		}
+		if _, ok := uniqueNames[name]; ok {
+			return nil, fmt.Errorf("environment variable CLIENT_GROUPS: %w: %s", errGroupNameDuplicate, name)
+		}
+		uniqueNames[name] = struct{}{}
+
+		group, err := getClientGroup(reader, name)
+		if err != nil {
+			return nil, err
+		}
+		group.Blacklist.AddBlockedIPs = append(group.Blacklist.AddBlockedIPs, privateIPs...)
+		group.Blacklist.AddBlockedIPPrefixes = append(group.Blacklist.AddBlockedIPPrefixes, privateIPPrefixes...)
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func getClientGroup(reader *reader, name string) (group blacklist.GroupBuilderSettings, err error) {
+	keyPrefix := "CLIENT_GROUP_" + strings.ToUpper(name) + "_"
+
+	group.Clients.Name = name
+	group.Clients.Subnets, err = getClientGroupSubnets(reader, keyPrefix)
+	if err != nil {
+		return group, err
+	}
+	group.Clients.MACs, err = getClientGroupMACs(reader, keyPrefix)
+	if err != nil {
+		return group, err
+	}
+	if len(group.Clients.Subnets) == 0 && len(group.Clients.MACs) == 0 {
+		return group, fmt.Errorf("%w: %s", errGroupNoClient, name)
+	}
+
+	group.Blacklist, err = getBuilderSettings(reader, keyPrefix, "on")
+	if err != nil {
+		return group, err
+	}
+	group.Blacklist.SafeSearch, err = getSafeSearch(reader, keyPrefix)
+	if err != nil {
+		return group, err
+	}
+	group.Blacklist.Scheduled, err = getSchedules(reader, keyPrefix)
+	if err != nil {
+		return group, err
+	}
+
+	return group, nil
+}
+
+func getClientGroupSubnets(reader *reader, keyPrefix string) (
+	subnets []netaddr.IPPrefix, err error) {
+	key := keyPrefix + "SUBNETS"
+	values, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	ips, subnets, err := convertStringsToIPs(values)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w: %s", key, errGroupSubnetInvalid, err)
+	}
+	for _, ip := range ips {
+		subnets = append(subnets, netaddr.IPPrefix{IP: ip, Bits: ip.BitLen()})
+	}
+	return subnets, nil
+}
+
+func getClientGroupMACs(reader *reader, keyPrefix string) (
+	macs []net.HardwareAddr, err error) {
+	key := keyPrefix + "MACS"
+	values, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	macs = make([]net.HardwareAddr, len(values))
+	for i, value := range values {
+		macs[i], err = net.ParseMAC(value)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w: %s", key, errGroupMACInvalid, err)
+		}
+	}
+	return macs, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/qdm12/dns/pkg/localdata"
+)
+
+// getLocalData obtains the sources of the local records from the comma
+// separated list of records for the environment variable LOCAL_RECORDS,
+// and the file paths of the environment variables LOCAL_RECORDS_FILE
+// and HOSTS_FILE.
+func getLocalData(reader *reader) (settings localdata.LoaderSettings, err error) {
+	settings.Records, err = reader.env.CSV("LOCAL_RECORDS")
+	if err != nil {
+		return settings, fmt.Errorf("environment variable LOCAL_RECORDS: %w", err)
+	}
+	_, err = localdata.ParseRecords(settings.Records)
+	if err != nil {
+		return settings, fmt.Errorf("environment variable LOCAL_RECORDS: %w", err)
+	}
+
+	settings.RecordsFilepath, err = reader.env.Get("LOCAL_RECORDS_FILE")
+	if err != nil {
+		return settings, fmt.Errorf("environment variable LOCAL_RECORDS_FILE: %w", err)
+	}
+
+	settings.HostsFilepath, err = reader.env.Get("HOSTS_FILE")
+	if err != nil {
+		return settings, fmt.Errorf("environment variable HOSTS_FILE: %w", err)
+	}
+
+	return settings, nil
+}
@@ -1,16 +1,40 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"strings"
 
 	"github.com/qdm12/dns/pkg/provider"
 	"github.com/qdm12/golibs/params"
 )
 
+var (
+	errProviderNoDoT               = errors.New("provider has no DNS over TLS server, which Unbound requires")
+	errProviderPinned              = errors.New("provider DNS over TLS server has certificate pins or authorities, which Unbound cannot enforce")
+	errCustomProviderNameInvalid   = errors.New("custom provider name is invalid")
+	errCustomProviderNameDuplicate = errors.New("custom provider name is duplicated")
+	errCustomProviderNameReserved  = errors.New("custom provider name is reserved for a built-in provider")
+	errCustomProviderIPInvalid     = errors.New("custom provider IP address is invalid")
+)
+
+// retroProviderNames maps the former provider names
+// to the names of their built-in providers.
+var retroProviderNames = map[string]string{ //nolint:gochecknoglobals
+	"cleanbrowsing": "cleanbrowsing security",
+	"cira":          "cira private",
+}
+
 // getProviders obtains the DNS over TLS providers to use
 // from the environment variable PROVIDERS and PROVIDER for retro-compatibility.
 func getProviders(reader *reader) (providers []provider.Provider, err error) {
+	customProviders, err := getCustomProviders(reader)
+	if err != nil {
+		return nil, err
+	}
+
 	words, err := reader.env.CSV("PROVIDERS", params.Default("cloudflare"),
 		params.RetroKeys([]string{"PROVIDER"}, reader.onRetroActive))
 	if err != nil {
@@ -19,20 +43,165 @@ func getProviders(reader *reader) (providers []provider.Provider, err error) {
 
 	for _, word := range words {
 		// Retro compatibility
-		word = strings.ReplaceAll(word, ".", " ")
-		switch strings.ToLower(word) {
-		case "cleanbrowsing":
-			word = "cleanbrowsing security"
-		case "cira":
-			word = "cira private"
+		if !strings.HasPrefix(word, "sdns://") {
+			word = strings.ReplaceAll(word, ".", " ")
+		}
+		if retroName, ok := retroProviderNames[strings.ToLower(word)]; ok {
+			word = retroName
 		}
 
-		provider, err := provider.Parse(word)
+		provider, err := provider.Parse(word, customProviders...)
 		if err != nil {
 			return nil, fmt.Errorf("environment variable PROVIDERS: %w", err)
 		}
 
+		// Unbound only uses the DNS over TLS servers
+		dotServer := provider.DoT()
+		if len(dotServer.IPv4)+len(dotServer.IPv6) == 0 {
+			return nil, fmt.Errorf("environment variable PROVIDERS: %w: %s only offers %s",
+				errProviderNoDoT, provider, strings.Join(offeredProtocols(provider), ", "))
+		}
+
+		// Unbound only verifies the server name of the certificate, so
+		// refuse to silently drop pins or certificate authorities.
+		if len(dotServer.SPKIPins)+len(dotServer.CertificateHashes) > 0 ||
+			dotServer.RootCAs != nil {
+			return nil, fmt.Errorf("environment variable PROVIDERS: %w: %s",
+				errProviderPinned, provider)
+		}
+
 		providers = append(providers, provider)
 	}
 	return providers, nil
 }
+
+// offeredProtocols returns the names of the protocols,
+// other than DNS over TLS, offered by the provider given.
+func offeredProtocols(provider provider.Provider) (protocols []string) {
+	if dns := provider.DNS(); len(dns.IPv4)+len(dns.IPv6) > 0 {
+		protocols = append(protocols, "plaintext DNS")
+	}
+	if provider.DoH().URL != nil {
+		protocols = append(protocols, "DNS over HTTPS")
+	}
+	if doq := provider.DoQ(); len(doq.IPv4)+len(doq.IPv6) > 0 {
+		protocols = append(protocols, "DNS over QUIC")
+	}
+	if dnsCrypt := provider.DNSCrypt(); len(dnsCrypt.IPv4)+len(dnsCrypt.IPv6) > 0 {
+		protocols = append(protocols, "DNSCrypt")
+	}
+	return protocols
+}
+
+// getCustomProviders obtains the custom providers from the comma separated
+// list of provider names for the environment variable CUSTOM_PROVIDERS.
+// Each provider is then configured with environment variables prefixed
+// with CUSTOM_PROVIDER_<NAME>_ such as CUSTOM_PROVIDER_NEXTDNS_DOT_NAME.
+func getCustomProviders(reader *reader) (providers []provider.Provider, err error) {
+	names, err := reader.env.CSV("CUSTOM_PROVIDERS")
+	if err != nil {
+		return nil, fmt.Errorf("environment variable CUSTOM_PROVIDERS: %w", err)
+	}
+
+	providers = make([]provider.Provider, 0, len(names))
+	uniqueNames := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !nameRegex.MatchString(name) {
+			return nil, fmt.Errorf("environment variable CUSTOM_PROVIDERS: %w: %s",
+				errCustomProviderNameInvalid, name)
+		}
+		if _, ok := uniqueNames[name]; ok {
+			return nil, fmt.Errorf("environment variable CUSTOM_PROVIDERS: %w: %s",
+				errCustomProviderNameDuplicate, name)
+		}
+		if _, ok := retroProviderNames[name]; ok {
+			return nil, fmt.Errorf("environment variable CUSTOM_PROVIDERS: %w: %s",
+				errCustomProviderNameReserved, name)
+		}
+		uniqueNames[name] = struct{}{}
+
+		customProvider, err := getCustomProvider(reader, name)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, customProvider)
+	}
+
+	return providers, nil
+}
+
+func getCustomProvider(reader *reader, name string) (customProvider provider.Provider, err error) {
+	keyPrefix := "CUSTOM_PROVIDER_" + strings.ToUpper(name) + "_"
+	settings := provider.CustomSettings{Name: name}
+
+	key := keyPrefix + "DNS_IPS"
+	settings.DNS.IPv4, settings.DNS.IPv6, err = getIPs(reader, key)
+	if err != nil {
+		return nil, err
+	}
+
+	key = keyPrefix + "DOT_NAME"
+	settings.DoT.Name, err = reader.env.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	key = keyPrefix + "DOT_IPS"
+	settings.DoT.IPv4, settings.DoT.IPv6, err = getIPs(reader, key)
+	if err != nil {
+		return nil, err
+	}
+
+	key = keyPrefix + "DOT_PORT"
+	settings.DoT.Port, err = reader.env.Port(key, params.Default("853"))
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	key = keyPrefix + "DOH_URL"
+	dohURL, err := reader.env.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	} else if dohURL != "" {
+		settings.DoH.URL, err = url.Parse(dohURL)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", key, err)
+		}
+	}
+
+	key = keyPrefix + "DOH_IPS"
+	settings.DoH.IPv4, settings.DoH.IPv6, err = getIPs(reader, key)
+	if err != nil {
+		return nil, err
+	}
+
+	customProvider, err = provider.NewCustom(settings)
+	if err != nil {
+		return nil, fmt.Errorf("environment variables %s*: %w", keyPrefix, err)
+	}
+	return customProvider, nil
+}
+
+// getIPs obtains the IPv4 and IPv6 addresses from the comma
+// separated list of IP addresses for the environment variable key.
+func getIPs(reader *reader, key string) (ipv4, ipv6 []net.IP, err error) {
+	values, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	for _, value := range values {
+		ip := net.ParseIP(value)
+		switch {
+		case ip == nil:
+			return nil, nil, fmt.Errorf("environment variable %s: %w: %s",
+				key, errCustomProviderIPInvalid, value)
+		case ip.To4() != nil:
+			ipv4 = append(ipv4, ip.To4())
+		default:
+			ipv6 = append(ipv6, ip)
+		}
+	}
+	return ipv4, ipv6, nil
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+)
+
+var (
+	errScheduleNameInvalid   = errors.New("schedule name is invalid")
+	errScheduleNameDuplicate = errors.New("schedule name is duplicated")
+	errScheduleDayInvalid    = errors.New("schedule day is invalid")
+	errScheduleTimeMissing   = errors.New("schedule time is missing")
+	errScheduleTimeInvalid   = errors.New("schedule time is invalid")
+	errScheduleZoneInvalid   = errors.New("schedule time zone is invalid")
+)
+
+// getSchedules obtains the scheduled blacklists from the comma separated
+// list of schedule names for the environment variable <keyPrefix>SCHEDULES.
+// Each scheduled blacklist is then configured with environment variables
+// prefixed with <keyPrefix>SCHEDULE_<NAME>_ such as SCHEDULE_BEDTIME_START.
+func getSchedules(reader *reader, keyPrefix string) (
+	scheduled []blacklist.ScheduledBuilderSettings, err error) {
+	key := keyPrefix + "SCHEDULES"
+	names, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	scheduled = make([]blacklist.ScheduledBuilderSettings, len(names))
+	uniqueNames := make(map[string]struct{}, len(names))
+	for i, name := range names {
+		name = strings.ToLower(name)
+		if !nameRegex.MatchString(name) {
+			return nil, fmt.Errorf("environment variable %s: %w: %s", key, errScheduleNameInvalid, name)
+		}
+		if _, ok := uniqueNames[name]; ok {
+			return nil, fmt.Errorf("environment variable %s: %w: %s", key, errScheduleNameDuplicate, name)
+		}
+		uniqueNames[name] = struct{}{}
+
+		scheduleKeyPrefix := keyPrefix + "SCHEDULE_" + strings.ToUpper(name) + "_"
+		scheduled[i].Name = name
+		scheduled[i].Schedule, err = getSchedule(reader, scheduleKeyPrefix)
+		if err != nil {
+			return nil, err
+		}
+		scheduled[i].Blacklist, err = getBuilderSettings(reader, scheduleKeyPrefix, "off")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return scheduled, nil
+}
+
+func getSchedule(reader *reader, keyPrefix string) (schedule blacklist.Schedule, err error) {
+	schedule.Days, err = getScheduleDays(reader, keyPrefix)
+	if err != nil {
+		return schedule, err
+	}
+	schedule.Start, err = getScheduleTime(reader, keyPrefix+"START")
+	if err != nil {
+		return schedule, err
+	}
+	schedule.End, err = getScheduleTime(reader, keyPrefix+"END")
+	if err != nil {
+		return schedule, err
+	}
+
+	key := keyPrefix + "TIMEZONE"
+	timezone, err := reader.env.Get(key)
+	if err != nil {
+		return schedule, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	if timezone != "" {
+		schedule.Location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return schedule, fmt.Errorf("environment variable %s: %w: %s", key, errScheduleZoneInvalid, err)
+		}
+	}
+
+	return schedule, nil
+}
+
+func getScheduleDays(reader *reader, keyPrefix string) (days []time.Weekday, err error) {
+	key := keyPrefix + "DAYS"
+	values, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	days = make([]time.Weekday, len(values))
+	for i, value := range values {
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(value, day.String()[:3]) {
+				days[i] = day
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("environment variable %s: %w: %s", key, errScheduleDayInvalid, value)
+		}
+	}
+	return days, nil
+}
+
+// getScheduleTime obtains a time of day in the format hh:mm
+// from the environment variable for the key given.
+func getScheduleTime(reader *reader, key string) (timeOfDay time.Duration, err error) {
+	value, err := reader.env.Get(key)
+	if err != nil {
+		return 0, fmt.Errorf("environment variable %s: %w", key, err)
+	} else if value == "" {
+		return 0, fmt.Errorf("environment variable %s: %w", key, errScheduleTimeMissing)
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("environment variable %s: %w: %s", key, errScheduleTimeInvalid, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
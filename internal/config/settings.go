@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/localdata"
 	"github.com/qdm12/dns/pkg/unbound"
 	"github.com/qdm12/golibs/params"
 )
@@ -12,6 +13,7 @@ import (
 type Settings struct {
 	Unbound      unbound.Settings
 	Blacklist    blacklist.BuilderSettings
+	LocalData    localdata.LoaderSettings
 	CheckDNS     bool
 	UpdatePeriod time.Duration
 }
@@ -27,6 +29,13 @@ func (settings *Settings) get(reader *reader) (err error) {
 	if err != nil {
 		return err
 	}
+	// Forwarded internal domains usually resolve to private IP addresses.
+	settings.Blacklist.PrivateHostnames = append(settings.Blacklist.PrivateHostnames,
+		settings.Unbound.Forward.FqdnDomains()...)
+	settings.LocalData, err = getLocalData(reader)
+	if err != nil {
+		return err
+	}
 	settings.CheckDNS, err = reader.env.OnOff("CHECK_DNS", params.Default("on"),
 		params.RetroKeys([]string{"CHECK_UNBOUND"}, reader.onRetroActive))
 	if err != nil {
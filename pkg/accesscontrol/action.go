@@ -0,0 +1,43 @@
+package accesscontrol
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Action is the action to take for the clients of a subnet,
+// using the same names as the Unbound access-control actions.
+type Action string
+
+const (
+	// Allow answers recursive queries and refuses non-recursive
+	// queries, which could be used to snoop the cache.
+	Allow Action = "allow"
+	// AllowSnoop answers both recursive and non-recursive queries.
+	AllowSnoop Action = "allow_snoop"
+	// Refuse answers with a REFUSED response code.
+	Refuse Action = "refuse"
+	// Deny drops the query without answering it.
+	Deny Action = "deny"
+)
+
+func ListActions() (actions []Action) {
+	return []Action{
+		Allow,
+		AllowSnoop,
+		Refuse,
+		Deny,
+	}
+}
+
+var ErrParseAction = errors.New("cannot parse access control action")
+
+func ParseAction(s string) (action Action, err error) {
+	for _, a := range ListActions() {
+		if strings.EqualFold(string(a), s) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is unknown", ErrParseAction, s)
+}
@@ -0,0 +1,59 @@
+package accesscontrol
+
+import (
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Checker
+
+// Checker finds the access control action to take for a client.
+type Checker interface {
+	// Action returns the action of the rule with the most specific subnet
+	// containing the client IP address given. If no rule matches, loopback
+	// addresses are allowed and other addresses are refused, as Unbound does.
+	Action(clientIP netaddr.IP) (action Action)
+}
+
+type checker struct {
+	rules []Rule
+}
+
+func NewChecker(settings Settings) Checker {
+	return &checker{
+		rules: settings.Rules,
+	}
+}
+
+func (c *checker) Action(clientIP netaddr.IP) (action Action) {
+	bits := -1
+	for _, rule := range c.rules {
+		if int(rule.Subnet.Bits) > bits && rule.Subnet.Contains(clientIP) {
+			action = rule.Action
+			bits = int(rule.Subnet.Bits)
+		}
+	}
+
+	if bits == -1 {
+		if clientIP.IsLoopback() {
+			return Allow
+		}
+		return Refuse
+	}
+
+	return action
+}
+
+// Answers returns true if a query should be answered given the action.
+// A query which is not answered should be refused, unless the action
+// is Deny in which case it should be dropped.
+func Answers(action Action, request *dns.Msg) (answer bool) {
+	switch action {
+	case AllowSnoop:
+		return true
+	case Allow:
+		return request.RecursionDesired
+	default:
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package accesscontrol
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_checker_Action(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		Rules: []Rule{
+			{Subnet: netaddr.MustParseIPPrefix("0.0.0.0/0"), Action: Refuse},
+			{Subnet: netaddr.MustParseIPPrefix("192.168.0.0/16"), Action: Allow},
+			{Subnet: netaddr.MustParseIPPrefix("192.168.1.0/24"), Action: AllowSnoop},
+			{Subnet: netaddr.MustParseIPPrefix("192.168.1.66/32"), Action: Deny},
+			{Subnet: netaddr.MustParseIPPrefix("fd00::/8"), Action: Allow},
+		},
+	}
+	checker := NewChecker(settings)
+
+	testCases := map[string]struct {
+		ip     netaddr.IP
+		action Action
+	}{
+		"least specific rule": {
+			ip:     netaddr.MustParseIP("1.2.3.4"),
+			action: Refuse,
+		},
+		"more specific rule": {
+			ip:     netaddr.MustParseIP("192.168.2.1"),
+			action: Allow,
+		},
+		"most specific rule": {
+			ip:     netaddr.MustParseIP("192.168.1.1"),
+			action: AllowSnoop,
+		},
+		"single IP rule": {
+			ip:     netaddr.MustParseIP("192.168.1.66"),
+			action: Deny,
+		},
+		"IPv6 rule": {
+			ip:     netaddr.MustParseIP("fd00::1"),
+			action: Allow,
+		},
+		"no rule for IPv6 loopback": {
+			ip:     netaddr.MustParseIP("::1"),
+			action: Allow,
+		},
+		"no rule for IPv6 address": {
+			ip:     netaddr.MustParseIP("2001:db8::1"),
+			action: Refuse,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			action := checker.Action(testCase.ip)
+			assert.Equal(t, testCase.action, action)
+		})
+	}
+}
+
+func Test_Answers(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		action           Action
+		recursionDesired bool
+		answer           bool
+	}{
+		"allow recursive":           {action: Allow, recursionDesired: true, answer: true},
+		"allow non recursive":       {action: Allow},
+		"allow snoop recursive":     {action: AllowSnoop, recursionDesired: true, answer: true},
+		"allow snoop non recursive": {action: AllowSnoop, answer: true},
+		"refuse":                    {action: Refuse, recursionDesired: true},
+		"deny":                      {action: Deny, recursionDesired: true},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+			request.RecursionDesired = testCase.recursionDesired
+			answer := Answers(testCase.action, request)
+			assert.Equal(t, testCase.answer, answer)
+		})
+	}
+}
+
+func Test_ParseAction(t *testing.T) {
+	t.Parallel()
+
+	action, err := ParseAction("Allow_Snoop")
+	assert.NoError(t, err)
+	assert.Equal(t, AllowSnoop, action)
+
+	_, err = ParseAction("block")
+	assert.EqualError(t, err, `cannot parse access control action: "block" is unknown`)
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/accesscontrol (interfaces: Checker)
+
+// Package mock_accesscontrol is a generated GoMock package.
+package mock_accesscontrol
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	accesscontrol "github.com/qdm12/dns/pkg/accesscontrol"
+	netaddr "inet.af/netaddr"
+)
+
+// MockChecker is a mock of Checker interface.
+type MockChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockCheckerMockRecorder
+}
+
+// MockCheckerMockRecorder is the mock recorder for MockChecker.
+type MockCheckerMockRecorder struct {
+	mock *MockChecker
+}
+
+// NewMockChecker creates a new mock instance.
+func NewMockChecker(ctrl *gomock.Controller) *MockChecker {
+	mock := &MockChecker{ctrl: ctrl}
+	mock.recorder = &MockCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChecker) EXPECT() *MockCheckerMockRecorder {
+	return m.recorder
+}
+
+// Action mocks base method.
+func (m *MockChecker) Action(arg0 netaddr.IP) accesscontrol.Action {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Action", arg0)
+	ret0, _ := ret[0].(accesscontrol.Action)
+	return ret0
+}
+
+// Action indicates an expected call of Action.
+func (mr *MockCheckerMockRecorder) Action(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Action", reflect.TypeOf((*MockChecker)(nil).Action), arg0)
+}
@@ -0,0 +1,61 @@
+package accesscontrol
+
+import (
+	"strings"
+
+	"inet.af/netaddr"
+)
+
+type Settings struct {
+	// Rules are the access control rules, where the rule with the
+	// most specific subnet containing the client IP address applies.
+	Rules []Rule
+}
+
+type Rule struct {
+	Subnet netaddr.IPPrefix
+	Action Action
+}
+
+// SetDefaults allows loopback and private clients if no rule
+// is set, and refuses other clients, so the server is not an open
+// resolver if it is reachable from the Internet.
+func (s *Settings) SetDefaults() {
+	if len(s.Rules) == 0 {
+		s.Rules = DefaultRules()
+	}
+}
+
+// DefaultRules returns the rules allowing the loopback and private
+// subnets, that is the RFC 1918 IPv4 subnets, the unique local IPv6
+// subnet fc00::/7 and the link local IPv6 subnet fe80::/10,
+// and refusing all other subnets.
+func DefaultRules() (rules []Rule) {
+	allowed := []string{
+		"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16",
+		"::1/128", "fc00::/7", "fe80::/10",
+	}
+	rules = make([]Rule, 0, len(allowed)+2) //nolint:gomnd
+	for _, subnet := range allowed {
+		rules = append(rules, Rule{Subnet: netaddr.MustParseIPPrefix(subnet), Action: Allow})
+	}
+	return append(rules,
+		Rule{Subnet: netaddr.IPPrefix{IP: netaddr.IPv4(0, 0, 0, 0)}, Action: Refuse},
+		Rule{Subnet: netaddr.IPPrefix{IP: netaddr.IPv6Unspecified()}, Action: Refuse},
+	)
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	for _, rule := range s.Rules {
+		lines = append(lines, subSection+rule.Subnet.String()+": "+string(rule.Action))
+	}
+	return lines
+}
@@ -1,10 +1,19 @@
 package blacklist
 
-import "github.com/miekg/dns"
+import (
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
 
-//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . BlackLister
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . BlackLister,Builder,Selector,Setter
 
 type BlackLister interface {
 	FilterRequest(request *dns.Msg) (blocked bool)
 	FilterResponse(response *dns.Msg) (blocked bool)
 }
+
+// Selector selects the BlackLister to use for a client,
+// and whether safe search should be enforced for the client.
+type Selector interface {
+	Select(clientIP netaddr.IP, request *dns.Msg) (blackLister BlackLister, safeSearch bool)
+}
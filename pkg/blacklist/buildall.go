@@ -25,7 +25,8 @@ func (b *builder) All(ctx context.Context, settings BuilderSettings) (
 	go func() {
 		blockedIPs, blockedIPPrefixes, errs := b.IPs(ctx,
 			settings.BlockMalicious, settings.BlockAds, settings.BlockSurveillance,
-			settings.AddBlockedIPs, settings.AddBlockedIPPrefixes)
+			settings.AddBlockedIPs, settings.AddBlockedIPPrefixes,
+			settings.AllowedIPs, settings.AllowedIPPrefixes)
 		chIPs <- blockedIPs
 		chIPPrefixes <- blockedIPPrefixes
 		chErrors <- errs
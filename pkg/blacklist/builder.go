@@ -17,8 +17,13 @@ type Builder interface {
 		blockedHostnames []string, errs []error)
 	IPs(ctx context.Context,
 		blockMalicious, blockAds, blockSurveillance bool,
-		additionalBlockedIPs []netaddr.IP, additionalBlockedIPPrefixes []netaddr.IPPrefix) (
+		additionalBlockedIPs []netaddr.IP, additionalBlockedIPPrefixes []netaddr.IPPrefix,
+		allowedIPs []netaddr.IP, allowedIPPrefixes []netaddr.IPPrefix) (
 		blockedIPs []netaddr.IP, blockedIPPrefixes []netaddr.IPPrefix, errs []error)
+	Groups(ctx context.Context, groups []GroupBuilderSettings) (
+		groupsSettings []GroupSettings, errs []error)
+	Scheduled(ctx context.Context, scheduled []ScheduledBuilderSettings) (
+		scheduledSettings []ScheduledSettings, errs []error)
 }
 
 func NewBuilder(client *http.Client) Builder {
@@ -11,10 +11,19 @@ type BuilderSettings struct {
 	BlockMalicious       bool
 	BlockAds             bool
 	BlockSurveillance    bool
+	SafeSearch           bool
 	AllowedHosts         []string
+	AllowedIPs           []netaddr.IP
+	AllowedIPPrefixes    []netaddr.IPPrefix
 	AddBlockedHosts      []string
 	AddBlockedIPs        []netaddr.IP
 	AddBlockedIPPrefixes []netaddr.IPPrefix
+	// PrivateHostnames are hostnames, and their subdomains, whose responses
+	// are not filtered by IP address, to allow internal hostnames to resolve
+	// to private addresses. It is only used at the top level.
+	PrivateHostnames []string
+	Groups           []GroupBuilderSettings
+	Scheduled        []ScheduledBuilderSettings
 }
 
 func (s *BuilderSettings) String() string {
@@ -38,11 +47,25 @@ func (s *BuilderSettings) Lines(indent, subSection string) (lines []string) {
 	}
 	lines = append(lines, subSection+"Blocked categories: "+strings.Join(blockedCategories, ", "))
 
+	if s.SafeSearch {
+		lines = append(lines, subSection+"Safe search: enforced")
+	}
+
 	if len(s.AllowedHosts) > 0 {
 		lines = append(lines, subSection+"Hostnames unblocked: "+
 			strconv.Itoa(len(s.AllowedHosts)))
 	}
 
+	if len(s.AllowedIPs) > 0 {
+		lines = append(lines, subSection+"IP addresses unblocked: "+
+			strconv.Itoa(len(s.AllowedIPs)))
+	}
+
+	if len(s.AllowedIPPrefixes) > 0 {
+		lines = append(lines, subSection+"IP networks unblocked: "+
+			strconv.Itoa(len(s.AllowedIPPrefixes)))
+	}
+
 	if len(s.AddBlockedHosts) > 0 {
 		lines = append(lines, subSection+"Additional hostnames blocked: "+
 			strconv.Itoa(len(s.AddBlockedHosts)))
@@ -58,5 +81,18 @@ func (s *BuilderSettings) Lines(indent, subSection string) (lines []string) {
 			strconv.Itoa(len(s.AddBlockedIPPrefixes)))
 	}
 
+	if len(s.PrivateHostnames) > 0 {
+		lines = append(lines, subSection+"Private domains: "+
+			strings.Join(s.PrivateHostnames, ", "))
+	}
+
+	for _, scheduled := range s.Scheduled {
+		lines = append(lines, scheduled.Lines(indent, subSection)...)
+	}
+
+	for _, group := range s.Groups {
+		lines = append(lines, group.Lines(indent, subSection)...)
+	}
+
 	return lines
 }
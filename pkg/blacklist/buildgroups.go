@@ -0,0 +1,28 @@
+package blacklist
+
+import (
+	"context"
+	"fmt"
+)
+
+func (b *builder) Groups(ctx context.Context, groups []GroupBuilderSettings) (
+	groupsSettings []GroupSettings, errs []error) {
+	groupsSettings = make([]GroupSettings, len(groups))
+	for i, group := range groups {
+		blockedHostnames, blockedIPs, blockedIPPrefixes, groupErrs :=
+			b.All(ctx, group.Blacklist)
+		scheduled, scheduledErrs := b.Scheduled(ctx, group.Blacklist.Scheduled)
+		groupErrs = append(groupErrs, scheduledErrs...)
+		for _, err := range groupErrs {
+			errs = append(errs, fmt.Errorf("client group %s: %w", group.Clients.Name, err))
+		}
+
+		groupsSettings[i].Clients = group.Clients
+		groupsSettings[i].Blacklist.BlockHostnames(blockedHostnames)
+		groupsSettings[i].Blacklist.IPs = blockedIPs
+		groupsSettings[i].Blacklist.IPPrefixes = blockedIPPrefixes
+		groupsSettings[i].Blacklist.SafeSearch = group.Blacklist.SafeSearch
+		groupsSettings[i].Blacklist.Scheduled = scheduled
+	}
+	return groupsSettings, errs
+}
@@ -15,7 +15,8 @@ const (
 
 func (b *builder) IPs(ctx context.Context,
 	blockMalicious, blockAds, blockSurveillance bool,
-	additionalBlockedIPs []netaddr.IP, additionalBlockedIPPrefixes []netaddr.IPPrefix) (
+	additionalBlockedIPs []netaddr.IP, additionalBlockedIPPrefixes []netaddr.IPPrefix,
+	allowedIPs []netaddr.IP, allowedIPPrefixes []netaddr.IPPrefix) (
 	blockedIPs []netaddr.IP, blockedIPPrefixes []netaddr.IPPrefix, errs []error) {
 	chResults := make(chan []string)
 	chError := make(chan error)
@@ -84,13 +85,32 @@ func (b *builder) IPs(ctx context.Context,
 		}
 	}
 
+	// Remove the allowed IP addresses and IP prefixes, splitting blocked
+	// IP prefixes as needed, merge overlapping and adjacent IP prefixes,
+	// and remove IP addresses already contained in one of the prefixes.
+	trie := newIPPrefixTrie(blockedIPPrefixes)
+	allowedTrie := newIPPrefixTrie(allowedIPPrefixes)
+	for _, allowedIPPrefix := range allowedIPPrefixes {
+		trie.remove(allowedIPPrefix)
+	}
+	for _, allowedIP := range allowedIPs {
+		allowedIPPrefix := netaddr.IPPrefix{IP: allowedIP, Bits: allowedIP.BitLen()}
+		trie.remove(allowedIPPrefix)
+		allowedTrie.insert(allowedIPPrefix)
+	}
+	blockedIPPrefixes = trie.prefixes()
+	i := 0
+	for _, blockedIP := range blockedIPs {
+		if !trie.contains(blockedIP) && !allowedTrie.contains(blockedIP) {
+			blockedIPs[i] = blockedIP
+			i++
+		}
+	}
+	blockedIPs = blockedIPs[:i]
+
 	sort.Slice(blockedIPs, func(i, j int) bool {
 		return blockedIPs[i].Compare(blockedIPs[j]) < 0
 	})
 
-	sort.Slice(blockedIPPrefixes, func(i, j int) bool {
-		return blockedIPPrefixes[i].String() < blockedIPPrefixes[j].String()
-	})
-
 	return blockedIPs, blockedIPPrefixes, errs
 }
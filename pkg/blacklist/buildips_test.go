@@ -26,6 +26,8 @@ func Test_builder_IPs(t *testing.T) {
 		surveillance                blockParams
 		additionalBlockedIPs        []netaddr.IP
 		additionalBlockedIPPrefixes []netaddr.IPPrefix
+		allowedIPs                  []netaddr.IP
+		allowedIPPrefixes           []netaddr.IPPrefix
 		blockedIPs                  []string // string format for easier comparison
 		blockedIPPrefixes           []string // string format for easier comparison
 		errsString                  []string // string format for easier comparison
@@ -37,7 +39,7 @@ func Test_builder_IPs(t *testing.T) {
 				content: []byte("1.2.3.4\n99.99.99.99/24"),
 			},
 			blockedIPs:        []string{"1.2.3.4"},
-			blockedIPPrefixes: []string{"99.99.99.99/24"},
+			blockedIPPrefixes: []string{"99.99.99.0/24"},
 		},
 		"all blocked with some duplicates": {
 			malicious: blockParams{
@@ -53,7 +55,7 @@ func Test_builder_IPs(t *testing.T) {
 				content: []byte("254.254.254.1\n1.2.3.4"),
 			},
 			blockedIPs:        []string{"1.2.3.4", "254.254.254.1"},
-			blockedIPPrefixes: []string{"66.67.68.10/28"},
+			blockedIPPrefixes: []string{"66.67.68.0/28"},
 		},
 		"all blocked with one errored": {
 			malicious: blockParams{
@@ -69,7 +71,7 @@ func Test_builder_IPs(t *testing.T) {
 				clientErr: fmt.Errorf("surveillance error"),
 			},
 			blockedIPs:        []string{"1.2.3.4", "254.254.254.1"},
-			blockedIPPrefixes: []string{"66.67.68.10/28"},
+			blockedIPPrefixes: []string{"66.67.68.0/28"},
 			errsString: []string{
 				`Get "https://raw.githubusercontent.com/qdm12/files/master/surveillance-ips.updated": surveillance error`,
 			},
@@ -89,7 +91,35 @@ func Test_builder_IPs(t *testing.T) {
 				Bits: 24,
 			}},
 			blockedIPs:        []string{"1.2.3.4", "254.254.254.1"},
-			blockedIPPrefixes: []string{"66.67.68.10/28", "55.55.55.0/24"},
+			blockedIPPrefixes: []string{"66.67.68.0/28", "55.55.55.0/24"},
+		},
+		"overlapping IP prefixes merged": {
+			malicious: blockParams{
+				blocked: true,
+				content: []byte("10.0.0.0/24\n10.0.1.0/24\n10.0.0.128/25\n10.0.1.1\n10.0.2.1"),
+			},
+			ads: blockParams{
+				blocked: true,
+				content: []byte("2001:db8::/33\n2001:db8:8000::/33"),
+			},
+			blockedIPs:        []string{"10.0.2.1"},
+			blockedIPPrefixes: []string{"10.0.0.0/23", "2001:db8::/32"},
+		},
+		"allowed IPs removed": {
+			malicious: blockParams{
+				blocked: true,
+				content: []byte("1.2.3.4\n1.2.3.5\n10.0.0.0/30\n20.0.0.0/24"),
+			},
+			allowedIPs: []netaddr.IP{
+				netaddr.IPv4(1, 2, 3, 4),
+				netaddr.IPv4(10, 0, 0, 1),
+			},
+			allowedIPPrefixes: []netaddr.IPPrefix{{
+				IP:   netaddr.IPv4(20, 0, 0, 128),
+				Bits: 25,
+			}},
+			blockedIPs:        []string{"1.2.3.5"},
+			blockedIPPrefixes: []string{"10.0.0.0/32", "10.0.0.2/31", "20.0.0.0/25"},
 		},
 	}
 	for name, tc := range tests {
@@ -155,7 +185,8 @@ func Test_builder_IPs(t *testing.T) {
 
 			blockedIPs, blockedIPPrefixes, errs := builder.IPs(ctx,
 				tc.malicious.blocked, tc.ads.blocked, tc.surveillance.blocked,
-				tc.additionalBlockedIPs, tc.additionalBlockedIPPrefixes)
+				tc.additionalBlockedIPs, tc.additionalBlockedIPPrefixes,
+				tc.allowedIPs, tc.allowedIPPrefixes)
 
 			assert.ElementsMatch(t, tc.blockedIPs, convertIPsToString(blockedIPs))
 			assert.ElementsMatch(t, tc.blockedIPPrefixes, convertIPPrefixesToString(blockedIPPrefixes))
@@ -0,0 +1,25 @@
+package blacklist
+
+import (
+	"context"
+	"fmt"
+)
+
+func (b *builder) Scheduled(ctx context.Context, scheduled []ScheduledBuilderSettings) (
+	scheduledSettings []ScheduledSettings, errs []error) {
+	scheduledSettings = make([]ScheduledSettings, len(scheduled))
+	for i, settings := range scheduled {
+		blockedHostnames, blockedIPs, blockedIPPrefixes, scheduledErrs :=
+			b.All(ctx, settings.Blacklist)
+		for _, err := range scheduledErrs {
+			errs = append(errs, fmt.Errorf("scheduled %s: %w", settings.Name, err))
+		}
+
+		scheduledSettings[i].Name = settings.Name
+		scheduledSettings[i].Schedule = settings.Schedule
+		scheduledSettings[i].Blacklist.BlockHostnames(blockedHostnames)
+		scheduledSettings[i].Blacklist.IPs = blockedIPs
+		scheduledSettings[i].Blacklist.IPPrefixes = blockedIPPrefixes
+	}
+	return scheduledSettings, errs
+}
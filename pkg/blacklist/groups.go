@@ -0,0 +1,70 @@
+package blacklist
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"inet.af/netaddr"
+)
+
+// ClientGroup identifies a group of clients by the subnets of their
+// source IP address and, optionally, by the MAC address forwarded by
+// a downstream DNS forwarder in the EDNS0 option 65001 (dnsmasq add-mac).
+type ClientGroup struct {
+	Name    string
+	Subnets []netaddr.IPPrefix
+	MACs    []net.HardwareAddr
+}
+
+// GroupSettings contains the blacklist settings for a client group.
+// Groups nested in the Blacklist settings field are ignored.
+type GroupSettings struct {
+	Clients   ClientGroup
+	Blacklist Settings
+}
+
+// GroupBuilderSettings contains the blacklist building settings
+// for a client group. Groups nested in the Blacklist field are ignored.
+type GroupBuilderSettings struct {
+	Clients   ClientGroup
+	Blacklist BuilderSettings
+}
+
+func (c *ClientGroup) Lines(indent, subSection string) (lines []string) {
+	if len(c.Subnets) > 0 {
+		subnets := make([]string, len(c.Subnets))
+		for i := range c.Subnets {
+			subnets[i] = c.Subnets[i].String()
+		}
+		lines = append(lines, subSection+"Subnets: "+strings.Join(subnets, ", "))
+	}
+
+	if len(c.MACs) > 0 {
+		lines = append(lines, subSection+"MAC addresses: "+strconv.Itoa(len(c.MACs)))
+	}
+
+	return lines
+}
+
+func (s *GroupSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Group "+s.Clients.Name+":")
+	for _, line := range s.Clients.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+	for _, line := range s.Blacklist.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+	return lines
+}
+
+func (s *GroupBuilderSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Group "+s.Clients.Name+":")
+	for _, line := range s.Clients.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+	for _, line := range s.Blacklist.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+	return lines
+}
@@ -0,0 +1,162 @@
+package blacklist
+
+import (
+	"net"
+
+	"inet.af/netaddr"
+)
+
+// ipPrefixTrie is a binary trie of IP prefixes, with one root for IPv4
+// prefixes and one root for IPv6 prefixes. It finds if an IP address is
+// contained in one of its prefixes in O(prefix length) time.
+type ipPrefixTrie struct {
+	ipv4 trieNode
+	ipv6 trieNode
+}
+
+type trieNode struct {
+	children [2]*trieNode
+	// terminal is true if a prefix ends at this node.
+	// A terminal node has no children since its
+	// prefix contains all the prefixes of its subtree.
+	terminal bool
+}
+
+func newIPPrefixTrie(ipPrefixes []netaddr.IPPrefix) (trie *ipPrefixTrie) {
+	trie = new(ipPrefixTrie)
+	for _, ipPrefix := range ipPrefixes {
+		trie.insert(ipPrefix)
+	}
+	return trie
+}
+
+func (t *ipPrefixTrie) root(ip netaddr.IP) (root *trieNode, bytes []byte) {
+	if ip.Is4() {
+		b := ip.As4()
+		return &t.ipv4, b[:]
+	}
+	b := ip.As16()
+	return &t.ipv6, b[:]
+}
+
+func bitAt(bytes []byte, i int) (bit byte) {
+	const bitsPerByte = 8
+	return (bytes[i/bitsPerByte] >> (bitsPerByte - 1 - i%bitsPerByte)) & 1
+}
+
+func (t *ipPrefixTrie) insert(ipPrefix netaddr.IPPrefix) {
+	node, bytes := t.root(ipPrefix.IP)
+	for i := 0; i < int(ipPrefix.Bits); i++ {
+		if node.terminal {
+			return // already contained in a shorter prefix
+		}
+		bit := bitAt(bytes, i)
+		if node.children[bit] == nil {
+			node.children[bit] = new(trieNode)
+		}
+		node = node.children[bit]
+	}
+	node.terminal = true
+	node.children = [2]*trieNode{}
+}
+
+// remove removes the IP prefix given from the trie, splitting
+// the prefixes containing it into smaller prefixes as needed.
+func (t *ipPrefixTrie) remove(ipPrefix netaddr.IPPrefix) {
+	node, bytes := t.root(ipPrefix.IP)
+	for i := 0; i < int(ipPrefix.Bits); i++ {
+		bit := bitAt(bytes, i)
+		if node.terminal {
+			node.terminal = false
+			node.children[0] = &trieNode{terminal: true}
+			node.children[1] = &trieNode{terminal: true}
+		} else if node.children[bit] == nil {
+			return // not contained in any prefix
+		}
+		node = node.children[bit]
+	}
+	node.terminal = false
+	node.children = [2]*trieNode{}
+}
+
+func (t *ipPrefixTrie) contains(ip netaddr.IP) (contained bool) {
+	node, bytes := t.root(ip)
+	maxBits := len(bytes) * 8 //nolint:gomnd
+	for i := 0; ; i++ {
+		if node.terminal {
+			return true
+		} else if i == maxBits {
+			return false
+		}
+		node = node.children[bitAt(bytes, i)]
+		if node == nil {
+			return false
+		}
+	}
+}
+
+// prefixes returns the minimal list of IP prefixes covering the same
+// IP addresses as the prefixes inserted, by merging sibling prefixes.
+// IPv4 prefixes are returned first, each family in ascending order.
+func (t *ipPrefixTrie) prefixes() (ipPrefixes []netaddr.IPPrefix) {
+	const ipv4Bytes, ipv6Bytes = 4, 16
+	ipPrefixes = appendTriePrefixes(ipPrefixes, &t.ipv4, make([]byte, ipv4Bytes), 0)
+	ipPrefixes = appendTriePrefixes(ipPrefixes, &t.ipv6, make([]byte, ipv6Bytes), 0)
+	return ipPrefixes
+}
+
+// appendTriePrefixes merges sibling prefixes of the subtree of node and
+// appends its prefixes to ipPrefixes, where bytes holds the bits of the
+// path to the node of length depth.
+func appendTriePrefixes(ipPrefixes []netaddr.IPPrefix, node *trieNode,
+	bytes []byte, depth int) []netaddr.IPPrefix {
+	mergeTrieNode(node)
+	if node.terminal {
+		return append(ipPrefixes, netaddr.IPPrefix{
+			IP:   ipFromBytes(bytes),
+			Bits: uint8(depth),
+		})
+	}
+
+	const bitsPerByte = 8
+	for bit, child := range node.children {
+		if child == nil {
+			continue
+		}
+		mask := byte(1) << (bitsPerByte - 1 - depth%bitsPerByte)
+		if bit == 1 {
+			bytes[depth/bitsPerByte] |= mask
+		}
+		ipPrefixes = appendTriePrefixes(ipPrefixes, child, bytes, depth+1)
+		bytes[depth/bitsPerByte] &^= mask
+	}
+	return ipPrefixes
+}
+
+// mergeTrieNode marks the node as terminal if its two
+// children are terminal once merged recursively.
+func mergeTrieNode(node *trieNode) {
+	if node.terminal {
+		return
+	}
+	for _, child := range node.children {
+		if child == nil {
+			return
+		}
+		mergeTrieNode(child)
+		if !child.terminal {
+			return
+		}
+	}
+	node.terminal = true
+	node.children = [2]*trieNode{}
+}
+
+func ipFromBytes(bytes []byte) (ip netaddr.IP) {
+	const ipv4Bytes = 4
+	if len(bytes) == ipv4Bytes {
+		return netaddr.IPv4(bytes[0], bytes[1], bytes[2], bytes[3])
+	}
+	ip, _ = netaddr.FromStdIPRaw(net.IP(bytes))
+	return ip
+}
@@ -0,0 +1,131 @@
+package blacklist
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_ipPrefixTrie(t *testing.T) {
+	t.Parallel()
+
+	trie := newIPPrefixTrie([]netaddr.IPPrefix{
+		netaddr.MustParseIPPrefix("10.0.0.0/25"),
+		netaddr.MustParseIPPrefix("10.0.0.128/25"),
+		netaddr.MustParseIPPrefix("10.0.0.64/26"),
+		netaddr.MustParseIPPrefix("192.168.1.1/32"),
+		netaddr.MustParseIPPrefix("2001:db8::/32"),
+		netaddr.MustParseIPPrefix("::ffff:0:0/96"),
+	})
+
+	testCases := map[string]struct {
+		ip        netaddr.IP
+		contained bool
+	}{
+		"IPv4 in prefix": {
+			ip:        netaddr.MustParseIP("10.0.0.200"),
+			contained: true,
+		},
+		"IPv4 not in prefix": {
+			ip: netaddr.MustParseIP("10.0.1.0"),
+		},
+		"IPv4 in single IP prefix": {
+			ip:        netaddr.MustParseIP("192.168.1.1"),
+			contained: true,
+		},
+		"IPv4 next to single IP prefix": {
+			ip: netaddr.MustParseIP("192.168.1.2"),
+		},
+		"IPv6 in prefix": {
+			ip:        netaddr.MustParseIP("2001:db8:1::1"),
+			contained: true,
+		},
+		"IPv6 not in prefix": {
+			ip: netaddr.MustParseIP("2001:db9::1"),
+		},
+		"IPv4 not in IPv4-mapped IPv6 prefix": {
+			ip: netaddr.MustParseIP("1.2.3.4"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			contained := trie.contains(testCase.ip)
+
+			assert.Equal(t, testCase.contained, contained)
+		})
+	}
+
+	expectedPrefixes := []netaddr.IPPrefix{
+		netaddr.MustParseIPPrefix("10.0.0.0/24"),
+		netaddr.MustParseIPPrefix("192.168.1.1/32"),
+		netaddr.MustParseIPPrefix("::ffff:0:0/96"),
+		netaddr.MustParseIPPrefix("2001:db8::/32"),
+	}
+	assert.Equal(t, expectedPrefixes, trie.prefixes())
+}
+
+func Test_ipPrefixTrie_remove(t *testing.T) {
+	t.Parallel()
+
+	trie := newIPPrefixTrie([]netaddr.IPPrefix{
+		netaddr.MustParseIPPrefix("10.0.0.0/24"),
+		netaddr.MustParseIPPrefix("192.168.0.0/16"),
+	})
+
+	trie.remove(netaddr.MustParseIPPrefix("10.0.0.64/26"))
+	trie.remove(netaddr.MustParseIPPrefix("192.168.0.0/16"))
+	trie.remove(netaddr.MustParseIPPrefix("172.16.0.0/12"))
+
+	assert.False(t, trie.contains(netaddr.MustParseIP("10.0.0.100")))
+	assert.True(t, trie.contains(netaddr.MustParseIP("10.0.0.1")))
+	assert.False(t, trie.contains(netaddr.MustParseIP("192.168.1.1")))
+
+	expectedPrefixes := []netaddr.IPPrefix{
+		netaddr.MustParseIPPrefix("10.0.0.0/26"),
+		netaddr.MustParseIPPrefix("10.0.0.128/25"),
+	}
+	assert.Equal(t, expectedPrefixes, trie.prefixes())
+}
+
+func randomIPv4Prefixes(n int) (ipPrefixes []netaddr.IPPrefix) {
+	generator := rand.New(rand.NewSource(0)) //nolint:gosec
+	ipPrefixes = make([]netaddr.IPPrefix, n)
+	for i := range ipPrefixes {
+		const minBits, maxBits = 16, 32
+		bits := uint8(minBits + generator.Intn(maxBits-minBits+1))
+		ip := netaddr.IPv4(byte(generator.Intn(256)), byte(generator.Intn(256)), //nolint:gomnd
+			byte(generator.Intn(256)), byte(generator.Intn(256))) //nolint:gomnd
+		ipPrefixes[i] = netaddr.IPPrefix{IP: ip, Bits: bits}
+	}
+	return ipPrefixes
+}
+
+func Benchmark_ipPrefixes_contains(b *testing.B) {
+	const prefixesCount = 10000
+	ipPrefixes := randomIPv4Prefixes(prefixesCount)
+	ip := netaddr.IPv4(1, 2, 3, 4)
+
+	b.Run("slice scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, ipPrefix := range ipPrefixes {
+				if ipPrefix.Contains(ip) {
+					break
+				}
+			}
+		}
+	})
+
+	b.Run("trie", func(b *testing.B) {
+		trie := newIPPrefixTrie(ipPrefixes)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			trie.contains(ip)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package blacklist
 
 import (
 	"net"
+	"strings"
 
 	"github.com/miekg/dns"
 	"inet.af/netaddr"
@@ -10,7 +11,10 @@ import (
 type mapBased struct {
 	fqdnHostnames map[string]struct{}
 	ips           map[netaddr.IP]struct{}
-	ipPrefixes    []netaddr.IPPrefix
+	ipPrefixes    *ipPrefixTrie
+	// privateFqdnHostnames are hostnames, and their subdomains,
+	// for which responses are not filtered by IP address.
+	privateFqdnHostnames map[string]struct{}
 }
 
 func NewMap(settings Settings) BlackLister {
@@ -24,10 +28,16 @@ func NewMap(settings Settings) BlackLister {
 		ipsSet[ip] = struct{}{}
 	}
 
+	privateFqdnHostnamesSet := make(map[string]struct{}, len(settings.PrivateFqdnHostnames))
+	for _, fqdnHostname := range settings.PrivateFqdnHostnames {
+		privateFqdnHostnamesSet[fqdnHostname] = struct{}{}
+	}
+
 	return &mapBased{
-		fqdnHostnames: fqdnHostnamesSet,
-		ips:           ipsSet,
-		ipPrefixes:    settings.IPPrefixes,
+		fqdnHostnames:        fqdnHostnamesSet,
+		ips:                  ipsSet,
+		ipPrefixes:           newIPPrefixTrie(settings.IPPrefixes),
+		privateFqdnHostnames: privateFqdnHostnamesSet,
 	}
 }
 
@@ -42,6 +52,10 @@ func (m *mapBased) FilterRequest(request *dns.Msg) (blocked bool) {
 }
 
 func (m *mapBased) FilterResponse(response *dns.Msg) (blocked bool) {
+	if m.isPrivateHostname(response) {
+		return false
+	}
+
 	for _, rr := range response.Answer {
 		// only filter A and AAAA responses for now
 		switch rr.Header().Rrtype {
@@ -70,10 +84,23 @@ func (m *mapBased) isIPBlocked(ip net.IP) (blocked bool) {
 		return blocked
 	}
 
-	for _, ipPrefix := range m.ipPrefixes {
-		if ipPrefix.Contains(netaddrIP) {
+	return m.ipPrefixes.contains(netaddrIP)
+}
+
+func (m *mapBased) isPrivateHostname(response *dns.Msg) (private bool) {
+	if len(m.privateFqdnHostnames) == 0 || len(response.Question) == 0 {
+		return false
+	}
+
+	fqdnHostname := strings.ToLower(response.Question[0].Name)
+	for {
+		if _, private := m.privateFqdnHostnames[fqdnHostname]; private {
 			return true
 		}
+		i := strings.IndexByte(fqdnHostname, '.')
+		if i < 0 || i == len(fqdnHostname)-1 {
+			return false
+		}
+		fqdnHostname = fqdnHostname[i+1:]
 	}
-	return false
 }
@@ -98,3 +98,29 @@ func Test_mapBased_threadSafety(t *testing.T) {
 
 	endWg.Wait()
 }
+
+func Test_mapBased_privateHostnames(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		IPPrefixes: []netaddr.IPPrefix{{IP: netaddr.IPv4(10, 0, 0, 0), Bits: 8}},
+	}
+	settings.SetPrivateHostnames([]string{"home.lan"})
+
+	blacklister := NewMap(settings)
+
+	newResponse := func(name string) *dns.Msg {
+		return &dns.Msg{
+			Question: []dns.Question{{Name: name}},
+			Answer: []dns.RR{&dns.A{
+				Hdr: dns.RR_Header{Rrtype: dns.TypeA},
+				A:   net.IP{10, 0, 0, 1},
+			}},
+		}
+	}
+
+	assert.False(t, blacklister.FilterResponse(newResponse("home.lan.")))
+	assert.False(t, blacklister.FilterResponse(newResponse("nas.HOME.lan.")))
+	assert.True(t, blacklister.FilterResponse(newResponse("lan.")))
+	assert.True(t, blacklister.FilterResponse(newResponse("example.com.")))
+}
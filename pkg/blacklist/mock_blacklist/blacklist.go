@@ -1,14 +1,17 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/qdm12/dns/pkg/blacklist (interfaces: BlackLister)
+// Source: github.com/qdm12/dns/pkg/blacklist (interfaces: BlackLister,Builder,Selector,Setter)
 
 // Package mock_blacklist is a generated GoMock package.
 package mock_blacklist
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 	dns "github.com/miekg/dns"
+	blacklist "github.com/qdm12/dns/pkg/blacklist"
+	netaddr "inet.af/netaddr"
 )
 
 // MockBlackLister is a mock of BlackLister interface.
@@ -61,3 +64,177 @@ func (mr *MockBlackListerMockRecorder) FilterResponse(arg0 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterResponse", reflect.TypeOf((*MockBlackLister)(nil).FilterResponse), arg0)
 }
+
+// MockBuilder is a mock of Builder interface.
+type MockBuilder struct {
+	ctrl     *gomock.Controller
+	recorder *MockBuilderMockRecorder
+}
+
+// MockBuilderMockRecorder is the mock recorder for MockBuilder.
+type MockBuilderMockRecorder struct {
+	mock *MockBuilder
+}
+
+// NewMockBuilder creates a new mock instance.
+func NewMockBuilder(ctrl *gomock.Controller) *MockBuilder {
+	mock := &MockBuilder{ctrl: ctrl}
+	mock.recorder = &MockBuilderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBuilder) EXPECT() *MockBuilderMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *MockBuilder) All(arg0 context.Context, arg1 blacklist.BuilderSettings) ([]string, []netaddr.IP, []netaddr.IPPrefix, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].([]netaddr.IP)
+	ret2, _ := ret[2].([]netaddr.IPPrefix)
+	ret3, _ := ret[3].([]error)
+	return ret0, ret1, ret2, ret3
+}
+
+// All indicates an expected call of All.
+func (mr *MockBuilderMockRecorder) All(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockBuilder)(nil).All), arg0, arg1)
+}
+
+// Groups mocks base method.
+func (m *MockBuilder) Groups(arg0 context.Context, arg1 []blacklist.GroupBuilderSettings) ([]blacklist.GroupSettings, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Groups", arg0, arg1)
+	ret0, _ := ret[0].([]blacklist.GroupSettings)
+	ret1, _ := ret[1].([]error)
+	return ret0, ret1
+}
+
+// Groups indicates an expected call of Groups.
+func (mr *MockBuilderMockRecorder) Groups(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Groups", reflect.TypeOf((*MockBuilder)(nil).Groups), arg0, arg1)
+}
+
+// Hostnames mocks base method.
+func (m *MockBuilder) Hostnames(arg0 context.Context, arg1, arg2, arg3 bool, arg4, arg5 []string) ([]string, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hostnames", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].([]error)
+	return ret0, ret1
+}
+
+// Hostnames indicates an expected call of Hostnames.
+func (mr *MockBuilderMockRecorder) Hostnames(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hostnames", reflect.TypeOf((*MockBuilder)(nil).Hostnames), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// IPs mocks base method.
+func (m *MockBuilder) IPs(arg0 context.Context, arg1, arg2, arg3 bool, arg4 []netaddr.IP, arg5 []netaddr.IPPrefix, arg6 []netaddr.IP, arg7 []netaddr.IPPrefix) ([]netaddr.IP, []netaddr.IPPrefix, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IPs", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	ret0, _ := ret[0].([]netaddr.IP)
+	ret1, _ := ret[1].([]netaddr.IPPrefix)
+	ret2, _ := ret[2].([]error)
+	return ret0, ret1, ret2
+}
+
+// IPs indicates an expected call of IPs.
+func (mr *MockBuilderMockRecorder) IPs(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IPs", reflect.TypeOf((*MockBuilder)(nil).IPs), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+}
+
+// Scheduled mocks base method.
+func (m *MockBuilder) Scheduled(arg0 context.Context, arg1 []blacklist.ScheduledBuilderSettings) ([]blacklist.ScheduledSettings, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scheduled", arg0, arg1)
+	ret0, _ := ret[0].([]blacklist.ScheduledSettings)
+	ret1, _ := ret[1].([]error)
+	return ret0, ret1
+}
+
+// Scheduled indicates an expected call of Scheduled.
+func (mr *MockBuilderMockRecorder) Scheduled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scheduled", reflect.TypeOf((*MockBuilder)(nil).Scheduled), arg0, arg1)
+}
+
+// MockSelector is a mock of Selector interface.
+type MockSelector struct {
+	ctrl     *gomock.Controller
+	recorder *MockSelectorMockRecorder
+}
+
+// MockSelectorMockRecorder is the mock recorder for MockSelector.
+type MockSelectorMockRecorder struct {
+	mock *MockSelector
+}
+
+// NewMockSelector creates a new mock instance.
+func NewMockSelector(ctrl *gomock.Controller) *MockSelector {
+	mock := &MockSelector{ctrl: ctrl}
+	mock.recorder = &MockSelectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSelector) EXPECT() *MockSelectorMockRecorder {
+	return m.recorder
+}
+
+// Select mocks base method.
+func (m *MockSelector) Select(arg0 netaddr.IP, arg1 *dns.Msg) (blacklist.BlackLister, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Select", arg0, arg1)
+	ret0, _ := ret[0].(blacklist.BlackLister)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Select indicates an expected call of Select.
+func (mr *MockSelectorMockRecorder) Select(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockSelector)(nil).Select), arg0, arg1)
+}
+
+// MockSetter is a mock of Setter interface.
+type MockSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSetterMockRecorder
+}
+
+// MockSetterMockRecorder is the mock recorder for MockSetter.
+type MockSetterMockRecorder struct {
+	mock *MockSetter
+}
+
+// NewMockSetter creates a new mock instance.
+func NewMockSetter(ctrl *gomock.Controller) *MockSetter {
+	mock := &MockSetter{ctrl: ctrl}
+	mock.recorder = &MockSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSetter) EXPECT() *MockSetterMockRecorder {
+	return m.recorder
+}
+
+// SetBlacklist mocks base method.
+func (m *MockSetter) SetBlacklist(arg0 blacklist.Settings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetBlacklist", arg0)
+}
+
+// SetBlacklist indicates an expected call of SetBlacklist.
+func (mr *MockSetterMockRecorder) SetBlacklist(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlacklist", reflect.TypeOf((*MockSetter)(nil).SetBlacklist), arg0)
+}
@@ -0,0 +1,5 @@
+package blacklist
+
+// Mocks of this package interfaces for this package tests, which
+// cannot import the mock_blacklist package without an import cycle.
+//go:generate mockgen -destination=mocks_test.go -package $GOPACKAGE -self_package github.com/qdm12/dns/pkg/blacklist . Builder,Setter
@@ -0,0 +1,149 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/blacklist (interfaces: Builder,Setter)
+
+// Package blacklist is a generated GoMock package.
+package blacklist
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	netaddr "inet.af/netaddr"
+)
+
+// MockBuilder is a mock of Builder interface.
+type MockBuilder struct {
+	ctrl     *gomock.Controller
+	recorder *MockBuilderMockRecorder
+}
+
+// MockBuilderMockRecorder is the mock recorder for MockBuilder.
+type MockBuilderMockRecorder struct {
+	mock *MockBuilder
+}
+
+// NewMockBuilder creates a new mock instance.
+func NewMockBuilder(ctrl *gomock.Controller) *MockBuilder {
+	mock := &MockBuilder{ctrl: ctrl}
+	mock.recorder = &MockBuilderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBuilder) EXPECT() *MockBuilderMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *MockBuilder) All(arg0 context.Context, arg1 BuilderSettings) ([]string, []netaddr.IP, []netaddr.IPPrefix, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].([]netaddr.IP)
+	ret2, _ := ret[2].([]netaddr.IPPrefix)
+	ret3, _ := ret[3].([]error)
+	return ret0, ret1, ret2, ret3
+}
+
+// All indicates an expected call of All.
+func (mr *MockBuilderMockRecorder) All(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockBuilder)(nil).All), arg0, arg1)
+}
+
+// Groups mocks base method.
+func (m *MockBuilder) Groups(arg0 context.Context, arg1 []GroupBuilderSettings) ([]GroupSettings, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Groups", arg0, arg1)
+	ret0, _ := ret[0].([]GroupSettings)
+	ret1, _ := ret[1].([]error)
+	return ret0, ret1
+}
+
+// Groups indicates an expected call of Groups.
+func (mr *MockBuilderMockRecorder) Groups(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Groups", reflect.TypeOf((*MockBuilder)(nil).Groups), arg0, arg1)
+}
+
+// Hostnames mocks base method.
+func (m *MockBuilder) Hostnames(arg0 context.Context, arg1, arg2, arg3 bool, arg4, arg5 []string) ([]string, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hostnames", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].([]error)
+	return ret0, ret1
+}
+
+// Hostnames indicates an expected call of Hostnames.
+func (mr *MockBuilderMockRecorder) Hostnames(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hostnames", reflect.TypeOf((*MockBuilder)(nil).Hostnames), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// IPs mocks base method.
+func (m *MockBuilder) IPs(arg0 context.Context, arg1, arg2, arg3 bool, arg4 []netaddr.IP, arg5 []netaddr.IPPrefix, arg6 []netaddr.IP, arg7 []netaddr.IPPrefix) ([]netaddr.IP, []netaddr.IPPrefix, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IPs", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	ret0, _ := ret[0].([]netaddr.IP)
+	ret1, _ := ret[1].([]netaddr.IPPrefix)
+	ret2, _ := ret[2].([]error)
+	return ret0, ret1, ret2
+}
+
+// IPs indicates an expected call of IPs.
+func (mr *MockBuilderMockRecorder) IPs(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IPs", reflect.TypeOf((*MockBuilder)(nil).IPs), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+}
+
+// Scheduled mocks base method.
+func (m *MockBuilder) Scheduled(arg0 context.Context, arg1 []ScheduledBuilderSettings) ([]ScheduledSettings, []error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scheduled", arg0, arg1)
+	ret0, _ := ret[0].([]ScheduledSettings)
+	ret1, _ := ret[1].([]error)
+	return ret0, ret1
+}
+
+// Scheduled indicates an expected call of Scheduled.
+func (mr *MockBuilderMockRecorder) Scheduled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scheduled", reflect.TypeOf((*MockBuilder)(nil).Scheduled), arg0, arg1)
+}
+
+// MockSetter is a mock of Setter interface.
+type MockSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSetterMockRecorder
+}
+
+// MockSetterMockRecorder is the mock recorder for MockSetter.
+type MockSetterMockRecorder struct {
+	mock *MockSetter
+}
+
+// NewMockSetter creates a new mock instance.
+func NewMockSetter(ctrl *gomock.Controller) *MockSetter {
+	mock := &MockSetter{ctrl: ctrl}
+	mock.recorder = &MockSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSetter) EXPECT() *MockSetterMockRecorder {
+	return m.recorder
+}
+
+// SetBlacklist mocks base method.
+func (m *MockSetter) SetBlacklist(arg0 Settings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetBlacklist", arg0)
+}
+
+// SetBlacklist indicates an expected call of SetBlacklist.
+func (mr *MockSetterMockRecorder) SetBlacklist(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlacklist", reflect.TypeOf((*MockSetter)(nil).SetBlacklist), arg0)
+}
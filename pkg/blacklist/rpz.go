@@ -0,0 +1,80 @@
+package blacklist
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"inet.af/netaddr"
+)
+
+// WriteRPZ writes the top level hostnames, IP addresses and IP networks of
+// the settings given as a Response Policy Zone file to the writer, with the
+// serial number given for the SOA record. Each hostname and its subdomains
+// are blocked with an NXDOMAIN policy, and answers containing one of the
+// IP addresses or IP networks are blocked with an NXDOMAIN policy as well.
+// The owner names are relative to the origin of the zone, which is set by
+// the resolver loading the zone file.
+func WriteRPZ(writer io.Writer, settings Settings, serial uint32) (err error) {
+	bufferedWriter := bufio.NewWriter(writer)
+
+	_, err = bufferedWriter.WriteString("$TTL 300\n" +
+		"@ SOA localhost. root.localhost. " +
+		strconv.FormatUint(uint64(serial), 10) + " 3600 600 86400 300\n" +
+		"@ NS localhost.\n")
+	if err != nil {
+		return err
+	}
+
+	for _, fqdnHostname := range settings.FqdnHostnames {
+		hostname := strings.TrimSuffix(fqdnHostname, ".")
+		_, err = bufferedWriter.WriteString(hostname + " CNAME .\n" +
+			"*." + hostname + " CNAME .\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ip := range settings.IPs {
+		ipPrefix := netaddr.IPPrefix{IP: ip, Bits: ip.BitLen()}
+		_, err = bufferedWriter.WriteString(rpzIPTrigger(ipPrefix) + " CNAME .\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ipPrefix := range settings.IPPrefixes {
+		_, err = bufferedWriter.WriteString(rpzIPTrigger(ipPrefix) + " CNAME .\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// rpzIPTrigger returns the response IP address trigger owner name for the
+// IP prefix given, such as 24.0.2.0.192.rpz-ip for 192.0.2.0/24.
+func rpzIPTrigger(ipPrefix netaddr.IPPrefix) (owner string) {
+	ip := ipPrefix.Masked().IP
+
+	var labels []string
+	if ip.Is4() {
+		bytes := ip.As4()
+		labels = make([]string, len(bytes))
+		for i, b := range bytes {
+			labels[len(bytes)-1-i] = strconv.Itoa(int(b))
+		}
+	} else {
+		bytes := ip.As16()
+		const groups = 8
+		labels = make([]string, groups)
+		for i := 0; i < groups; i++ {
+			group := uint64(bytes[2*i])<<8 | uint64(bytes[2*i+1]) //nolint:gomnd
+			labels[groups-1-i] = strconv.FormatUint(group, 16)    //nolint:gomnd
+		}
+	}
+
+	return strconv.Itoa(int(ipPrefix.Bits)) + "." + strings.Join(labels, ".") + ".rpz-ip"
+}
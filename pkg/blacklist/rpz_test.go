@@ -0,0 +1,42 @@
+package blacklist
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_WriteRPZ(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		FqdnHostnames: []string{"github.com.", "google.com."},
+		IPs: []netaddr.IP{
+			netaddr.IPv4(1, 2, 3, 4),
+			netaddr.MustParseIP("2001:db8::1"),
+		},
+		IPPrefixes: []netaddr.IPPrefix{
+			netaddr.MustParseIPPrefix("192.0.2.0/24"),
+		},
+	}
+	buffer := bytes.NewBuffer(nil)
+
+	err := WriteRPZ(buffer, settings, 1)
+
+	require.NoError(t, err)
+	const expected = `$TTL 300
+@ SOA localhost. root.localhost. 1 3600 600 86400 300
+@ NS localhost.
+github.com CNAME .
+*.github.com CNAME .
+google.com CNAME .
+*.google.com CNAME .
+32.4.3.2.1.rpz-ip CNAME .
+128.1.0.0.0.0.0.db8.2001.rpz-ip CNAME .
+24.0.2.0.192.rpz-ip CNAME .
+`
+	assert.Equal(t, expected, buffer.String())
+}
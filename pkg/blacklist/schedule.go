@@ -0,0 +1,149 @@
+package blacklist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schedule is a daily time window, repeated on the days given.
+type Schedule struct {
+	// Days are the days on which the time window starts.
+	// It defaults to every day if left empty.
+	Days []time.Weekday
+	// Start is the duration from midnight at which the window starts.
+	Start time.Duration
+	// End is the duration from midnight at which the window ends.
+	// If it is before or equal to Start, the window ends on the next day.
+	End time.Duration
+	// Location is the time zone of the schedule and defaults
+	// to the local time zone if left to nil.
+	Location *time.Location
+}
+
+func (s *Schedule) String() string {
+	days := "every day"
+	if len(s.Days) > 0 {
+		dayNames := make([]string, len(s.Days))
+		for i, day := range s.Days {
+			dayNames[i] = day.String()[:3]
+		}
+		days = strings.Join(dayNames, ", ")
+	}
+	return fmt.Sprintf("%s from %s to %s (%s)", days,
+		formatTimeOfDay(s.Start), formatTimeOfDay(s.End), s.location())
+}
+
+func formatTimeOfDay(duration time.Duration) string {
+	hours := int(duration / time.Hour)
+	minutes := int((duration % time.Hour) / time.Minute)
+	return fmt.Sprintf("%02d:%02d", hours, minutes)
+}
+
+func (s *Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.Local
+	}
+	return s.Location
+}
+
+func (s *Schedule) startsOn(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, startDay := range s.Days {
+		if startDay == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schedule) crossesMidnight() bool {
+	return s.End <= s.Start
+}
+
+// Active returns true if the time given is within a time window of the
+// schedule, comparing its wall clock time with the window boundaries.
+func (s *Schedule) Active(t time.Time) (active bool) {
+	t = t.In(s.location())
+	timeOfDay := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	if !s.crossesMidnight() {
+		return s.startsOn(t.Weekday()) && timeOfDay >= s.Start && timeOfDay < s.End
+	}
+
+	previousDay := (t.Weekday() + 6) % 7 //nolint:gomnd
+	return (s.startsOn(t.Weekday()) && timeOfDay >= s.Start) ||
+		(s.startsOn(previousDay) && timeOfDay < s.End)
+}
+
+// NextChange returns the first time strictly after the time given at
+// which the schedule becomes active or inactive, or the zero time if it
+// does not change within the next week.
+func (s *Schedule) NextChange(t time.Time) (next time.Time) {
+	t = t.In(s.location())
+	active := s.Active(t)
+	for _, boundary := range s.boundaries(t) {
+		if s.Active(boundary) != active {
+			return boundary
+		}
+	}
+	return time.Time{}
+}
+
+// boundaries returns the sorted times strictly after the time given and
+// within the next week at which the wall clock reaches the start or end of
+// a time window, or at which the time zone offset changes. Wall clock times
+// skipped by a daylight saving time change are not reached, and wall clock
+// times repeated by a daylight saving time change are reached twice.
+func (s *Schedule) boundaries(t time.Time) (boundaries []time.Time) {
+	year, month, day := t.Date()
+	const daysToCheck = 9 // a week and the day before and after
+
+	// Wall clock times are represented in UTC to compute
+	// the time for each time zone offset of the period.
+	wallBoundaries := make([]time.Time, 0, 2*daysToCheck) //nolint:gomnd
+	for dayOffset := -1; dayOffset < daysToCheck-1; dayOffset++ {
+		startDate := time.Date(year, month, day+dayOffset, 0, 0, 0, 0, time.UTC)
+		if !s.startsOn(startDate.Weekday()) {
+			continue
+		}
+
+		endDate := startDate
+		if s.crossesMidnight() {
+			endDate = startDate.AddDate(0, 0, 1)
+		}
+		wallBoundaries = append(wallBoundaries,
+			startDate.Add(s.Start), endDate.Add(s.End))
+	}
+
+	limit := time.Date(year, month, day+daysToCheck-1, 0, 0, 0, 0, t.Location())
+	for periodStart := t; periodStart.Before(limit); {
+		_, offset := periodStart.Zone()
+		_, periodEnd := periodStart.ZoneBounds()
+		if periodEnd.IsZero() || periodEnd.After(limit) {
+			periodEnd = limit
+		}
+
+		for _, wallBoundary := range wallBoundaries {
+			boundary := wallBoundary.Add(-time.Duration(offset) * time.Second).In(t.Location())
+			if boundary.After(t) && !boundary.Before(periodStart) && boundary.Before(periodEnd) {
+				boundaries = append(boundaries, boundary)
+			}
+		}
+		if periodEnd.Before(limit) {
+			boundaries = append(boundaries, periodEnd)
+		}
+
+		periodStart = periodEnd
+	}
+
+	sort.Slice(boundaries, func(i, j int) bool {
+		return boundaries[i].Before(boundaries[j])
+	})
+	return boundaries
+}
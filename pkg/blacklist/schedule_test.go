@@ -0,0 +1,223 @@
+package blacklist
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Schedule_Active(t *testing.T) {
+	t.Parallel()
+
+	// 2021-08-02 is a Monday
+	date := func(day, hour, minute int) time.Time {
+		return time.Date(2021, time.August, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := map[string]struct {
+		schedule Schedule
+		t        time.Time
+		active   bool
+	}{
+		"every day in window": {
+			schedule: Schedule{Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+			t:        date(2, 12, 0),
+			active:   true,
+		},
+		"every day before window": {
+			schedule: Schedule{Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+			t:        date(2, 8, 59),
+		},
+		"every day at window end": {
+			schedule: Schedule{Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+			t:        date(2, 17, 0),
+		},
+		"not on day": {
+			schedule: Schedule{
+				Days:  []time.Weekday{time.Tuesday},
+				Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC,
+			},
+			t: date(2, 12, 0),
+		},
+		"crossing midnight before midnight": {
+			schedule: Schedule{
+				Days:  []time.Weekday{time.Monday},
+				Start: 21 * time.Hour, End: 7 * time.Hour, Location: time.UTC,
+			},
+			t:      date(2, 22, 0),
+			active: true,
+		},
+		"crossing midnight after midnight": {
+			schedule: Schedule{
+				Days:  []time.Weekday{time.Monday},
+				Start: 21 * time.Hour, End: 7 * time.Hour, Location: time.UTC,
+			},
+			t:      date(3, 6, 59),
+			active: true,
+		},
+		"crossing midnight after midnight of other day": {
+			schedule: Schedule{
+				Days:  []time.Weekday{time.Monday},
+				Start: 21 * time.Hour, End: 7 * time.Hour, Location: time.UTC,
+			},
+			t: date(2, 6, 0),
+		},
+		"time zone": {
+			schedule: Schedule{
+				Start: 9 * time.Hour, End: 17 * time.Hour,
+				Location: time.FixedZone("UTC+10", 10*60*60), //nolint:gomnd
+			},
+			t:      date(2, 0, 0),
+			active: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			active := testCase.schedule.Active(testCase.t)
+
+			assert.Equal(t, testCase.active, active)
+		})
+	}
+}
+
+func Test_Schedule_NextChange(t *testing.T) {
+	t.Parallel()
+
+	// 2021-08-02 is a Monday
+	date := func(day, hour, minute int) time.Time {
+		return time.Date(2021, time.August, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := map[string]struct {
+		schedule Schedule
+		t        time.Time
+		next     time.Time
+	}{
+		"before window start": {
+			schedule: Schedule{Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+			t:        date(2, 8, 0),
+			next:     date(2, 9, 0),
+		},
+		"at window start": {
+			schedule: Schedule{Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+			t:        date(2, 9, 0),
+			next:     date(2, 17, 0),
+		},
+		"after window end": {
+			schedule: Schedule{Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC},
+			t:        date(2, 18, 0),
+			next:     date(3, 9, 0),
+		},
+		"skips other days": {
+			schedule: Schedule{
+				Days:  []time.Weekday{time.Sunday},
+				Start: 21 * time.Hour, End: 7 * time.Hour, Location: time.UTC,
+			},
+			t:    date(2, 8, 0),
+			next: date(8, 21, 0),
+		},
+		"crossing midnight end": {
+			schedule: Schedule{
+				Days:  []time.Weekday{time.Sunday},
+				Start: 21 * time.Hour, End: 7 * time.Hour, Location: time.UTC,
+			},
+			t:    date(2, 1, 0),
+			next: date(2, 7, 0),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			next := testCase.schedule.NextChange(testCase.t)
+
+			assert.Equal(t, testCase.next, next)
+		})
+	}
+}
+
+func Test_Schedule_daylightSavingTime(t *testing.T) {
+	t.Parallel()
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	// On 2021-03-28, clocks go from 02:00 CET to 03:00 CEST at 01:00 UTC.
+	// On 2021-10-31, clocks go from 03:00 CEST to 02:00 CET at 01:00 UTC.
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2021, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := map[string]struct {
+		schedule Schedule
+		t        time.Time
+		active   bool
+		next     time.Time
+	}{
+		"spring forward after window end": {
+			schedule: Schedule{Start: time.Hour, End: 2*time.Hour + 30*time.Minute, Location: paris},
+			t:        utc(time.March, 28, 1, 15), // 03:15 CEST
+			active:   false,
+			next:     utc(time.March, 28, 23, 0), // 01:00 CEST
+		},
+		"spring forward window end skipped": {
+			schedule: Schedule{Start: time.Hour, End: 2*time.Hour + 30*time.Minute, Location: paris},
+			t:        utc(time.March, 28, 0, 30), // 01:30 CET
+			active:   true,
+			next:     utc(time.March, 28, 1, 0), // 03:00 CEST
+		},
+		"spring forward in window": {
+			schedule: Schedule{Start: 2*time.Hour + 30*time.Minute, End: 4 * time.Hour, Location: paris},
+			t:        utc(time.March, 28, 1, 15), // 03:15 CEST
+			active:   true,
+			next:     utc(time.March, 28, 2, 0), // 04:00 CEST
+		},
+		"spring forward before window": {
+			schedule: Schedule{Start: 9 * time.Hour, End: 17 * time.Hour, Location: paris},
+			t:        utc(time.March, 28, 6, 0), // 08:00 CEST
+			active:   false,
+			next:     utc(time.March, 28, 7, 0), // 09:00 CEST
+		},
+		"fall back first window end": {
+			schedule: Schedule{Start: time.Hour, End: 2*time.Hour + 30*time.Minute, Location: paris},
+			t:        utc(time.October, 30, 23, 30), // 01:30 CEST
+			active:   true,
+			next:     utc(time.October, 31, 0, 30), // 02:30 CEST
+		},
+		"fall back repeated hour": {
+			schedule: Schedule{Start: time.Hour, End: 2*time.Hour + 30*time.Minute, Location: paris},
+			t:        utc(time.October, 31, 0, 45), // 02:45 CEST
+			active:   false,
+			next:     utc(time.October, 31, 1, 0), // 02:00 CET
+		},
+		"fall back second window end": {
+			schedule: Schedule{Start: time.Hour, End: 2*time.Hour + 30*time.Minute, Location: paris},
+			t:        utc(time.October, 31, 1, 0), // 02:00 CET
+			active:   true,
+			next:     utc(time.October, 31, 1, 30), // 02:30 CET
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			active := testCase.schedule.Active(testCase.t)
+			next := testCase.schedule.NextChange(testCase.t)
+
+			assert.Equal(t, testCase.active, active)
+			assert.Equal(t, testCase.next, next.UTC())
+			assert.NotEqual(t, active, testCase.schedule.Active(next))
+		})
+	}
+}
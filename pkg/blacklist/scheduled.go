@@ -0,0 +1,166 @@
+package blacklist
+
+import (
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// ScheduledSettings contains blacklist settings only applying
+// during the time windows of a schedule. Groups and scheduled
+// settings nested in the Blacklist field are ignored, as well as
+// its safe search field.
+type ScheduledSettings struct {
+	Name      string
+	Schedule  Schedule
+	Blacklist Settings
+}
+
+// ScheduledBuilderSettings contains blacklist building settings for
+// a blacklist applying only during the time windows of a schedule.
+// Groups and scheduled settings nested in the Blacklist field are ignored,
+// as well as its safe search field.
+type ScheduledBuilderSettings struct {
+	Name      string
+	Schedule  Schedule
+	Blacklist BuilderSettings
+}
+
+func (s *ScheduledSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Scheduled "+s.Name+": "+s.Schedule.String())
+	for _, line := range s.Blacklist.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+	return lines
+}
+
+func (s *ScheduledBuilderSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Scheduled "+s.Name+": "+s.Schedule.String())
+	for _, line := range s.Blacklist.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+	return lines
+}
+
+// Active returns the settings with the blacklists of the scheduled
+// settings active at the time given merged in, and with all the scheduled
+// settings removed. This is done for the settings groups as well.
+func (s *Settings) Active(t time.Time) (active Settings) {
+	active = Settings{
+		FqdnHostnames:        s.FqdnHostnames,
+		IPs:                  s.IPs,
+		IPPrefixes:           s.IPPrefixes,
+		SafeSearch:           s.SafeSearch,
+		PrivateFqdnHostnames: s.PrivateFqdnHostnames,
+	}
+
+	for _, scheduled := range s.Scheduled {
+		if !scheduled.Schedule.Active(t) {
+			continue
+		}
+		active.AddBlockHostnames(scheduled.Blacklist.FqdnHostnames)
+		active.IPs = append(active.IPs[:len(active.IPs):len(active.IPs)],
+			scheduled.Blacklist.IPs...)
+		active.IPPrefixes = append(active.IPPrefixes[:len(active.IPPrefixes):len(active.IPPrefixes)],
+			scheduled.Blacklist.IPPrefixes...)
+	}
+
+	if len(s.Groups) > 0 {
+		active.Groups = make([]GroupSettings, len(s.Groups))
+		for i, group := range s.Groups {
+			active.Groups[i] = GroupSettings{
+				Clients:   group.Clients,
+				Blacklist: group.Blacklist.Active(t),
+			}
+		}
+	}
+
+	return active
+}
+
+// NextChange returns the first time strictly after the time given at
+// which one of the scheduled settings, including the ones of the groups,
+// becomes active or inactive. It returns the zero time if there is
+// no scheduled settings.
+func (s *Settings) NextChange(t time.Time) (next time.Time) {
+	for _, scheduled := range s.Scheduled {
+		change := scheduled.Schedule.NextChange(t)
+		if !change.IsZero() && (next.IsZero() || change.Before(next)) {
+			next = change
+		}
+	}
+
+	for _, group := range s.Groups {
+		change := group.Blacklist.NextChange(t)
+		if !change.IsZero() && (next.IsZero() || change.Before(next)) {
+			next = change
+		}
+	}
+
+	return next
+}
+
+type scheduledBlackLister struct {
+	schedule    Schedule
+	blackLister BlackLister
+}
+
+// scheduled is a BlackLister combining a base BlackLister with
+// BlackListers only applying during their schedule time windows.
+type scheduled struct {
+	base      BlackLister
+	scheduled []scheduledBlackLister
+	timeNow   func() time.Time
+}
+
+// newBlackLister creates a BlackLister from the settings given,
+// taking into account its scheduled settings.
+func newBlackLister(settings Settings) BlackLister {
+	base := NewMap(settings)
+	if len(settings.Scheduled) == 0 {
+		return base
+	}
+
+	scheduledBlackListers := make([]scheduledBlackLister, len(settings.Scheduled))
+	for i, scheduledSettings := range settings.Scheduled {
+		scheduledSettings.Blacklist.PrivateFqdnHostnames = settings.PrivateFqdnHostnames
+		scheduledBlackListers[i] = scheduledBlackLister{
+			schedule:    scheduledSettings.Schedule,
+			blackLister: NewMap(scheduledSettings.Blacklist),
+		}
+	}
+
+	return &scheduled{
+		base:      base,
+		scheduled: scheduledBlackListers,
+		timeNow:   time.Now,
+	}
+}
+
+func (s *scheduled) FilterRequest(request *dns.Msg) (blocked bool) {
+	if s.base.FilterRequest(request) {
+		return true
+	}
+
+	now := s.timeNow()
+	for _, scheduled := range s.scheduled {
+		if scheduled.schedule.Active(now) && scheduled.blackLister.FilterRequest(request) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scheduled) FilterResponse(response *dns.Msg) (blocked bool) {
+	if s.base.FilterResponse(response) {
+		return true
+	}
+
+	now := s.timeNow()
+	for _, scheduled := range s.scheduled {
+		if scheduled.schedule.Active(now) && scheduled.blackLister.FilterResponse(response) {
+			return true
+		}
+	}
+	return false
+}
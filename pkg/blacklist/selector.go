@@ -0,0 +1,136 @@
+package blacklist
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// edns0MACOptionCode is the EDNS0 option code used by dnsmasq
+// to forward the MAC address of the client with --add-mac.
+const edns0MACOptionCode = 65001
+
+type selector struct {
+	defaultPolicy policy
+	groups        []groupPolicy
+	macToPolicy   map[string]policy
+}
+
+type policy struct {
+	blackLister BlackLister
+	safeSearch  bool
+}
+
+type groupPolicy struct {
+	subnets []netaddr.IPPrefix
+	policy  policy
+}
+
+// NewSelector creates a Selector returning the BlackLister and safe
+// search setting of the client group matching the client, or the ones
+// from the settings top level fields if no group matches the client.
+func NewSelector(settings Settings) Selector {
+	groups := make([]groupPolicy, len(settings.Groups))
+	macToPolicy := make(map[string]policy)
+	for i, group := range settings.Groups {
+		group.Blacklist.PrivateFqdnHostnames = settings.PrivateFqdnHostnames
+		groupPolicy := policy{
+			blackLister: newBlackLister(group.Blacklist),
+			safeSearch:  group.Blacklist.SafeSearch,
+		}
+		groups[i].subnets = group.Clients.Subnets
+		groups[i].policy = groupPolicy
+		for _, mac := range group.Clients.MACs {
+			macToPolicy[mac.String()] = groupPolicy
+		}
+	}
+
+	return &selector{
+		defaultPolicy: policy{
+			blackLister: newBlackLister(settings),
+			safeSearch:  settings.SafeSearch,
+		},
+		groups:      groups,
+		macToPolicy: macToPolicy,
+	}
+}
+
+func (s *selector) Select(clientIP netaddr.IP, request *dns.Msg) (
+	blackLister BlackLister, safeSearch bool) {
+	if len(s.macToPolicy) > 0 {
+		if mac := extractMAC(request); mac != nil {
+			policy, ok := s.macToPolicy[mac.String()]
+			if ok {
+				return policy.blackLister, policy.safeSearch
+			}
+		}
+	}
+
+	// The most specific subnet containing the client IP address
+	// wins, as it does for Unbound access-control-tag.
+	matched := s.defaultPolicy
+	matchBits := -1
+	for _, group := range s.groups {
+		for _, subnet := range group.subnets {
+			if int(subnet.Bits) > matchBits && subnet.Contains(clientIP) {
+				matchBits = int(subnet.Bits)
+				matched = group.policy
+			}
+		}
+	}
+
+	return matched.blackLister, matched.safeSearch
+}
+
+func extractMAC(request *dns.Msg) (mac net.HardwareAddr) {
+	opt := request.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+
+	for _, option := range opt.Option {
+		local, ok := option.(*dns.EDNS0_LOCAL)
+		if !ok || local.Code != edns0MACOptionCode {
+			continue
+		}
+		const macLength = 6
+		if len(local.Data) == macLength {
+			return net.HardwareAddr(local.Data)
+		}
+	}
+	return nil
+}
+
+// RemoveMAC returns a copy of the request without the EDNS0 option
+// carrying the MAC address of the client, such that it is not sent
+// upstream. It returns the request given if it has no such option.
+func RemoveMAC(request *dns.Msg) *dns.Msg {
+	if !hasMACOption(request) {
+		return request
+	}
+
+	request = request.Copy()
+	opt := request.IsEdns0()
+	options := make([]dns.EDNS0, 0, len(opt.Option))
+	for _, option := range opt.Option {
+		if option.Option() != edns0MACOptionCode {
+			options = append(options, option)
+		}
+	}
+	opt.Option = options
+	return request
+}
+
+func hasMACOption(request *dns.Msg) bool {
+	opt := request.IsEdns0()
+	if opt == nil {
+		return false
+	}
+	for _, option := range opt.Option {
+		if option.Option() == edns0MACOptionCode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package blacklist
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_selector_Select(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		FqdnHostnames: []string{"default.com."},
+		Groups: []GroupSettings{
+			{
+				Clients: ClientGroup{
+					Name: "kids",
+					Subnets: []netaddr.IPPrefix{
+						{IP: netaddr.IPv4(10, 0, 0, 0), Bits: 8},
+					},
+					MACs: []net.HardwareAddr{{1, 2, 3, 4, 5, 6}},
+				},
+				Blacklist: Settings{
+					FqdnHostnames: []string{"kids.com."},
+					SafeSearch:    true,
+				},
+			},
+			{
+				Clients: ClientGroup{
+					Name: "servers",
+					Subnets: []netaddr.IPPrefix{
+						{IP: netaddr.IPv4(10, 0, 1, 0), Bits: 24},
+					},
+				},
+				Blacklist: Settings{
+					FqdnHostnames: []string{"servers.com."},
+				},
+			},
+		},
+	}
+
+	newRequest := func(mac net.HardwareAddr) *dns.Msg {
+		request := new(dns.Msg).SetQuestion("", dns.TypeA)
+		if mac != nil {
+			request.SetEdns0(dns.DefaultMsgSize, false)
+			opt := request.IsEdns0()
+			opt.Option = append(opt.Option, &dns.EDNS0_LOCAL{
+				Code: edns0MACOptionCode,
+				Data: mac,
+			})
+		}
+		return request
+	}
+
+	testCases := map[string]struct {
+		clientIP   netaddr.IP
+		mac        net.HardwareAddr
+		blocked    string
+		safeSearch bool
+	}{
+		"no client IP": {
+			blocked: "default.com.",
+		},
+		"client IP in no group": {
+			clientIP: netaddr.IPv4(192, 168, 1, 1),
+			blocked:  "default.com.",
+		},
+		"client IP in group subnet": {
+			clientIP:   netaddr.IPv4(10, 1, 1, 1),
+			blocked:    "kids.com.",
+			safeSearch: true,
+		},
+		"most specific subnet wins": {
+			clientIP: netaddr.IPv4(10, 0, 1, 1),
+			blocked:  "servers.com.",
+		},
+		"MAC address wins over subnet": {
+			clientIP:   netaddr.IPv4(10, 0, 1, 1),
+			mac:        net.HardwareAddr{1, 2, 3, 4, 5, 6},
+			blocked:    "kids.com.",
+			safeSearch: true,
+		},
+		"unknown MAC address": {
+			clientIP: netaddr.IPv4(192, 168, 1, 1),
+			mac:      net.HardwareAddr{6, 5, 4, 3, 2, 1},
+			blocked:  "default.com.",
+		},
+	}
+
+	selector := NewSelector(settings)
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			request := newRequest(testCase.mac)
+
+			blackLister, safeSearch := selector.Select(testCase.clientIP, request)
+
+			assert.Equal(t, testCase.safeSearch, safeSearch)
+
+			for _, hostname := range []string{"default.com.", "kids.com.", "servers.com."} {
+				request.Question[0].Name = hostname
+				blocked := blackLister.FilterRequest(request)
+				assert.Equal(t, hostname == testCase.blocked, blocked, hostname)
+			}
+		})
+	}
+}
+
+func Test_RemoveMAC(t *testing.T) {
+	t.Parallel()
+
+	cookie := &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"}
+	mac := &dns.EDNS0_LOCAL{Code: edns0MACOptionCode, Data: []byte{1, 2, 3, 4, 5, 6}}
+
+	newRequest := func(options ...dns.EDNS0) *dns.Msg {
+		request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+		request.Id = 1
+		if options != nil {
+			request.SetEdns0(dns.DefaultMsgSize, false)
+			opt := request.IsEdns0()
+			opt.Option = append(opt.Option, options...)
+		}
+		return request
+	}
+
+	testCases := map[string]struct {
+		request  *dns.Msg
+		expected *dns.Msg
+		same     bool
+	}{
+		"no EDNS0": {
+			request:  newRequest(),
+			expected: newRequest(),
+			same:     true,
+		},
+		"no MAC option": {
+			request:  newRequest(cookie),
+			expected: newRequest(cookie),
+			same:     true,
+		},
+		"MAC option removed": {
+			request:  newRequest(cookie, mac),
+			expected: newRequest(cookie),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			original := testCase.request.Copy()
+
+			request := RemoveMAC(testCase.request)
+
+			assert.Equal(t, testCase.expected.String(), request.String())
+			assert.Equal(t, testCase.same, request == testCase.request)
+			assert.Equal(t, original.String(), testCase.request.String())
+		})
+	}
+}
@@ -13,6 +13,20 @@ type Settings struct {
 	FqdnHostnames []string
 	IPs           []netaddr.IP
 	IPPrefixes    []netaddr.IPPrefix
+	// SafeSearch is true to rewrite requests for search engines
+	// and YouTube to their safe search hostnames.
+	SafeSearch bool
+	// PrivateFqdnHostnames are hostnames, and their subdomains, whose
+	// responses are not filtered by IP address. The ones at the top level
+	// are used for the groups and scheduled settings as well, and the
+	// ones nested in groups and scheduled settings are ignored.
+	PrivateFqdnHostnames []string
+	// Groups are client groups with their own blacklist,
+	// used instead of the blacklist above for their clients.
+	Groups []GroupSettings
+	// Scheduled are blacklists only applying during
+	// the time windows of their schedule.
+	Scheduled []ScheduledSettings
 }
 
 // BlockHostnames transforms the slice of hostnames given to
@@ -24,6 +38,15 @@ func (s *Settings) BlockHostnames(hostnames []string) {
 	}
 }
 
+// SetPrivateHostnames transforms the slice of hostnames given to
+// lower cased FQDN hostnames and sets these as private hostnames.
+func (s *Settings) SetPrivateHostnames(hostnames []string) {
+	s.PrivateFqdnHostnames = make([]string, len(hostnames))
+	for i := range hostnames {
+		s.PrivateFqdnHostnames[i] = strings.ToLower(dns.Fqdn(hostnames[i]))
+	}
+}
+
 // AddBlockHostnames transforms the slice of hostnames given to
 // FQDN hostnames and adds the new hostnames to the settings,
 // removing any duplicate.
@@ -57,8 +80,9 @@ func (s *Settings) String() string {
 }
 
 func (s *Settings) Lines(indent, subSection string) (lines []string) {
-	if len(s.IPs) == 0 && len(s.FqdnHostnames) == 0 {
-		return []string{subSection + "Blacklisting is disabled"}
+	if len(s.IPs) == 0 && len(s.FqdnHostnames) == 0 &&
+		len(s.Groups) == 0 && len(s.Scheduled) == 0 {
+		lines = append(lines, subSection+"Blacklisting is disabled")
 	}
 
 	if len(s.IPs) > 0 {
@@ -76,5 +100,22 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 			strconv.Itoa(len(s.FqdnHostnames)))
 	}
 
+	if len(s.PrivateFqdnHostnames) > 0 {
+		lines = append(lines, subSection+"Private domains: "+
+			strconv.Itoa(len(s.PrivateFqdnHostnames)))
+	}
+
+	if s.SafeSearch {
+		lines = append(lines, subSection+"Safe search: enforced")
+	}
+
+	for _, scheduled := range s.Scheduled {
+		lines = append(lines, scheduled.Lines(indent, subSection)...)
+	}
+
+	for _, group := range s.Groups {
+		lines = append(lines, group.Lines(indent, subSection)...)
+	}
+
 	return lines
 }
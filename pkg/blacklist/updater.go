@@ -0,0 +1,148 @@
+package blacklist
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/qdm12/golibs/logging"
+	"inet.af/netaddr"
+)
+
+// Setter sets the blacklist settings to use, for example
+// to swap the blacklist of a running DNS server.
+type Setter interface {
+	SetBlacklist(settings Settings)
+}
+
+// Updater periodically builds the blacklist settings
+// and sets them to a Setter.
+type Updater interface {
+	// Run builds and sets the blacklist settings right away and then
+	// at each period, until the context is canceled. If the period is
+	// zero, periodic updates are disabled and Run returns after the
+	// first update.
+	Run(ctx context.Context)
+}
+
+type updater struct {
+	builder  Builder
+	settings BuilderSettings
+	period   time.Duration
+	setter   Setter
+	logger   logging.Logger
+	current  Settings
+}
+
+// NewUpdater creates an Updater building the blacklist settings with
+// the builder and builder settings given, every period, and setting them
+// to the setter given. A zero or negative period disables periodic updates.
+func NewUpdater(builder Builder, settings BuilderSettings, period time.Duration,
+	setter Setter, logger logging.Logger) Updater {
+	return &updater{
+		builder:  builder,
+		settings: settings,
+		period:   period,
+		setter:   setter,
+		logger:   logger,
+	}
+}
+
+func (u *updater) Run(ctx context.Context) {
+	u.update(ctx)
+
+	if u.period <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(u.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			u.update(ctx)
+		}
+	}
+}
+
+func (u *updater) update(ctx context.Context) {
+	settings, errs := build(ctx, u.builder, u.settings)
+	if ctx.Err() != nil {
+		return
+	}
+	for _, err := range errs {
+		u.logger.Warn(err.Error())
+	}
+
+	added, removed := diffCount(u.current.FqdnHostnames, settings.FqdnHostnames)
+	u.logger.Info("hostnames blocked: " + strconv.Itoa(added) + " added, " +
+		strconv.Itoa(removed) + " removed")
+	added, removed = diffCount(ipsToStrings(u.current.IPs), ipsToStrings(settings.IPs))
+	u.logger.Info("IP addresses blocked: " + strconv.Itoa(added) + " added, " +
+		strconv.Itoa(removed) + " removed")
+	added, removed = diffCount(ipPrefixesToStrings(u.current.IPPrefixes),
+		ipPrefixesToStrings(settings.IPPrefixes))
+	u.logger.Info("IP networks blocked: " + strconv.Itoa(added) + " added, " +
+		strconv.Itoa(removed) + " removed")
+
+	u.setter.SetBlacklist(settings)
+	u.current = settings
+}
+
+// build builds the blacklist settings from the builder settings,
+// including its client groups and scheduled blacklists.
+func build(ctx context.Context, builder Builder, builderSettings BuilderSettings) (
+	settings Settings, errs []error) {
+	blockedHostnames, blockedIPs, blockedIPPrefixes, errs := builder.All(ctx, builderSettings)
+	groups, groupsErrs := builder.Groups(ctx, builderSettings.Groups)
+	errs = append(errs, groupsErrs...)
+	scheduled, scheduledErrs := builder.Scheduled(ctx, builderSettings.Scheduled)
+	errs = append(errs, scheduledErrs...)
+
+	settings.BlockHostnames(blockedHostnames)
+	settings.IPs = blockedIPs
+	settings.IPPrefixes = blockedIPPrefixes
+	settings.SafeSearch = builderSettings.SafeSearch
+	settings.SetPrivateHostnames(builderSettings.PrivateHostnames)
+	settings.Groups = groups
+	settings.Scheduled = scheduled
+	return settings, errs
+}
+
+// diffCount returns the number of strings in newValues not in
+// oldValues, and the number of strings in oldValues not in newValues.
+func diffCount(oldValues, newValues []string) (added, removed int) {
+	oldSet := make(map[string]struct{}, len(oldValues))
+	for _, s := range oldValues {
+		oldSet[s] = struct{}{}
+	}
+
+	for _, s := range newValues {
+		if _, ok := oldSet[s]; ok {
+			delete(oldSet, s)
+			continue
+		}
+		added++
+	}
+
+	return added, len(oldSet)
+}
+
+func ipsToStrings(ips []netaddr.IP) (values []string) {
+	values = make([]string, len(ips))
+	for i := range ips {
+		values[i] = ips[i].String()
+	}
+	return values
+}
+
+func ipPrefixesToStrings(ipPrefixes []netaddr.IPPrefix) (values []string) {
+	values = make([]string, len(ipPrefixes))
+	for i := range ipPrefixes {
+		values[i] = ipPrefixes[i].String()
+	}
+	return values
+}
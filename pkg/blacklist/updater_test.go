@@ -0,0 +1,76 @@
+package blacklist
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdm12/golibs/logging/mock_logging"
+	"inet.af/netaddr"
+)
+
+func Test_updater_update(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	builder := NewMockBuilder(ctrl)
+	setter := NewMockSetter(ctrl)
+	logger := mock_logging.NewMockLogger(ctrl)
+	settings := BuilderSettings{SafeSearch: true}
+
+	updater := NewUpdater(builder, settings, 0, setter, logger).(*updater)
+
+	ctx := context.Background()
+
+	builder.EXPECT().All(ctx, settings).Return([]string{"a.com", "b.com"},
+		[]netaddr.IP{netaddr.IPv4(1, 2, 3, 4)}, nil, nil)
+	builder.EXPECT().Groups(ctx, nil).Return(nil, nil)
+	builder.EXPECT().Scheduled(ctx, nil).Return(nil, nil)
+	logger.EXPECT().Info("hostnames blocked: 2 added, 0 removed")
+	logger.EXPECT().Info("IP addresses blocked: 1 added, 0 removed")
+	logger.EXPECT().Info("IP networks blocked: 0 added, 0 removed")
+	setter.EXPECT().SetBlacklist(Settings{
+		FqdnHostnames:        []string{"a.com.", "b.com."},
+		IPs:                  []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)},
+		SafeSearch:           true,
+		PrivateFqdnHostnames: []string{},
+	})
+	updater.update(ctx)
+
+	builder.EXPECT().All(ctx, settings).Return([]string{"b.com", "c.com", "d.com"},
+		nil, nil, nil)
+	builder.EXPECT().Groups(ctx, nil).Return(nil, nil)
+	builder.EXPECT().Scheduled(ctx, nil).Return(nil, nil)
+	logger.EXPECT().Info("hostnames blocked: 2 added, 1 removed")
+	logger.EXPECT().Info("IP addresses blocked: 0 added, 1 removed")
+	logger.EXPECT().Info("IP networks blocked: 0 added, 0 removed")
+	setter.EXPECT().SetBlacklist(Settings{
+		FqdnHostnames:        []string{"b.com.", "c.com.", "d.com."},
+		SafeSearch:           true,
+		PrivateFqdnHostnames: []string{},
+	})
+	updater.update(ctx)
+}
+
+func Test_updater_Run_zeroPeriod(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	builder := NewMockBuilder(ctrl)
+	setter := NewMockSetter(ctrl)
+	logger := mock_logging.NewMockLogger(ctrl)
+	settings := BuilderSettings{}
+
+	updater := NewUpdater(builder, settings, 0, setter, logger)
+
+	ctx := context.Background()
+
+	builder.EXPECT().All(ctx, settings).Return(nil, nil, nil, nil)
+	builder.EXPECT().Groups(ctx, nil).Return(nil, nil)
+	builder.EXPECT().Scheduled(ctx, nil).Return(nil, nil)
+	logger.EXPECT().Info(gomock.Any()).Times(3)
+	setter.EXPECT().SetBlacklist(gomock.Any())
+
+	// Run returns after the first update since periodic updates are disabled.
+	updater.Run(ctx)
+}
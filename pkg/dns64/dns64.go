@@ -0,0 +1,117 @@
+// Package dns64 synthesizes AAAA records from A records as defined in
+// RFC 6147, for IPv6 only clients reaching IPv4 hosts through NAT64.
+package dns64
+
+import (
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// Synthesizer synthesizes AAAA responses from A responses.
+type Synthesizer struct {
+	enabled    bool
+	prefix     netaddr.IPPrefix
+	ignoreAAAA map[string]struct{}
+}
+
+func NewSynthesizer(settings Settings) *Synthesizer {
+	settings.SetDefaults()
+	ignoreAAAA := make(map[string]struct{}, len(settings.IgnoreAAAAFqdnHostnames))
+	for _, fqdnHostname := range settings.IgnoreAAAAFqdnHostnames {
+		ignoreAAAA[fqdnHostname] = struct{}{}
+	}
+	return &Synthesizer{
+		enabled:    settings.Enabled,
+		prefix:     settings.Prefix.Masked(),
+		ignoreAAAA: ignoreAAAA,
+	}
+}
+
+// Needed returns true if the response to the request should be synthesized
+// from the response to the A request returned by ARequest, which is the
+// case for AAAA requests without AAAA records in their response.
+func (s *Synthesizer) Needed(request, response *dns.Msg) (needed bool) {
+	if !s.enabled || len(request.Question) != 1 ||
+		response.Rcode != dns.RcodeSuccess {
+		return false
+	}
+
+	question := request.Question[0]
+	if question.Qtype != dns.TypeAAAA || question.Qclass != dns.ClassINET {
+		return false
+	}
+
+	if _, ok := s.ignoreAAAA[strings.ToLower(question.Name)]; ok {
+		return true
+	}
+
+	for _, rr := range response.Answer {
+		if rr.Header().Rrtype == dns.TypeAAAA {
+			return false
+		}
+	}
+	return true
+}
+
+// ARequest returns the A request for the question name of the request given.
+func ARequest(request *dns.Msg) (aRequest *dns.Msg) {
+	aRequest = request.Copy()
+	aRequest.Id = dns.Id()
+	aRequest.Question[0].Qtype = dns.TypeA
+	return aRequest
+}
+
+// Synthesize returns a response to the AAAA request given with AAAA records
+// synthesized from the A records of the A response given, keeping its CNAME
+// and DNAME records. It returns nil if the A response has no A record.
+func (s *Synthesizer) Synthesize(request, aResponse *dns.Msg) (response *dns.Msg) {
+	response = new(dns.Msg).SetReply(request)
+	response.RecursionAvailable = aResponse.RecursionAvailable
+
+	synthesized := false
+	for _, rr := range aResponse.Answer {
+		switch record := rr.(type) {
+		case *dns.A:
+			ipv4, ok := netaddr.FromStdIP(record.A)
+			if !ok {
+				continue
+			}
+			header := record.Hdr
+			header.Rrtype = dns.TypeAAAA
+			header.Rdlength = 0
+			response.Answer = append(response.Answer, &dns.AAAA{
+				Hdr:  header,
+				AAAA: s.embed(ipv4),
+			})
+			synthesized = true
+		case *dns.CNAME, *dns.DNAME:
+			response.Answer = append(response.Answer, dns.Copy(rr))
+		}
+	}
+
+	if !synthesized {
+		return nil
+	}
+	return response
+}
+
+// embed embeds the IPv4 address in the IPv6 prefix as defined in
+// RFC 6052 section 2.2, skipping the bits 64 to 71 of the address.
+func (s *Synthesizer) embed(ipv4 netaddr.IP) (ipv6 net.IP) {
+	const bitsPerByte = 8
+	const reservedByte = 8 // bits 64 to 71
+	bytes := s.prefix.IP.As16()
+	ipv4Bytes := ipv4.As4()
+	position := int(s.prefix.Bits) / bitsPerByte
+	for _, b := range ipv4Bytes {
+		if position == reservedByte {
+			position++
+		}
+		bytes[position] = b
+		position++
+	}
+	return net.IP(bytes[:])
+}
@@ -0,0 +1,146 @@
+package dns64
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_Synthesizer_embed(t *testing.T) {
+	t.Parallel()
+
+	// Examples from RFC 6052 section 2.4
+	testCases := map[string]struct {
+		prefix string
+		ipv6   string
+	}{
+		"32 bits":    {prefix: "2001:db8::/32", ipv6: "2001:db8:c000:221::"},
+		"40 bits":    {prefix: "2001:db8:100::/40", ipv6: "2001:db8:1c0:2:21::"},
+		"48 bits":    {prefix: "2001:db8:122::/48", ipv6: "2001:db8:122:c000:2:2100::"},
+		"56 bits":    {prefix: "2001:db8:122:300::/56", ipv6: "2001:db8:122:3c0:0:221::"},
+		"64 bits":    {prefix: "2001:db8:122:344::/64", ipv6: "2001:db8:122:344:c0:2:2100:0"},
+		"96 bits":    {prefix: "2001:db8:122:344::/96", ipv6: "2001:db8:122:344::c000:221"},
+		"well-known": {prefix: "64:ff9b::/96", ipv6: "64:ff9b::c000:221"},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			synthesizer := NewSynthesizer(Settings{
+				Enabled: true,
+				Prefix:  netaddr.MustParseIPPrefix(testCase.prefix),
+			})
+			ipv6 := synthesizer.embed(netaddr.MustParseIP("192.0.2.33"))
+			assert.Equal(t, testCase.ipv6, ipv6.String())
+		})
+	}
+}
+
+func Test_Synthesizer_Needed(t *testing.T) {
+	t.Parallel()
+
+	aaaaRecord := &dns.AAAA{
+		Hdr:  dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeAAAA, Class: dns.ClassINET},
+		AAAA: net.ParseIP("2001:db8::1"),
+	}
+
+	testCases := map[string]struct {
+		settings Settings
+		qtype    uint16
+		name     string
+		rcode    int
+		answer   []dns.RR
+		needed   bool
+	}{
+		"disabled": {
+			qtype: dns.TypeAAAA,
+		},
+		"A request": {
+			settings: Settings{Enabled: true},
+			qtype:    dns.TypeA,
+		},
+		"NXDOMAIN response": {
+			settings: Settings{Enabled: true},
+			qtype:    dns.TypeAAAA,
+			rcode:    dns.RcodeNameError,
+		},
+		"AAAA record": {
+			settings: Settings{Enabled: true},
+			qtype:    dns.TypeAAAA,
+			answer:   []dns.RR{aaaaRecord},
+		},
+		"no AAAA record": {
+			settings: Settings{Enabled: true},
+			qtype:    dns.TypeAAAA,
+			needed:   true,
+		},
+		"AAAA record ignored": {
+			settings: Settings{
+				Enabled:                 true,
+				IgnoreAAAAFqdnHostnames: []string{"example.com."},
+			},
+			qtype:  dns.TypeAAAA,
+			answer: []dns.RR{aaaaRecord},
+			needed: true,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			synthesizer := NewSynthesizer(testCase.settings)
+			request := new(dns.Msg).SetQuestion("example.com.", testCase.qtype)
+			response := new(dns.Msg).SetRcode(request, testCase.rcode)
+			response.Answer = testCase.answer
+
+			needed := synthesizer.Needed(request, response)
+
+			assert.Equal(t, testCase.needed, needed)
+		})
+	}
+}
+
+func Test_Synthesizer_Synthesize(t *testing.T) {
+	t.Parallel()
+
+	synthesizer := NewSynthesizer(Settings{Enabled: true})
+	request := new(dns.Msg).SetQuestion("www.example.com.", dns.TypeAAAA)
+
+	aRequest := ARequest(request)
+	assert.Equal(t, dns.TypeA, aRequest.Question[0].Qtype)
+	assert.Equal(t, dns.TypeAAAA, request.Question[0].Qtype)
+
+	aResponse := new(dns.Msg).SetReply(aRequest)
+	aResponse.Answer = []dns.RR{
+		&dns.CNAME{
+			Hdr:    dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60},
+			Target: "example.com.",
+		},
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+			A:   net.IP{192, 0, 2, 33},
+		},
+	}
+
+	response := synthesizer.Synthesize(request, aResponse)
+
+	require.NotNil(t, response)
+	assert.Equal(t, request.Id, response.Id)
+	answer := make([]string, len(response.Answer))
+	for i, rr := range response.Answer {
+		answer[i] = rr.String()
+	}
+	expected := []string{
+		"www.example.com.\t60\tIN\tCNAME\texample.com.",
+		"example.com.\t30\tIN\tAAAA\t64:ff9b::c000:221",
+	}
+	assert.Equal(t, expected, answer)
+
+	aResponse.Answer = aResponse.Answer[:1]
+	response = synthesizer.Synthesize(request, aResponse)
+	assert.Nil(t, response)
+}
@@ -0,0 +1,87 @@
+package dns64
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+type Settings struct {
+	Enabled bool
+	// Prefix is the IPv6 prefix used to synthesize AAAA records, and
+	// defaults to the well-known prefix 64:ff9b::/96. Its length must
+	// be 32, 40, 48, 56, 64 or 96 bits as defined in RFC 6052.
+	Prefix netaddr.IPPrefix
+	// IgnoreAAAAFqdnHostnames are hostnames for which the AAAA
+	// records are ignored and synthesized from the A records.
+	IgnoreAAAAFqdnHostnames []string
+}
+
+func (s *Settings) SetDefaults() {
+	if s.Prefix.IP.IsZero() {
+		s.Prefix = netaddr.MustParseIPPrefix("64:ff9b::/96")
+	}
+}
+
+var (
+	ErrPrefixNotIPv6         = errors.New("prefix is not an IPv6 prefix")
+	ErrPrefixLengthInvalid   = errors.New("prefix length is not one of 32, 40, 48, 56, 64 or 96")
+	ErrPrefixReservedBitsSet = errors.New("prefix bits 64 to 71 are not zero")
+)
+
+// Validate returns an error if the prefix cannot be used to synthesize
+// AAAA records as defined in RFC 6052 section 2.2.
+func (s *Settings) Validate() (err error) {
+	if !s.Prefix.IP.Is6() || s.Prefix.IP.Is4in6() {
+		return fmt.Errorf("%w: %s", ErrPrefixNotIPv6, s.Prefix)
+	}
+
+	switch s.Prefix.Bits {
+	case 32, 40, 48, 56, 64, 96: //nolint:gomnd
+	default:
+		return fmt.Errorf("%w: %s", ErrPrefixLengthInvalid, s.Prefix)
+	}
+
+	const reservedByte = 8 // bits 64 to 71
+	if s.Prefix.Masked().IP.As16()[reservedByte] != 0 {
+		return fmt.Errorf("%w: %s", ErrPrefixReservedBitsSet, s.Prefix)
+	}
+
+	return nil
+}
+
+// SetIgnoreAAAAHostnames transforms the slice of hostnames given to lower
+// cased FQDN hostnames and sets these as the hostnames for which the AAAA
+// records are ignored.
+func (s *Settings) SetIgnoreAAAAHostnames(hostnames []string) {
+	s.IgnoreAAAAFqdnHostnames = make([]string, len(hostnames))
+	for i := range hostnames {
+		s.IgnoreAAAAFqdnHostnames[i] = strings.ToLower(dns.Fqdn(hostnames[i]))
+	}
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled {
+		return []string{subSection + "DNS64: disabled"}
+	}
+
+	lines = append(lines, subSection+"DNS64: enabled")
+	lines = append(lines, indent+subSection+"Prefix: "+s.Prefix.String())
+	if len(s.IgnoreAAAAFqdnHostnames) > 0 {
+		lines = append(lines, indent+subSection+"AAAA records ignored for: "+
+			strings.Join(s.IgnoreAAAAFqdnHostnames, ", "))
+	}
+
+	return lines
+}
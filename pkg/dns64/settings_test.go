@@ -0,0 +1,79 @@
+package dns64
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_Settings_Validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		prefix string
+		err    error
+		errMsg string
+	}{
+		"well-known prefix": {
+			prefix: "64:ff9b::/96",
+		},
+		"32 bits prefix": {
+			prefix: "2001:db8::/32",
+		},
+		"64 bits prefix": {
+			prefix: "2001:db8:1:2::/64",
+		},
+		"IPv4 prefix": {
+			prefix: "10.0.0.0/8",
+			err:    ErrPrefixNotIPv6,
+			errMsg: "prefix is not an IPv6 prefix: 10.0.0.0/8",
+		},
+		"IPv4 mapped prefix": {
+			prefix: "::ffff:0:0/96",
+			err:    ErrPrefixNotIPv6,
+			errMsg: "prefix is not an IPv6 prefix: ::ffff:0:0/96",
+		},
+		"length 0": {
+			prefix: "::/0",
+			err:    ErrPrefixLengthInvalid,
+			errMsg: "prefix length is not one of 32, 40, 48, 56, 64 or 96: ::/0",
+		},
+		"length 33": {
+			prefix: "2001:db8::/33",
+			err:    ErrPrefixLengthInvalid,
+			errMsg: "prefix length is not one of 32, 40, 48, 56, 64 or 96: 2001:db8::/33",
+		},
+		"length 97": {
+			prefix: "64:ff9b::/97",
+			err:    ErrPrefixLengthInvalid,
+			errMsg: "prefix length is not one of 32, 40, 48, 56, 64 or 96: 64:ff9b::/97",
+		},
+		"length 128": {
+			prefix: "64:ff9b::/128",
+			err:    ErrPrefixLengthInvalid,
+			errMsg: "prefix length is not one of 32, 40, 48, 56, 64 or 96: 64:ff9b::/128",
+		},
+		"reserved bits set": {
+			prefix: "2001:db8:0:0:ff00::/96",
+			err:    ErrPrefixReservedBitsSet,
+			errMsg: "prefix bits 64 to 71 are not zero: 2001:db8:0:0:ff00::/96",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := Settings{Prefix: netaddr.MustParseIPPrefix(testCase.prefix)}
+
+			err := settings.Validate()
+
+			assert.ErrorIs(t, err, testCase.err)
+			if testCase.err != nil {
+				assert.EqualError(t, err, testCase.errMsg)
+			}
+		})
+	}
+}
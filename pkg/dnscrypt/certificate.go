@@ -0,0 +1,153 @@
+package dnscrypt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+const clientMagicSize = 8
+
+// certificate is a DNSCrypt resolver certificate, signed
+// with the Ed25519 private key of the provider.
+type certificate struct {
+	system      encryptionSystem
+	resolverKey [keySize]byte
+	clientMagic [clientMagicSize]byte
+	serial      uint32
+	notBefore   time.Time
+	notAfter    time.Time
+}
+
+var (
+	ErrCertificateTooShort  = errors.New("certificate is too short")
+	ErrCertificateMagic     = errors.New("certificate magic is invalid")
+	ErrCertificateSignature = errors.New("certificate signature is invalid")
+	ErrNoValidCertificate   = errors.New("no valid certificate")
+)
+
+// certificateMagic is the magic prefix of certificates.
+const certificateMagic = "DNSC"
+
+// parseCertificate parses the certificate and verifies its
+// signature with the public key of the provider given.
+func parseCertificate(bin []byte, providerKey ed25519.PublicKey) (
+	cert certificate, err error) {
+	const (
+		systemOffset    = 4
+		signatureOffset = 8
+		signedOffset    = signatureOffset + ed25519.SignatureSize
+		minLength       = signedOffset + keySize + clientMagicSize + 3*4 //nolint:gomnd
+	)
+	if len(bin) < minLength {
+		return cert, fmt.Errorf("%w: %d bytes instead of at least %d",
+			ErrCertificateTooShort, len(bin), minLength)
+	} else if string(bin[:systemOffset]) != certificateMagic {
+		return cert, fmt.Errorf("%w: %q", ErrCertificateMagic, bin[:systemOffset])
+	}
+
+	// The signed part includes the optional extensions at its end.
+	signature, signed := bin[signatureOffset:signedOffset], bin[signedOffset:]
+	if !ed25519.Verify(providerKey, signed, signature) {
+		return cert, ErrCertificateSignature
+	}
+
+	cert.system = encryptionSystem(binary.BigEndian.Uint16(bin[systemOffset:]))
+	copy(cert.resolverKey[:], signed)
+	signed = signed[keySize:]
+	copy(cert.clientMagic[:], signed)
+	signed = signed[clientMagicSize:]
+	cert.serial = binary.BigEndian.Uint32(signed)
+	cert.notBefore = time.Unix(int64(binary.BigEndian.Uint32(signed[4:])), 0)
+	cert.notAfter = time.Unix(int64(binary.BigEndian.Uint32(signed[8:])), 0)
+	return cert, nil
+}
+
+// fetchCertificate queries the TXT records of the provider name from
+// the DNSCrypt server at the address given, and returns the currently
+// valid certificate with the highest serial, preferring XChaCha20 over
+// XSalsa20 for the same serial.
+func fetchCertificate(ctx context.Context, address string,
+	server provider.DNSCryptServer) (cert certificate, err error) {
+	request := new(dns.Msg).SetQuestion(dns.Fqdn(server.ProviderName), dns.TypeTXT)
+	request.SetEdns0(dns.DefaultMsgSize, false)
+
+	client := &dns.Client{}
+	response, _, err := client.ExchangeContext(ctx, request, address)
+	if err == nil && response.Truncated {
+		client = &dns.Client{Net: "tcp"}
+		response, _, err = client.ExchangeContext(ctx, request, address)
+	}
+	if err != nil {
+		return cert, fmt.Errorf("cannot fetch certificates: %w", err)
+	}
+
+	now := time.Now()
+	found := false
+	var errs []string
+	for _, rr := range response.Answer {
+		txt, ok := rr.(*dns.TXT)
+		if !ok {
+			continue
+		}
+
+		candidate, err := parseCertificate(unescapeTXT(strings.Join(txt.Txt, "")),
+			ed25519.PublicKey(server.PublicKey))
+		switch {
+		case err != nil:
+			errs = append(errs, err.Error())
+			continue
+		case candidate.system != xsalsa20Poly1305 && candidate.system != xchacha20Poly1305,
+			now.Before(candidate.notBefore), now.After(candidate.notAfter):
+			continue
+		case !found,
+			candidate.serial > cert.serial,
+			candidate.serial == cert.serial && candidate.system > cert.system:
+			cert = candidate
+			found = true
+		}
+	}
+
+	if !found {
+		if len(errs) > 0 {
+			return cert, fmt.Errorf("%w: for %s: %s", ErrNoValidCertificate,
+				server.ProviderName, strings.Join(errs, "; "))
+		}
+		return cert, fmt.Errorf("%w: for %s", ErrNoValidCertificate, server.ProviderName)
+	}
+
+	return cert, nil
+}
+
+// unescapeTXT returns the bytes of the TXT string given, where the
+// miekg/dns library escapes bytes as \DDD and special characters as \X.
+func unescapeTXT(s string) (bin []byte) {
+	bin = make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			bin = append(bin, s[i])
+			continue
+		}
+
+		i++
+		const decimalLength = 3
+		if i+decimalLength <= len(s) {
+			value, err := strconv.ParseUint(s[i:i+decimalLength], 10, 8) //nolint:gomnd
+			if err == nil {
+				bin = append(bin, byte(value))
+				i += decimalLength - 1
+				continue
+			}
+		}
+		bin = append(bin, s[i])
+	}
+	return bin
+}
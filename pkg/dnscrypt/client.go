@@ -0,0 +1,340 @@
+package dnscrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/provider"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrNoServer         = errors.New("no DNSCrypt server")
+	ErrNoServerIP       = errors.New("no IP address for DNSCrypt server")
+	ErrIDMismatch       = errors.New("DNS response ID does not match the request ID")
+	ErrResponseTooShort = errors.New("DNSCrypt response is too short")
+	ErrResolverMagic    = errors.New("DNSCrypt response magic is invalid")
+	ErrNonceMismatch    = errors.New("DNSCrypt response nonce does not match the query nonce")
+)
+
+// resolverMagic is the magic prefix of responses.
+const resolverMagic = "r6fnvWj8"
+
+// lengthPrefix is the size of the message length prefix
+// of DNS messages over stream connections.
+const lengthPrefix = 2
+
+// Client is a DNSCrypt client exchanging DNS messages with
+// the DNSCrypt servers of the providers from its settings.
+type Client struct {
+	servers []provider.DNSCryptServer
+	picker  *exchange.Picker
+	timeout time.Duration
+	ipv6    bool
+
+	// sessions maps a server address to its certificate and shared key.
+	sessions      map[string]*session
+	sessionsMutex sync.Mutex
+	// fetches ensures a single certificate fetch is in flight
+	// for each server address.
+	fetches singleflight.Group
+}
+
+// session contains the certificate of a server, and the public key
+// of the client and the shared key generated for this certificate.
+type session struct {
+	certificate certificate
+	publicKey   [keySize]byte
+	sharedKey   [keySize]byte
+}
+
+// NewClient creates a DNSCrypt client.
+func NewClient(settings ResolverSettings) *Client {
+	settings.setDefaults()
+
+	servers := make([]provider.DNSCryptServer, len(settings.DNSCryptProviders))
+	for i := range settings.DNSCryptProviders {
+		servers[i] = settings.DNSCryptProviders[i].DNSCrypt()
+	}
+
+	return &Client{
+		servers:  servers,
+		picker:   exchange.NewPicker(), // fast thread safe random picker
+		timeout:  settings.Timeout,
+		ipv6:     settings.IPv6,
+		sessions: make(map[string]*session, len(servers)),
+	}
+}
+
+// Exchange sends the DNS request to a DNSCrypt server picked pseudo-randomly
+// and returns its DNS response. It is safe to call concurrently.
+func (c *Client) Exchange(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, err error) {
+	if len(c.servers) == 0 {
+		return nil, ErrNoServer
+	}
+	server := c.pickServer()
+
+	query, err := request.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("cannot pack DNS request: %w", err)
+	}
+
+	wire, err := c.exchangeWire(ctx, server, query)
+	if err != nil {
+		return nil, err
+	}
+
+	response = new(dns.Msg)
+	if err := response.Unpack(wire); err != nil {
+		return nil, fmt.Errorf("cannot unpack DNS response: %w", err)
+	}
+
+	if response.Id != request.Id {
+		return nil, fmt.Errorf("%w: %d instead of %d", ErrIDMismatch, response.Id, request.Id)
+	}
+
+	return response, nil
+}
+
+func (c *Client) pickServer() provider.DNSCryptServer {
+	return c.servers[c.picker.Index(len(c.servers))]
+}
+
+// exchangeWire sends the DNS query wire encrypted to the DNSCrypt
+// server given over UDP, and returns the decrypted DNS response wire.
+// The query is sent again over TCP if the response is truncated.
+func (c *Client) exchangeWire(ctx context.Context, server provider.DNSCryptServer,
+	query []byte) (response []byte, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	ip := c.picker.ServerIP(server.IPv4, server.IPv6, c.ipv6)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoServerIP, server.ProviderName)
+	}
+	address := net.JoinHostPort(ip.String(), strconv.Itoa(int(server.Port)))
+
+	session, err := c.getSession(ctx, address, server)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err = exchangeEncrypted(ctx, "udp", address, session, query)
+	if err != nil {
+		return nil, err
+	}
+
+	const flagsOffset, truncatedFlag = 2, 0x02
+	if response[flagsOffset]&truncatedFlag != 0 {
+		response, err = exchangeEncrypted(ctx, "tcp", address, session, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// getSession returns the session for the server at the address given,
+// fetching the certificate of the server and generating a new key pair
+// if there is no session or if its certificate expired.
+func (c *Client) getSession(ctx context.Context, address string,
+	server provider.DNSCryptServer) (s *session, err error) {
+	if s := c.validSession(address); s != nil {
+		return s, nil
+	}
+
+	// Fetch the certificate without holding the sessions mutex, such that
+	// exchanges with other servers are not blocked during the fetch.
+	result := c.fetches.DoChan(address, func() (interface{}, error) {
+		if s := c.validSession(address); s != nil {
+			return s, nil // fetched just before by another exchange
+		}
+
+		s, err := newSession(ctx, address, server)
+		if err != nil {
+			return nil, err
+		}
+
+		c.sessionsMutex.Lock()
+		c.sessions[address] = s
+		c.sessionsMutex.Unlock()
+		return s, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case fetch := <-result:
+		if fetch.Err != nil {
+			return nil, fetch.Err
+		}
+		return fetch.Val.(*session), nil
+	}
+}
+
+// validSession returns the session for the server at the address
+// given, or nil if there is none or if its certificate expired.
+func (c *Client) validSession(address string) (s *session) {
+	c.sessionsMutex.Lock()
+	defer c.sessionsMutex.Unlock()
+	s, ok := c.sessions[address]
+	if !ok || !time.Now().Before(s.certificate.notAfter) {
+		return nil
+	}
+	return s
+}
+
+// newSession fetches the certificate of the server at the address
+// given and generates a new key pair for it.
+func newSession(ctx context.Context, address string,
+	server provider.DNSCryptServer) (s *session, err error) {
+	cert, err := fetchCertificate(ctx, address, server)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, secretKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate key pair: %w", err)
+	}
+
+	s = &session{
+		certificate: cert,
+		publicKey:   *publicKey,
+	}
+	s.sharedKey, err = computeSharedKey(cert.system, secretKey, &cert.resolverKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// exchangeEncrypted encrypts the DNS query wire, exchanges it with the
+// DNSCrypt server over the network given, and returns the decrypted DNS
+// response wire, which is at least as long as a DNS message header.
+func exchangeEncrypted(ctx context.Context, network, address string,
+	session *session, query []byte) (response []byte, err error) {
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:halfNonceSize]); err != nil {
+		return nil, err
+	}
+
+	minSize := 0
+	if network == "udp" {
+		minSize = minQuerySize
+	}
+	encrypted := seal(session.certificate.system, &session.sharedKey,
+		&nonce, pad(query, minSize))
+
+	packet := make([]byte, 0, clientMagicSize+keySize+halfNonceSize+len(encrypted))
+	packet = append(packet, session.certificate.clientMagic[:]...)
+	packet = append(packet, session.publicKey[:]...)
+	packet = append(packet, nonce[:halfNonceSize]...)
+	packet = append(packet, encrypted...)
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if network == "udp" {
+		packet, err = exchangeUDP(conn, packet)
+	} else {
+		packet, err = exchangeTCP(conn, packet)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return openResponse(session, &nonce, packet)
+}
+
+func exchangeUDP(conn net.Conn, packet []byte) (response []byte, err error) {
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+
+	response = make([]byte, dns.MaxMsgSize)
+	n, err := conn.Read(response)
+	if err != nil {
+		return nil, err
+	}
+	return response[:n], nil
+}
+
+func exchangeTCP(conn net.Conn, packet []byte) (response []byte, err error) {
+	message := make([]byte, lengthPrefix+len(packet))
+	binary.BigEndian.PutUint16(message, uint16(len(packet)))
+	copy(message[lengthPrefix:], packet)
+	if _, err := conn.Write(message); err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, lengthPrefix)
+	if _, err := io.ReadFull(conn, prefix); err != nil {
+		return nil, err
+	}
+	response = make([]byte, binary.BigEndian.Uint16(prefix))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// openResponse checks the DNSCrypt response packet is for the query
+// with the nonce given, and returns its decrypted DNS response wire.
+func openResponse(session *session, queryNonce *[nonceSize]byte,
+	packet []byte) (response []byte, err error) {
+	const (
+		headerLength = 12
+		minLength    = len(resolverMagic) + nonceSize + tagSize + headerLength
+	)
+	switch {
+	case len(packet) < minLength:
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooShort, len(packet))
+	case string(packet[:len(resolverMagic)]) != resolverMagic:
+		return nil, ErrResolverMagic
+	}
+	packet = packet[len(resolverMagic):]
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], packet)
+	if !bytes.Equal(nonce[:halfNonceSize], queryNonce[:halfNonceSize]) {
+		return nil, ErrNonceMismatch
+	}
+
+	padded, err := open(session.certificate.system, &session.sharedKey,
+		&nonce, packet[nonceSize:])
+	if err != nil {
+		return nil, err
+	}
+
+	response, err = unpad(padded)
+	if err != nil {
+		return nil, err
+	} else if len(response) < headerLength {
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooShort, len(response))
+	}
+	return response, nil
+}
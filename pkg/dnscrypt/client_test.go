@@ -0,0 +1,307 @@
+package dnscrypt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// testServer is an in-process DNSCrypt server, answering
+// over UDP and TCP with the handler given.
+type testServer struct {
+	t              *testing.T
+	system         encryptionSystem
+	certificate    []byte
+	clientMagic    [clientMagicSize]byte
+	resolverSecret *[keySize]byte
+	handler        dns.HandlerFunc
+	// truncateUDP makes the server answer truncated responses over UDP.
+	truncateUDP bool
+}
+
+// newTestServer starts a test DNSCrypt server and
+// returns the DNSCrypt server description to reach it.
+func newTestServer(t *testing.T, system encryptionSystem, handler dns.HandlerFunc,
+	truncateUDP bool) provider.DNSCryptServer {
+	t.Helper()
+
+	providerPublicKey, providerPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	resolverPublicKey, resolverSecretKey, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	s := &testServer{
+		t:              t,
+		system:         system,
+		clientMagic:    [clientMagicSize]byte{'c', 'l', 'i', 'e', 'n', 't', 'm', 'g'},
+		resolverSecret: resolverSecretKey,
+		handler:        handler,
+		truncateUDP:    truncateUDP,
+	}
+
+	signed := append([]byte{}, resolverPublicKey[:]...)
+	signed = append(signed, s.clientMagic[:]...)
+	signed = binary.BigEndian.AppendUint32(signed, 1) // serial
+	signed = binary.BigEndian.AppendUint32(signed, uint32(time.Now().Add(-time.Hour).Unix()))
+	signed = binary.BigEndian.AppendUint32(signed, uint32(time.Now().Add(time.Hour).Unix()))
+	s.certificate = append([]byte(certificateMagic), 0, byte(system), 0, 0)
+	s.certificate = append(s.certificate, ed25519.Sign(providerPrivateKey, signed)...)
+	s.certificate = append(s.certificate, signed...)
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = packetConn.Close() })
+	address := packetConn.LocalAddr().(*net.UDPAddr)
+	listener, err := net.Listen("tcp", address.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go s.serveUDP(packetConn)
+	go s.serveTCP(listener)
+
+	return provider.DNSCryptServer{
+		IPv4:         []net.IP{address.IP},
+		Port:         uint16(address.Port),
+		ProviderName: "2.dnscrypt-cert.example.com",
+		PublicKey:    providerPublicKey,
+	}
+}
+
+func (s *testServer) serveUDP(packetConn net.PacketConn) {
+	buffer := make([]byte, dns.MaxMsgSize)
+	for {
+		n, address, err := packetConn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		_, _ = packetConn.WriteTo(s.handle(buffer[:n], true), address)
+	}
+}
+
+func (s *testServer) serveTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		packet, err := readPrefixed(conn)
+		if err == nil {
+			reply := s.handle(packet, false)
+			_, _ = conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(reply))))
+			_, _ = conn.Write(reply)
+		}
+		_ = conn.Close()
+	}
+}
+
+func readPrefixed(reader io.Reader) (message []byte, err error) {
+	prefix := make([]byte, lengthPrefix)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return nil, err
+	}
+	message = make([]byte, binary.BigEndian.Uint16(prefix))
+	_, err = io.ReadFull(reader, message)
+	return message, err
+}
+
+// handle answers the certificate request in plaintext,
+// and decrypts and encrypts other exchanges.
+func (s *testServer) handle(packet []byte, udp bool) (reply []byte) {
+	t := s.t
+	if string(packet[:clientMagicSize]) != string(s.clientMagic[:]) {
+		request := new(dns.Msg)
+		require.NoError(t, request.Unpack(packet))
+		response := new(dns.Msg).SetReply(request)
+		response.Answer = []dns.RR{&dns.TXT{
+			Hdr: dns.RR_Header{Name: request.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET},
+			Txt: []string{escapeTXT(s.certificate)},
+		}}
+		reply, err := response.Pack()
+		require.NoError(t, err)
+		return reply
+	}
+
+	packet = packet[clientMagicSize:]
+	var clientPublicKey [keySize]byte
+	copy(clientPublicKey[:], packet)
+	packet = packet[keySize:]
+	var nonce [nonceSize]byte
+	copy(nonce[:], packet[:halfNonceSize])
+	packet = packet[halfNonceSize:]
+
+	sharedKey, err := computeSharedKey(s.system, s.resolverSecret, &clientPublicKey)
+	require.NoError(t, err)
+	padded, err := open(s.system, &sharedKey, &nonce, packet)
+	require.NoError(t, err)
+	if udp {
+		assert.GreaterOrEqual(t, len(padded), minQuerySize)
+	}
+	query, err := unpad(padded)
+	require.NoError(t, err)
+
+	request := new(dns.Msg)
+	require.NoError(t, request.Unpack(query))
+	writer := &testResponseWriter{}
+	s.handler(writer, request)
+	response := writer.response
+	if udp && s.truncateUDP {
+		response = new(dns.Msg).SetReply(request)
+		response.Truncated = true
+	}
+	wire, err := response.Pack()
+	require.NoError(t, err)
+
+	_, err = rand.Read(nonce[halfNonceSize:])
+	require.NoError(t, err)
+	reply = append([]byte(resolverMagic), nonce[:]...)
+	return append(reply, seal(s.system, &sharedKey, &nonce, pad(wire, 0))...)
+}
+
+// escapeTXT escapes the bytes as expected by the miekg/dns library.
+func escapeTXT(bin []byte) (s string) {
+	for _, b := range bin {
+		if b < ' ' || b > '~' || b == '"' || b == '\\' || b == ';' {
+			s += fmt.Sprintf("\\%03d", b)
+			continue
+		}
+		s += string(b)
+	}
+	return s
+}
+
+type testResponseWriter struct {
+	dns.ResponseWriter
+	response *dns.Msg
+}
+
+func (w *testResponseWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+func answerHandler(w dns.ResponseWriter, request *dns.Msg) {
+	response := new(dns.Msg).SetReply(request)
+	question := request.Question[0]
+	header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: 300}
+	switch question.Qtype {
+	case dns.TypeA:
+		response.Answer = []dns.RR{&dns.A{Hdr: header, A: net.IP{1, 2, 3, 4}}}
+	case dns.TypeAAAA:
+		response.Answer = []dns.RR{&dns.AAAA{Hdr: header, AAAA: net.ParseIP("2001:db8::1")}}
+	}
+	_ = w.WriteMsg(response)
+}
+
+// newTestClient returns a client using the DNSCrypt server given.
+func newTestClient(t *testing.T, server provider.DNSCryptServer) *Client {
+	t.Helper()
+
+	customProvider, err := provider.NewCustom(provider.CustomSettings{
+		Name:     "test",
+		DNSCrypt: server,
+	})
+	require.NoError(t, err)
+
+	return NewClient(ResolverSettings{
+		DNSCryptProviders: []provider.Provider{customProvider},
+	})
+}
+
+func Test_Client_Exchange(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		system      encryptionSystem
+		truncateUDP bool
+		otherKey    bool
+		err         error
+	}{
+		"XSalsa20Poly1305": {
+			system: xsalsa20Poly1305,
+		},
+		"XChaCha20Poly1305": {
+			system: xchacha20Poly1305,
+		},
+		"truncated over UDP": {
+			system:      xchacha20Poly1305,
+			truncateUDP: true,
+		},
+		"other provider public key": {
+			system:   xchacha20Poly1305,
+			otherKey: true,
+			err:      ErrNoValidCertificate,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newTestServer(t, testCase.system, answerHandler, testCase.truncateUDP)
+			if testCase.otherKey {
+				publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+				require.NoError(t, err)
+				server.PublicKey = publicKey
+			}
+			client := newTestClient(t, server)
+			request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+
+			response, err := client.Exchange(context.Background(), request)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, request.Id, response.Id)
+			require.Len(t, response.Answer, 1)
+			assert.Equal(t, "example.com.\t300\tIN\tA\t1.2.3.4", response.Answer[0].String())
+
+			address := net.JoinHostPort(server.IPv4[0].String(), strconv.Itoa(int(server.Port)))
+			assert.Equal(t, testCase.system, client.sessions[address].certificate.system)
+		})
+	}
+}
+
+func Test_Client_Exchange_noServer(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(ResolverSettings{})
+
+	_, err := client.Exchange(context.Background(), new(dns.Msg))
+
+	assert.ErrorIs(t, err, ErrNoServer)
+}
+
+func Test_Client_resolverDial(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, xchacha20Poly1305, answerHandler, false)
+	client := newTestClient(t, server)
+	resolver := &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: true,
+		Dial:         exchange.NewDial(client),
+	}
+
+	ips, err := resolver.LookupIP(context.Background(), "ip", "example.com")
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []net.IP{{1, 2, 3, 4}, net.ParseIP("2001:db8::1")}, ips)
+}
@@ -0,0 +1,188 @@
+package dnscrypt
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/poly1305" //nolint:staticcheck
+)
+
+// encryptionSystem is the encryption system of a resolver certificate.
+type encryptionSystem uint16
+
+const (
+	xsalsa20Poly1305  encryptionSystem = 1
+	xchacha20Poly1305 encryptionSystem = 2
+)
+
+func (e encryptionSystem) String() string {
+	switch e {
+	case xsalsa20Poly1305:
+		return "X25519-XSalsa20Poly1305"
+	case xchacha20Poly1305:
+		return "X25519-XChacha20Poly1305"
+	default:
+		return fmt.Sprintf("unknown encryption system %d", uint16(e))
+	}
+}
+
+const (
+	keySize       = 32
+	nonceSize     = 24
+	halfNonceSize = nonceSize / 2
+	tagSize       = poly1305.TagSize
+)
+
+var (
+	ErrEncryptionSystem = errors.New("encryption system is not supported")
+	ErrSharedKeyWeak    = errors.New("shared key is weak")
+	ErrDecryption       = errors.New("cannot decrypt and authenticate")
+)
+
+// computeSharedKey returns the key shared with the resolver
+// for the encryption system given.
+func computeSharedKey(system encryptionSystem, secretKey,
+	resolverPublicKey *[keySize]byte) (sharedKey [keySize]byte, err error) {
+	switch system {
+	case xsalsa20Poly1305:
+		box.Precompute(&sharedKey, resolverPublicKey, secretKey)
+	case xchacha20Poly1305:
+		// The X25519 shared secret is hashed with HChaCha20,
+		// as for the XSalsa20 box with HSalsa20.
+		secret, err := curve25519.X25519(secretKey[:], resolverPublicKey[:])
+		if err != nil {
+			return sharedKey, fmt.Errorf("%w: %s", ErrSharedKeyWeak, err)
+		}
+		subKey, err := chacha20.HChaCha20(secret, make([]byte, 16)) //nolint:gomnd
+		if err != nil {
+			return sharedKey, err
+		}
+		copy(sharedKey[:], subKey)
+	default:
+		return sharedKey, fmt.Errorf("%w: %s", ErrEncryptionSystem, system)
+	}
+	return sharedKey, nil
+}
+
+// seal encrypts and authenticates the plaintext with the encryption
+// system given, returning the authentication tag followed by the
+// ciphertext as for the NaCl secretbox.
+func seal(system encryptionSystem, sharedKey *[keySize]byte,
+	nonce *[nonceSize]byte, plaintext []byte) (sealed []byte) {
+	if system == xchacha20Poly1305 {
+		return xchachaSeal(sharedKey, nonce, plaintext)
+	}
+	return secretbox.Seal(nil, plaintext, nonce, sharedKey)
+}
+
+// open authenticates and decrypts the sealed data with the encryption
+// system given, and returns the plaintext.
+func open(system encryptionSystem, sharedKey *[keySize]byte,
+	nonce *[nonceSize]byte, sealed []byte) (plaintext []byte, err error) {
+	var ok bool
+	if system == xchacha20Poly1305 {
+		plaintext, ok = xchachaOpen(sharedKey, nonce, sealed)
+	} else {
+		plaintext, ok = secretbox.Open(nil, sealed, nonce, sharedKey)
+	}
+	if !ok {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+// xchachaSeal is the secretbox construction using XChaCha20 instead of
+// XSalsa20, as crypto_secretbox_xchacha20poly1305 from libsodium. The
+// first 32 bytes of the key stream are the Poly1305 one time key, and
+// the plaintext is encrypted with the rest of the key stream.
+func xchachaSeal(key *[keySize]byte, nonce *[nonceSize]byte,
+	plaintext []byte) (sealed []byte) {
+	stream, _ := chacha20.NewUnauthenticatedCipher(key[:], nonce[:]) // sizes are valid
+
+	buffer := make([]byte, keySize+len(plaintext))
+	copy(buffer[keySize:], plaintext)
+	stream.XORKeyStream(buffer, buffer)
+
+	var polyKey [keySize]byte
+	copy(polyKey[:], buffer[:keySize])
+	ciphertext := buffer[keySize:]
+
+	var tag [tagSize]byte
+	poly1305.Sum(&tag, ciphertext, &polyKey)
+
+	sealed = make([]byte, 0, tagSize+len(ciphertext))
+	sealed = append(sealed, tag[:]...)
+	return append(sealed, ciphertext...)
+}
+
+func xchachaOpen(key *[keySize]byte, nonce *[nonceSize]byte,
+	sealed []byte) (plaintext []byte, ok bool) {
+	if len(sealed) < tagSize {
+		return nil, false
+	}
+	var tag [tagSize]byte
+	copy(tag[:], sealed[:tagSize])
+	ciphertext := sealed[tagSize:]
+
+	stream, _ := chacha20.NewUnauthenticatedCipher(key[:], nonce[:]) // sizes are valid
+
+	var polyKey [keySize]byte
+	stream.XORKeyStream(polyKey[:], polyKey[:])
+
+	var expectedTag [tagSize]byte
+	poly1305.Sum(&expectedTag, ciphertext, &polyKey)
+	if subtle.ConstantTimeCompare(tag[:], expectedTag[:]) != 1 {
+		return nil, false
+	}
+
+	plaintext = make([]byte, len(ciphertext))
+	stream.XORKeyStream(plaintext, ciphertext)
+	return plaintext, true
+}
+
+const (
+	// minQuerySize is the minimum size of padded queries over UDP,
+	// to prevent amplification attacks.
+	minQuerySize = 256
+	// paddingBlockSize is the size queries are padded to a multiple of.
+	paddingBlockSize = 64
+)
+
+// pad pads the query with the ISO/IEC 7816-4 padding to a multiple of
+// 64 bytes, and to at least the minimum size given.
+func pad(query []byte, minSize int) (padded []byte) {
+	size := len(query) + 1
+	if size < minSize {
+		size = minSize
+	}
+	if remainder := size % paddingBlockSize; remainder != 0 {
+		size += paddingBlockSize - remainder
+	}
+
+	padded = make([]byte, size)
+	copy(padded, query)
+	padded[len(query)] = 0x80
+	return padded
+}
+
+var ErrPadding = errors.New("padding is invalid")
+
+// unpad removes the ISO/IEC 7816-4 padding of the message.
+func unpad(padded []byte) (message []byte, err error) {
+	for i := len(padded) - 1; i >= 0; i-- {
+		switch padded[i] {
+		case 0x00:
+			continue
+		case 0x80:
+			return padded[:i], nil
+		default:
+			return nil, ErrPadding
+		}
+	}
+	return nil, ErrPadding
+}
@@ -0,0 +1,117 @@
+package dnscrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_xchacha20Poly1305(t *testing.T) {
+	t.Parallel()
+
+	// Expected values are computed with libsodium, using
+	// crypto_box_curve25519xchacha20poly1305_beforenm and
+	// crypto_secretbox_xchacha20poly1305_easy.
+	var key, secretKey, publicKey [keySize]byte
+	var nonce [nonceSize]byte
+	for i := 0; i < keySize; i++ {
+		key[i], secretKey[i], publicKey[i] = byte(i), byte(100+i), byte(200+i)
+	}
+	for i := range nonce {
+		nonce[i] = byte(50 + i)
+	}
+	plaintext := []byte("hello dnscrypt, this message is longer than thirty two bytes for sure")
+
+	sharedKey, err := computeSharedKey(xchacha20Poly1305, &secretKey, &publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "7b2909fc28056aaa325c535a5107b8468265bb1604a8b64adf95adc0fca32c7e",
+		hex.EncodeToString(sharedKey[:]))
+
+	sealed := seal(xchacha20Poly1305, &key, &nonce, plaintext)
+	assert.Equal(t, "16e4a6c896e8f9b2d9afb1a3f7b08431286cea7ba1f576399c1ae56203e97fc6"+
+		"9d1eed83bdf364cf12cfe1706deb5990671531bb0b936b422b161c4db47e39ce"+
+		"9c215ee15c09517658fb01235408af775a77c4120b",
+		hex.EncodeToString(sealed))
+
+	opened, err := open(xchacha20Poly1305, &key, &nonce, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = open(xchacha20Poly1305, &key, &nonce, sealed)
+	assert.ErrorIs(t, err, ErrDecryption)
+}
+
+func Test_pad(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		message []byte
+		minSize int
+		size    int
+	}{
+		"UDP minimum size": {
+			message: make([]byte, 30),
+			minSize: minQuerySize,
+			size:    256,
+		},
+		"multiple of block size": {
+			message: make([]byte, 64),
+			size:    128,
+		},
+		"padding to block size": {
+			message: []byte{1, 2, 3},
+			size:    64,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			padded := pad(testCase.message, testCase.minSize)
+
+			assert.Len(t, padded, testCase.size)
+			message, err := unpad(padded)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.message, message)
+		})
+	}
+}
+
+func Test_unpad(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		padded  []byte
+		message []byte
+		err     error
+	}{
+		"empty": {
+			err: ErrPadding,
+		},
+		"no padding byte": {
+			padded: []byte{1, 0, 0},
+			err:    ErrPadding,
+		},
+		"empty message": {
+			padded:  []byte{0x80, 0},
+			message: []byte{},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			message, err := unpad(testCase.padded)
+
+			assert.ErrorIs(t, err, testCase.err)
+			assert.Equal(t, testCase.message, message)
+		})
+	}
+}
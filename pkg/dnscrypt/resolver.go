@@ -0,0 +1,16 @@
+package dnscrypt
+
+import (
+	"net"
+
+	"github.com/qdm12/dns/pkg/exchange"
+)
+
+// NewResolver creates a DNSCrypt resolver.
+func NewResolver(settings ResolverSettings) *net.Resolver {
+	return &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: true,
+		Dial:         exchange.NewDial(NewClient(settings)),
+	}
+}
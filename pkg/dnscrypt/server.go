@@ -0,0 +1,75 @@
+package dnscrypt
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/handler"
+	"github.com/qdm12/dns/pkg/localdata"
+	"github.com/qdm12/golibs/logging"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Server
+
+type Server interface {
+	Run(ctx context.Context, stopped chan<- error)
+	// SetBlacklist swaps the blacklist used by the server,
+	// and is safe to call while the server is running.
+	SetBlacklist(settings blacklist.Settings)
+	// SetLocalData swaps the local records answered by the server,
+	// and is safe to call while the server is running.
+	SetLocalData(settings localdata.Settings)
+}
+
+type server struct {
+	dnsServer dns.Server
+	handler   *handler.Handler
+	logger    logging.Logger
+}
+
+func NewServer(ctx context.Context, logger logging.Logger,
+	settings ServerSettings) (Server, error) {
+	settings.setDefaults()
+	client := NewClient(settings.Resolver)
+	dnsHandler, err := handler.New(ctx, logger, client, settings.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &server{
+		dnsServer: dns.Server{
+			Addr:    ":" + strconv.Itoa(int(settings.Port)),
+			Net:     "udp",
+			Handler: dnsHandler,
+		},
+		handler: dnsHandler,
+		logger:  logger,
+	}, nil
+}
+
+func (s *server) Run(ctx context.Context, stopped chan<- error) {
+	go func() { // shutdown goroutine
+		<-ctx.Done()
+
+		const graceTime = 100 * time.Millisecond
+		ctx, cancel := context.WithTimeout(context.Background(), graceTime)
+		defer cancel()
+		if err := s.dnsServer.ShutdownContext(ctx); err != nil {
+			s.logger.Error("DNS server shutdown error: " + err.Error())
+		}
+	}()
+
+	s.logger.Info("DNS server listening on " + s.dnsServer.Addr)
+	stopped <- s.dnsServer.ListenAndServe()
+}
+
+func (s *server) SetBlacklist(settings blacklist.Settings) {
+	s.handler.SetBlacklist(settings)
+}
+
+func (s *server) SetLocalData(settings localdata.Settings) {
+	s.handler.SetLocalData(settings)
+}
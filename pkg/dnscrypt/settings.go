@@ -0,0 +1,91 @@
+package dnscrypt
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qdm12/dns/pkg/handler"
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+type ServerSettings struct {
+	Resolver ResolverSettings
+	Port     uint16
+	// Settings are the DNS handler settings,
+	// see pkg/handler/settings.go
+	handler.Settings
+}
+
+type ResolverSettings struct {
+	// DNSCryptProviders are the providers whose DNSCrypt servers
+	// are used. There is no default since no built-in provider
+	// describes its DNSCrypt server, so providers are usually
+	// parsed from DNS stamps with provider.Parse.
+	DNSCryptProviders []provider.Provider
+	Timeout           time.Duration
+	IPv6              bool
+}
+
+func (s *ServerSettings) setDefaults() {
+	s.Resolver.setDefaults()
+
+	if s.Port == 0 {
+		const defaultPort = 53
+		s.Port = defaultPort
+	}
+
+	s.Settings.SetDefaults()
+}
+
+func (s *ResolverSettings) setDefaults() {
+	if s.Timeout == 0 {
+		const defaultTimeout = 5 * time.Second
+		s.Timeout = defaultTimeout
+	}
+}
+
+const (
+	subSection = " |--"
+	indent     = "    " // used if lines already contain the subSection
+)
+
+func (s *ServerSettings) String() string {
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *ResolverSettings) String() string {
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *ServerSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Resolver:")
+	for _, line := range s.Resolver.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines,
+		subSection+"Listening port: "+strconv.Itoa(int(s.Port)))
+
+	lines = append(lines, s.Settings.Lines(indent, subSection)...)
+
+	return lines
+}
+
+func (s *ResolverSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"DNSCrypt providers:")
+	for _, provider := range s.DNSCryptProviders {
+		lines = append(lines, indent+subSection+provider.String())
+	}
+
+	lines = append(lines,
+		subSection+"Query timeout: "+s.Timeout.String())
+
+	connectOver := "IPv4"
+	if s.IPv6 {
+		connectOver = "IPv6"
+	}
+	lines = append(lines, subSection+"Connecting over: "+connectOver)
+
+	return lines
+}
@@ -0,0 +1,95 @@
+package dnscrypt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qdm12/dns/pkg/accesscontrol"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/dns/pkg/forward"
+	"github.com/qdm12/dns/pkg/handler"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_ServerSettings_setDefaults(t *testing.T) {
+	t.Parallel()
+
+	s := ServerSettings{}
+	s.setDefaults()
+
+	expectedSettings := ServerSettings{
+		Resolver: ResolverSettings{
+			Timeout: 5 * time.Second,
+		},
+		Port: 53,
+		Settings: handler.Settings{
+			Cache: cache.Settings{
+				Type: cache.Disabled,
+			},
+			AccessControl: accesscontrol.Settings{
+				Rules: accesscontrol.DefaultRules(),
+			},
+			Forward: forward.Settings{
+				Timeout: 5 * time.Second,
+			},
+			DNS64: dns64.Settings{
+				Prefix: netaddr.MustParseIPPrefix("64:ff9b::/96"),
+			},
+		},
+	}
+	expectedSettings.DNSSEC.SetDefaults()
+	assert.Equal(t, expectedSettings, s)
+}
+
+func Test_ServerSettings_Lines(t *testing.T) {
+	t.Parallel()
+
+	stampProvider, err := provider.Parse("sdns://AQcAAAAAAAAACTEwLjAuMC41MyAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABsyLmRuc2NyeXB0LWNlcnQuZXhhbXBsZS5jb20")
+	require.NoError(t, err)
+
+	s := ServerSettings{
+		Resolver: ResolverSettings{
+			DNSCryptProviders: []provider.Provider{stampProvider},
+		},
+		Settings: handler.Settings{
+			Blacklist: blacklist.Settings{
+				FqdnHostnames: []string{"abc.com"},
+			},
+		},
+	}
+	s.setDefaults()
+
+	lines := s.Lines(indent, subSection)
+
+	expectedLines := []string{
+		" |--Resolver:",
+		"     |--DNSCrypt providers:",
+		"         |--2.dnscrypt-cert.example.com",
+		"     |--Query timeout: 5s",
+		"     |--Connecting over: IPv4",
+		" |--Listening port: 53",
+		" |--Caching:",
+		"     |--Type: disabled",
+		" |--Blacklist:",
+		"     |--Hostnames blocked: 1",
+		" |--Access control:",
+		"     |--127.0.0.0/8: allow",
+		"     |--10.0.0.0/8: allow",
+		"     |--172.16.0.0/12: allow",
+		"     |--192.168.0.0/16: allow",
+		"     |--::1/128: allow",
+		"     |--fc00::/7: allow",
+		"     |--fe80::/10: allow",
+		"     |--0.0.0.0/0: refuse",
+		"     |--::/0: refuse",
+		" |--Local records: 0",
+		" |--DNS64: disabled",
+		" |--DNSSEC validation: disabled",
+	}
+	assert.Equal(t, expectedLines, lines)
+}
@@ -0,0 +1,52 @@
+package dnssec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/updated/pkg/dnscrypto"
+)
+
+// DownloadRootKeys downloads the root trust anchors published by IANA
+// and returns them as DS records lines in the zone file format, as
+// written to the Unbound root.key file.
+func DownloadRootKeys(ctx context.Context, dnsCrypto dnscrypto.DNSCrypto) (
+	rootKeys []string, err error) {
+	rootAnchorsXML, err := dnsCrypto.DownloadRootAnchorsXML(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dnsCrypto.ConvertRootAnchorsToRootKeys(rootAnchorsXML)
+}
+
+var (
+	ErrTrustAnchorMalformed = errors.New("trust anchor is malformed")
+	ErrTrustAnchorNotRootDS = errors.New("trust anchor is not a DS record for the root zone")
+)
+
+// ParseTrustAnchors parses root keys lines such as returned by
+// DownloadRootKeys, ignoring empty lines and comments.
+func ParseTrustAnchors(rootKeys []string) (trustAnchors []*dns.DS, err error) {
+	for _, line := range rootKeys {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrTrustAnchorMalformed, line, err)
+		}
+
+		ds, ok := rr.(*dns.DS)
+		if !ok || ds.Hdr.Name != "." {
+			return nil, fmt.Errorf("%w: %s", ErrTrustAnchorNotRootDS, line)
+		}
+
+		trustAnchors = append(trustAnchors, ds)
+	}
+	return trustAnchors, nil
+}
@@ -0,0 +1,74 @@
+package dnssec
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// zoneCache is a least recently used cache of zone cuts keyed by
+// name, holding at most maxEntries names.
+type zoneCache struct {
+	maxEntries int
+	kv         map[string]*list.Element
+	linkedList *list.List
+	mutex      sync.Mutex
+}
+
+type zoneCacheEntry struct {
+	name string
+	cut  zoneCut
+}
+
+func newZoneCache(maxEntries int) *zoneCache {
+	return &zoneCache{
+		maxEntries: maxEntries,
+		kv:         make(map[string]*list.Element),
+		linkedList: list.New(),
+	}
+}
+
+// get returns the zone cut cached for the name given,
+// or false if it is not cached or has expired.
+func (c *zoneCache) get(name string, now time.Time) (cut zoneCut, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	listElement, ok := c.kv[name]
+	if !ok {
+		return zoneCut{}, false
+	}
+
+	entryPtr := listElement.Value.(*zoneCacheEntry)
+	if !now.Before(entryPtr.cut.expiry) {
+		c.remove(listElement)
+		return zoneCut{}, false
+	}
+
+	c.linkedList.MoveToFront(listElement)
+	return entryPtr.cut, true
+}
+
+func (c *zoneCache) add(name string, cut zoneCut) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if listElement, ok := c.kv[name]; ok {
+		c.linkedList.MoveToFront(listElement)
+		listElement.Value.(*zoneCacheEntry).cut = cut
+		return
+	}
+
+	listElement := c.linkedList.PushFront(&zoneCacheEntry{name: name, cut: cut})
+	c.kv[name] = listElement
+
+	if c.linkedList.Len() > c.maxEntries {
+		c.remove(c.linkedList.Back())
+	}
+}
+
+// remove removes a list element and is NOT thread safe.
+func (c *zoneCache) remove(listElement *list.Element) {
+	c.linkedList.Remove(listElement)
+	delete(c.kv, listElement.Value.(*zoneCacheEntry).name)
+}
@@ -0,0 +1,34 @@
+package dnssec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_zoneCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	expiry := now.Add(time.Minute)
+
+	cache := newZoneCache(2)
+	cache.add("a.", zoneCut{cut: true, expiry: expiry})
+	cache.add("b.", zoneCut{expiry: expiry})
+
+	_, ok := cache.get("a.", now)
+	assert.True(t, ok)
+
+	cache.add("c.", zoneCut{expiry: expiry}) // evicts b. used least recently
+
+	_, ok = cache.get("b.", now)
+	assert.False(t, ok)
+	cut, ok := cache.get("a.", now)
+	assert.True(t, ok)
+	assert.Equal(t, zoneCut{cut: true, expiry: expiry}, cut)
+
+	_, ok = cache.get("c.", expiry)
+	assert.False(t, ok)
+	assert.Len(t, cache.kv, 1)
+}
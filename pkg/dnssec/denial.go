@@ -0,0 +1,319 @@
+package dnssec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	errDenialMissing     = errors.New("denial of existence is not proven")
+	errDenialBelowCut    = errors.New("closest encloser is a delegation or a DNAME")
+	errWildcardNotDenied = errors.New("wildcard expansion without denial of the name")
+	errWildcardExists    = errors.New("wildcard matching the name exists")
+)
+
+// denial contains the NSEC and NSEC3 records of a response which are
+// verified to be from the zone containing the name to deny.
+type denial struct {
+	nsecs  []*dns.NSEC
+	nsec3s []*dns.NSEC3
+}
+
+// newDenial returns the denial records from the RRsets given, keeping
+// only the RRsets which were verified to be from the zone given.
+func newDenial(sets []*rrset, setZones map[*rrset]string, zone string) (d denial) {
+	for _, set := range sets {
+		if setZones[set] != zone {
+			continue
+		}
+		for _, rr := range set.records {
+			switch record := rr.(type) {
+			case *dns.NSEC:
+				d.nsecs = append(d.nsecs, record)
+			case *dns.NSEC3:
+				// Only SHA-1 is defined for NSEC3, see RFC 5155 section 8.1.
+				if record.Hash == dns.SHA1 {
+					d.nsec3s = append(d.nsec3s, record)
+				}
+			}
+		}
+	}
+	return d
+}
+
+// verify verifies the denial of existence of the name given if the rcode
+// is NXDOMAIN, and of the type for the name otherwise, as defined in
+// RFC 4035 section 5.4 for NSEC and RFC 5155 sections 8.4 to 8.7 for NSEC3.
+// It returns false if the denial relies on an NSEC3 opt-out span,
+// which may contain unsigned delegations and is therefore insecure.
+func (d denial) verify(name string, qtype uint16, rcode int) (secure bool, err error) {
+	switch {
+	case len(d.nsecs) > 0:
+		return true, d.verifyNSEC(name, qtype, rcode)
+	case len(d.nsec3s) > 0:
+		return d.verifyNSEC3(name, qtype, rcode)
+	default:
+		return false, fmt.Errorf("%w: no NSEC or NSEC3 record for %s",
+			errDenialMissing, name)
+	}
+}
+
+// verifyWildcardAnswer verifies the name given does not exist, such that
+// the answer synthesized from the wildcard at the closest encloser given
+// is legitimate. It returns false if the name is in an NSEC3 opt-out span.
+func (d denial) verifyWildcardAnswer(name, closestEncloser string) (
+	secure bool, err error) {
+	if len(d.nsecs) > 0 {
+		covering := d.nsecCovering(name)
+		if covering == nil || nsecClosestEncloser(covering, name) != closestEncloser {
+			return false, fmt.Errorf("%w: %s", errWildcardNotDenied, name)
+		}
+		return true, nil
+	}
+
+	covering := d.nsec3Covering(nextCloser(name, closestEncloser))
+	if covering == nil {
+		return false, fmt.Errorf("%w: %s", errWildcardNotDenied, name)
+	}
+	return !isOptOut(covering), nil
+}
+
+func (d denial) verifyNSEC(name string, qtype uint16, rcode int) (err error) {
+	if rcode == dns.RcodeNameError {
+		covering := d.nsecCovering(name)
+		if covering == nil {
+			return fmt.Errorf("%w: for name %s", errDenialMissing, name)
+		}
+		wildcard := "*." + nsecClosestEncloser(covering, name)
+		if d.nsecMatching(wildcard) != nil {
+			return fmt.Errorf("%w: %s", errWildcardExists, wildcard)
+		} else if d.nsecCovering(wildcard) == nil {
+			return fmt.Errorf("%w: for wildcard %s", errDenialMissing, wildcard)
+		}
+		return nil
+	}
+
+	if matching := d.nsecMatching(name); matching != nil {
+		if typeDenied(matching.TypeBitMap, qtype) {
+			return nil
+		}
+		return fmt.Errorf("%w: for type %s of %s", errDenialMissing,
+			dns.TypeToString[qtype], name)
+	}
+
+	covering := d.nsecCovering(name)
+	if covering == nil {
+		return fmt.Errorf("%w: for name %s", errDenialMissing, name)
+	}
+
+	if dns.IsSubDomain(name, dns.CanonicalName(covering.NextDomain)) {
+		return nil // empty non-terminal
+	}
+
+	wildcard := "*." + nsecClosestEncloser(covering, name)
+	if matching := d.nsecMatching(wildcard); matching != nil &&
+		typeDenied(matching.TypeBitMap, qtype) {
+		return nil
+	}
+	return fmt.Errorf("%w: for type %s of wildcard %s", errDenialMissing,
+		dns.TypeToString[qtype], wildcard)
+}
+
+func (d denial) verifyNSEC3(name string, qtype uint16, rcode int) (
+	secure bool, err error) {
+	if rcode == dns.RcodeNameError {
+		closestEncloser, covering, err := d.nsec3ClosestEncloser(name)
+		if err != nil {
+			return false, err
+		}
+		wildcard := "*." + closestEncloser
+		if d.nsec3Matching(wildcard) != nil {
+			return false, fmt.Errorf("%w: %s", errWildcardExists, wildcard)
+		} else if d.nsec3Covering(wildcard) == nil {
+			return false, fmt.Errorf("%w: for wildcard %s", errDenialMissing, wildcard)
+		}
+		return !isOptOut(covering), nil
+	}
+
+	if matching := d.nsec3Matching(name); matching != nil {
+		if typeDenied(matching.TypeBitMap, qtype) {
+			return true, nil
+		}
+		return false, fmt.Errorf("%w: for type %s of %s", errDenialMissing,
+			dns.TypeToString[qtype], name)
+	}
+
+	closestEncloser, covering, err := d.nsec3ClosestEncloser(name)
+	if err != nil {
+		return false, err
+	}
+
+	if qtype == dns.TypeDS && isOptOut(covering) {
+		return false, nil // unsigned delegation in an opt-out span
+	}
+
+	wildcard := "*." + closestEncloser
+	if matching := d.nsec3Matching(wildcard); matching != nil &&
+		typeDenied(matching.TypeBitMap, qtype) {
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: for type %s of wildcard %s", errDenialMissing,
+		dns.TypeToString[qtype], wildcard)
+}
+
+// nsecMatching returns the NSEC record owned by the name given, or nil.
+func (d denial) nsecMatching(name string) *dns.NSEC {
+	for _, nsec := range d.nsecs {
+		if dns.CanonicalName(nsec.Hdr.Name) == name {
+			return nsec
+		}
+	}
+	return nil
+}
+
+// nsecCovering returns an NSEC record covering the name given, or nil.
+// NSEC records of a delegation or of a DNAME owned by an ancestor of
+// the name are ignored, since the name is not in their zone.
+func (d denial) nsecCovering(name string) *dns.NSEC {
+	for _, nsec := range d.nsecs {
+		owner := dns.CanonicalName(nsec.Hdr.Name)
+		if dns.IsSubDomain(owner, name) && isCut(nsec.TypeBitMap) {
+			continue
+		}
+		if nsecCovers(nsec, name) {
+			return nsec
+		}
+	}
+	return nil
+}
+
+// nsec3Matching returns the NSEC3 record matching the name given, or nil.
+func (d denial) nsec3Matching(name string) *dns.NSEC3 {
+	for _, nsec3 := range d.nsec3s {
+		if nsec3.Match(name) {
+			return nsec3
+		}
+	}
+	return nil
+}
+
+// nsec3Covering returns an NSEC3 record covering the name given, or nil.
+func (d denial) nsec3Covering(name string) *dns.NSEC3 {
+	for _, nsec3 := range d.nsec3s {
+		if nsec3.Cover(name) {
+			return nsec3
+		}
+	}
+	return nil
+}
+
+// nsec3ClosestEncloser verifies the closest encloser proof defined in
+// RFC 5155 section 8.3 for the name given, and returns the closest
+// encloser and the NSEC3 record covering the next closer name.
+func (d denial) nsec3ClosestEncloser(name string) (closestEncloser string,
+	covering *dns.NSEC3, err error) {
+	labels := dns.Split(name)
+	for i := 1; i <= len(labels); i++ {
+		closestEncloser = "."
+		if i < len(labels) {
+			closestEncloser = name[labels[i]:]
+		}
+		matching := d.nsec3Matching(closestEncloser)
+		if matching == nil {
+			continue
+		} else if isCut(matching.TypeBitMap) {
+			return "", nil, fmt.Errorf("%w: %s", errDenialBelowCut, closestEncloser)
+		}
+
+		next := name[labels[i-1]:]
+		covering = d.nsec3Covering(next)
+		if covering == nil {
+			return "", nil, fmt.Errorf("%w: for next closer name %s",
+				errDenialMissing, next)
+		}
+		return closestEncloser, covering, nil
+	}
+	return "", nil, fmt.Errorf("%w: for closest encloser of %s", errDenialMissing, name)
+}
+
+// nsecClosestEncloser returns the closest encloser of the name given
+// which is covered by the NSEC record given, that is the longest
+// common ancestor of the name with the owner or next domain names.
+func nsecClosestEncloser(nsec *dns.NSEC, name string) string {
+	common := dns.CompareDomainName(dns.CanonicalName(nsec.Hdr.Name), name)
+	if n := dns.CompareDomainName(dns.CanonicalName(nsec.NextDomain), name); n > common {
+		common = n
+	}
+	labels := dns.Split(name)
+	if common == 0 || common > len(labels) {
+		return "."
+	}
+	return name[labels[len(labels)-common]:]
+}
+
+// nextCloser returns the ancestor of the name given which is one
+// label longer than the closest encloser given.
+func nextCloser(name, closestEncloser string) string {
+	labels := dns.Split(name)
+	index := len(labels) - dns.CountLabel(closestEncloser) - 1
+	if index < 0 {
+		return name
+	}
+	return name[labels[index]:]
+}
+
+// typeDenied returns true if the type bit map of the NSEC or NSEC3
+// record owned by a name proves the name has no record of the type given.
+func typeDenied(typeBitMap []uint16, qtype uint16) bool {
+	return !hasType(typeBitMap, qtype) && !hasType(typeBitMap, dns.TypeCNAME)
+}
+
+// isCut returns true if the type bit map of the NSEC or NSEC3 record
+// owned by a name shows names below it are not in the zone, because it
+// is a delegation point or because it has a DNAME record.
+func isCut(typeBitMap []uint16) bool {
+	return hasType(typeBitMap, dns.TypeDNAME) ||
+		(hasType(typeBitMap, dns.TypeNS) && !hasType(typeBitMap, dns.TypeSOA))
+}
+
+func isOptOut(nsec3 *dns.NSEC3) bool {
+	const optOutFlag = 1
+	return nsec3.Flags&optOutFlag != 0
+}
+
+func hasType(typeBitMap []uint16, rrtype uint16) bool {
+	for _, t := range typeBitMap {
+		if t == rrtype {
+			return true
+		}
+	}
+	return false
+}
+
+// nsecCovers returns true if the name given is strictly between the
+// owner name and the next domain name of the NSEC record, in the
+// canonical order defined in RFC 4034 section 6.1.
+func nsecCovers(nsec *dns.NSEC, name string) bool {
+	owner, next := nsec.Hdr.Name, nsec.NextDomain
+	afterOwner := canonicalCompare(owner, name) < 0
+	beforeNext := canonicalCompare(name, next) < 0
+	if canonicalCompare(owner, next) < 0 {
+		return afterOwner && beforeNext
+	}
+	// last NSEC record of the zone
+	return afterOwner || beforeNext
+}
+
+func canonicalCompare(a, b string) int {
+	aLabels := dns.SplitDomainName(dns.CanonicalName(a))
+	bLabels := dns.SplitDomainName(dns.CanonicalName(b))
+	for i, j := len(aLabels)-1, len(bLabels)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(aLabels[i], bLabels[j]); c != 0 {
+			return c
+		}
+	}
+	return len(aLabels) - len(bLabels)
+}
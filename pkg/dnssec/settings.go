@@ -0,0 +1,66 @@
+package dnssec
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+type Settings struct {
+	Enabled bool
+	// TrustAnchors are the DS records of the root zone keys,
+	// and default to the DS records of the root KSK-2017 and
+	// KSK-2024 keys. They can be obtained with DownloadRootKeys
+	// and ParseTrustAnchors.
+	TrustAnchors []*dns.DS
+}
+
+func (s *Settings) SetDefaults() {
+	if len(s.TrustAnchors) == 0 {
+		s.TrustAnchors = []*dns.DS{
+			newRootDS(20326, "E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"), //nolint:gomnd
+			newRootDS(38696, "683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16"), //nolint:gomnd
+		}
+	}
+}
+
+func newRootDS(keyTag uint16, sha256Digest string) *dns.DS {
+	const rootTTL = 172800
+	return &dns.DS{
+		Hdr: dns.RR_Header{
+			Name:   ".",
+			Rrtype: dns.TypeDS,
+			Class:  dns.ClassINET,
+			Ttl:    rootTTL,
+		},
+		KeyTag:     keyTag,
+		Algorithm:  dns.RSASHA256,
+		DigestType: dns.SHA256,
+		Digest:     sha256Digest,
+	}
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled {
+		return []string{subSection + "DNSSEC validation: disabled"}
+	}
+
+	lines = append(lines, subSection+"DNSSEC validation: enabled")
+	keyTags := make([]string, len(s.TrustAnchors))
+	for i, trustAnchor := range s.TrustAnchors {
+		keyTags[i] = strconv.Itoa(int(trustAnchor.KeyTag))
+	}
+	lines = append(lines, indent+subSection+"Trust anchors key tags: "+
+		strings.Join(keyTags, ", "))
+
+	return lines
+}
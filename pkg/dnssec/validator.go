@@ -0,0 +1,132 @@
+// Package dnssec validates DNSSEC signed responses from the root trust
+// anchors down, without trusting the AD bit set by upstream servers.
+package dnssec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Exchange exchanges a request with an upstream server.
+type Exchange func(ctx context.Context, request *dns.Msg) (response *dns.Msg, err error)
+
+var ErrBogus = errors.New("DNSSEC validation failed")
+
+// Validator validates responses using DNSKEY and DS records
+// obtained with its exchange function and cached in memory.
+type Validator struct {
+	enabled  bool
+	anchors  []*dns.DS
+	exchange Exchange
+	timeNow  func() time.Time
+	zones    *zoneCache
+}
+
+func NewValidator(settings Settings, exchange Exchange) *Validator {
+	settings.SetDefaults()
+	const maxCachedNames = 10000
+	return &Validator{
+		enabled:  settings.Enabled,
+		anchors:  settings.TrustAnchors,
+		exchange: exchange,
+		timeNow:  time.Now,
+		zones:    newZoneCache(maxCachedNames),
+	}
+}
+
+// Prepare returns the request to send upstream. If validation is enabled,
+// this is a copy of the request with the DO bit set to obtain the DNSSEC
+// records, and with the CD bit set so the upstream server returns the
+// records even if it considers them bogus, since they are validated here.
+func (v *Validator) Prepare(request *dns.Msg) *dns.Msg {
+	if !v.enabled {
+		return request
+	}
+	request = request.Copy()
+	request.CheckingDisabled = true
+	if opt := request.IsEdns0(); opt != nil {
+		opt.SetDo()
+	} else {
+		const udpSize = 4096
+		request.SetEdns0(udpSize, true)
+	}
+	return request
+}
+
+// Validate validates the response to the request returned by Prepare,
+// and sets its AD bit only if it is secure. It returns an error wrapping
+// ErrBogus if the response fails validation.
+func (v *Validator) Validate(ctx context.Context, request, response *dns.Msg) (err error) {
+	if !v.enabled {
+		return nil
+	}
+
+	response.AuthenticatedData = false
+
+	if len(request.Question) != 1 ||
+		(response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError) {
+		return nil
+	}
+
+	secure, err := v.validate(ctx, request.Question[0], response)
+	if err != nil {
+		return fmt.Errorf("%w: for %s: %s", ErrBogus, request.Question[0].Name, err)
+	}
+	response.AuthenticatedData = secure
+	return nil
+}
+
+// AdaptResponse removes the DNSSEC records from the response if the client
+// request did not set the DO bit, and clears the AD bit if the client
+// request set neither the DO bit nor the AD bit, as defined in RFC 6840.
+func (v *Validator) AdaptResponse(request, response *dns.Msg) {
+	if !v.enabled {
+		return
+	}
+
+	clientOpt := request.IsEdns0()
+	if clientOpt != nil && clientOpt.Do() {
+		return
+	}
+
+	if !request.AuthenticatedData {
+		response.AuthenticatedData = false
+	}
+
+	var qtype uint16
+	if len(request.Question) == 1 {
+		qtype = request.Question[0].Qtype
+	}
+	response.Answer = removeDNSSECRecords(response.Answer, qtype)
+	response.Ns = removeDNSSECRecords(response.Ns, qtype)
+
+	extra := response.Extra[:0]
+	for _, rr := range response.Extra {
+		if opt, ok := rr.(*dns.OPT); ok {
+			if clientOpt == nil {
+				continue
+			}
+			opt.SetDo(false)
+		}
+		extra = append(extra, rr)
+	}
+	response.Extra = extra
+}
+
+func removeDNSSECRecords(rrs []dns.RR, qtype uint16) (filtered []dns.RR) {
+	filtered = rrs[:0]
+	for _, rr := range rrs {
+		switch rrtype := rr.Header().Rrtype; rrtype {
+		case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3:
+			if rrtype != qtype {
+				continue
+			}
+		}
+		filtered = append(filtered, rr)
+	}
+	return filtered
+}
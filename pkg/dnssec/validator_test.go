@@ -0,0 +1,616 @@
+package dnssec
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testZone struct {
+	key        *dns.DNSKEY
+	privateKey crypto.Signer
+}
+
+func newTestZone(t *testing.T, name string) testZone {
+	t.Helper()
+	key := &dns.DNSKEY{
+		Hdr: dns.RR_Header{
+			Name: name, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600,
+		},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	privateKey, err := key.Generate(256)
+	require.NoError(t, err)
+	return testZone{key: key, privateKey: privateKey.(crypto.Signer)}
+}
+
+func (z testZone) sign(t *testing.T, rrs ...dns.RR) (signed []dns.RR) {
+	t.Helper()
+	signature := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Class: dns.ClassINET, Ttl: 3600},
+		Algorithm:  z.key.Algorithm,
+		KeyTag:     z.key.KeyTag(),
+		SignerName: z.key.Hdr.Name,
+		Inception:  uint32(time.Now().Add(-time.Hour).Unix()),
+		Expiration: uint32(time.Now().Add(time.Hour).Unix()),
+	}
+	err := signature.Sign(z.privateKey, rrs)
+	require.NoError(t, err)
+	return append(rrs, signature)
+}
+
+// signEach signs each of the records given as its own RRset.
+func (z testZone) signEach(t *testing.T, rrs ...dns.RR) (signed []dns.RR) {
+	t.Helper()
+	for _, rr := range rrs {
+		signed = append(signed, z.sign(t, rr)...)
+	}
+	return signed
+}
+
+func (z testZone) ds() *dns.DS {
+	return z.key.ToDS(dns.SHA256)
+}
+
+type questionKey struct {
+	name  string
+	qtype uint16
+}
+
+// testHierarchy is a signed root zone delegating to the signed zone
+// secure. and to the unsigned zone insecure. The zone secure. itself
+// delegates to the unsigned zone delegated.secure., to the signed zone
+// child.secure. using NSEC records and to the signed zone nsec3.secure.
+// using NSEC3 records, and has the wildcard *.wild.secure.
+type testHierarchy struct {
+	root      testZone
+	secure    testZone
+	child     testZone
+	nsec3     testZone
+	responses map[questionKey]*dns.Msg
+}
+
+func newTestHierarchy(t *testing.T) *testHierarchy {
+	t.Helper()
+	h := &testHierarchy{
+		root:      newTestZone(t, "."),
+		secure:    newTestZone(t, "secure."),
+		child:     newTestZone(t, "child.secure."),
+		nsec3:     newTestZone(t, "nsec3.secure."),
+		responses: make(map[questionKey]*dns.Msg),
+	}
+
+	h.set(".", dns.TypeDNSKEY, h.root.sign(t, h.root.key), nil)
+	h.set("secure.", dns.TypeDS, h.root.sign(t, h.secure.ds()), nil)
+	h.set("secure.", dns.TypeDNSKEY, h.secure.sign(t, h.secure.key), nil)
+	h.set("child.secure.", dns.TypeDS, h.secure.sign(t, h.child.ds()), nil)
+	h.set("child.secure.", dns.TypeDNSKEY, h.child.sign(t, h.child.key), nil)
+	h.set("nsec3.secure.", dns.TypeDS, h.secure.sign(t, h.nsec3.ds()), nil)
+	h.set("nsec3.secure.", dns.TypeDNSKEY, h.nsec3.sign(t, h.nsec3.key), nil)
+
+	authority := h.root.sign(t, newSOA("."))
+	authority = append(authority, h.root.sign(t, newNSEC("insecure.", "secure.",
+		dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC))...)
+	h.set("insecure.", dns.TypeDS, nil, authority)
+
+	h.setSecureDSDenial(t, "www.secure.", "zzz.secure.", dns.TypeA)
+	h.setSecureDSDenial(t, "delegated.secure.", "www.secure.", dns.TypeNS)
+	h.setSecureDSDenial(t, "apex.secure.", "delegated.secure.", dns.TypeNS, dns.TypeSOA)
+	h.setSecureDSDenial(t, "wild.secure.", "www.secure.", dns.TypeTXT)
+	h.setNameError("abc.secure.", dns.TypeDS, h.secure.signEach(t, newSOA("secure."),
+		newNSEC("secure.", "apex.secure.", dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG,
+			dns.TypeNSEC, dns.TypeDNSKEY)))
+	h.setNameError("xyz.secure.", dns.TypeDS, h.secure.signEach(t, newSOA("secure."),
+		newNSEC("secure.", "apex.secure.", dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG,
+			dns.TypeNSEC, dns.TypeDNSKEY),
+		newNSEC("www.secure.", "zzz.secure.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC)))
+	h.set("x.wild.secure.", dns.TypeDS, nil, h.secure.sign(t, newWildcardNSEC()))
+
+	childDenial := h.child.signEach(t, newSOA("child.secure."), newNSEC("child.secure.",
+		"www.child.secure.", dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY))
+	h.setNameError("abc.child.secure.", dns.TypeDS, childDenial)
+	h.setNameError("mail.child.secure.", dns.TypeDS, childDenial)
+
+	h.setNameError("x.nsec3.secure.", dns.TypeDS, h.nsec3.signEach(t,
+		newSOA("nsec3.secure."), newNSEC3Apex(),
+		newNSEC3Covering("x.nsec3.secure.", true),
+		newNSEC3Covering("*.nsec3.secure.", false)))
+	h.set("y.nsec3.secure.", dns.TypeDS, nil, h.nsec3.signEach(t,
+		newSOA("nsec3.secure."), newNSEC3Apex(),
+		newNSEC3Covering("y.nsec3.secure.", true)))
+	h.set("z.nsec3.secure.", dns.TypeDS, nil, h.nsec3.signEach(t,
+		newSOA("nsec3.secure."), newNSEC3Apex(),
+		newNSEC3Covering("z.nsec3.secure.", false)))
+
+	return h
+}
+
+// newWildcardNSEC returns the NSEC record of the wildcard *.wild.secure.
+// which only has TXT records, and covers the name x.wild.secure.
+func newWildcardNSEC() *dns.NSEC {
+	return newNSEC("*.wild.secure.", "www.secure.", dns.TypeTXT, dns.TypeRRSIG, dns.TypeNSEC)
+}
+
+// setSecureDSDenial sets the response to the DS query for the name given
+// as a denial from the zone secure. with the types given in its bitmap.
+func (h *testHierarchy) setSecureDSDenial(t *testing.T, name, next string,
+	types ...uint16) {
+	t.Helper()
+	types = append(types, dns.TypeRRSIG, dns.TypeNSEC)
+	authority := h.secure.sign(t, newSOA("secure."))
+	authority = append(authority, h.secure.sign(t, newNSEC(name, next, types...))...)
+	h.set(name, dns.TypeDS, nil, authority)
+}
+
+func newNSEC(name, next string, types ...uint16) *dns.NSEC {
+	return &dns.NSEC{
+		Hdr: dns.RR_Header{
+			Name: name, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 300,
+		},
+		NextDomain: next,
+		TypeBitMap: types,
+	}
+}
+
+const (
+	nsec3Iterations = 1
+	nsec3Salt       = "AB"
+)
+
+func newNSEC3(ownerHash, nextHash string, optOut bool, types ...uint16) *dns.NSEC3 {
+	var flags uint8
+	if optOut {
+		flags = 1
+	}
+	return &dns.NSEC3{
+		Hdr: dns.RR_Header{
+			Name:   strings.ToLower(ownerHash) + ".nsec3.secure.",
+			Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: 300,
+		},
+		Hash:       dns.SHA1,
+		Flags:      flags,
+		Iterations: nsec3Iterations,
+		SaltLength: uint8(len(nsec3Salt) / 2), //nolint:gomnd
+		Salt:       nsec3Salt,
+		HashLength: 20, //nolint:gomnd
+		NextDomain: nextHash,
+		TypeBitMap: types,
+	}
+}
+
+// newNSEC3Apex returns the NSEC3 record of the apex of the zone
+// nsec3.secure., which covers no name.
+func newNSEC3Apex() *dns.NSEC3 {
+	hash := hashName("nsec3.secure.")
+	return newNSEC3(hash, shiftHash(hash, 1), false, dns.TypeNS,
+		dns.TypeSOA, dns.TypeRRSIG, dns.TypeDNSKEY, dns.TypeNSEC3PARAM)
+}
+
+// newNSEC3Covering returns an NSEC3 record of the zone nsec3.secure.
+// covering only the hash of the name given.
+func newNSEC3Covering(name string, optOut bool) *dns.NSEC3 {
+	hash := hashName(name)
+	return newNSEC3(shiftHash(hash, -1), shiftHash(hash, 1), optOut,
+		dns.TypeA, dns.TypeRRSIG)
+}
+
+func hashName(name string) string {
+	return dns.HashName(name, dns.SHA1, nsec3Iterations, nsec3Salt)
+}
+
+// shiftHash adds the delta given, 1 or -1, to the base32hex hash given.
+func shiftHash(hash string, delta int) string {
+	const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUV"
+	b := []byte(hash)
+	for i := len(b) - 1; i >= 0; i-- {
+		index := strings.IndexByte(alphabet, b[i]) + delta
+		switch {
+		case index < 0:
+			b[i] = alphabet[len(alphabet)-1]
+		case index >= len(alphabet):
+			b[i] = alphabet[0]
+		default:
+			b[i] = alphabet[index]
+			return string(b)
+		}
+	}
+	return string(b)
+}
+
+func newSOA(zone string) *dns.SOA {
+	return &dns.SOA{
+		Hdr: dns.RR_Header{
+			Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600,
+		},
+		Ns: "ns.example.", Mbox: "admin.example.",
+		Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minttl: 300,
+	}
+}
+
+func newA(name, ip string) *dns.A {
+	return &dns.A{
+		Hdr: dns.RR_Header{
+			Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300,
+		},
+		A: net.ParseIP(ip),
+	}
+}
+
+func (h *testHierarchy) set(name string, qtype uint16, answer, authority []dns.RR) {
+	response := new(dns.Msg)
+	response.Answer = answer
+	response.Ns = authority
+	h.responses[questionKey{name: name, qtype: qtype}] = response
+}
+
+func (h *testHierarchy) setNameError(name string, qtype uint16, authority []dns.RR) {
+	h.set(name, qtype, nil, authority)
+	h.responses[questionKey{name: name, qtype: qtype}].Rcode = dns.RcodeNameError
+}
+
+var errNoTestResponse = errors.New("no test response")
+
+func (h *testHierarchy) exchange(_ context.Context, request *dns.Msg) (*dns.Msg, error) {
+	question := request.Question[0]
+	response, ok := h.responses[questionKey{name: question.Name, qtype: question.Qtype}]
+	if !ok {
+		return nil, errNoTestResponse
+	}
+	response = response.Copy()
+	rcode := response.Rcode
+	response.SetReply(request)
+	response.Rcode = rcode
+	return response, nil
+}
+
+func Test_Validator_Validate(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHierarchy(t)
+
+	tamperedAnswer := h.secure.sign(t, newA("www.secure.", "1.2.3.4"))
+	tamperedAnswer[0] = newA("www.secure.", "6.6.6.6")
+
+	nxdomainDenial := newNSEC("secure.", "apex.secure.",
+		dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY)
+	nxdomainAuthority := h.secure.sign(t, newSOA("secure."))
+	nxdomainAuthority = append(nxdomainAuthority, h.secure.sign(t, nxdomainDenial)...)
+
+	// The forged zone www.secure. signs its own records, and is returned
+	// with the genuine denial of DS records for www.secure. which is not
+	// a delegation in the zone secure.
+	forged := newTestZone(t, "www.secure.")
+	h.set("www.secure.", dns.TypeDNSKEY, forged.sign(t, forged.key), nil)
+	forgedAuthority := h.responses[questionKey{name: "www.secure.", qtype: dns.TypeDS}].Ns
+
+	// The NSEC record of the delegation to child.secure. from the zone
+	// secure. covers the names of the zone child.secure., but cannot
+	// deny their existence.
+	delegationDenial := h.secure.signEach(t, newSOA("secure."), newNSEC("child.secure.",
+		"delegated.secure.", dns.TypeNS, dns.TypeDS, dns.TypeRRSIG, dns.TypeNSEC))
+	childDenial := h.responses[questionKey{name: "mail.child.secure.", qtype: dns.TypeDS}].Ns
+	childNameOnlyDenial := h.child.signEach(t, newSOA("child.secure."), newNSEC(
+		"abc.child.secure.", "www.child.secure.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))
+
+	wildcardAnswer := h.secure.sign(t, newA("*.wild.secure.", "1.2.3.4"))
+	for _, rr := range wildcardAnswer {
+		rr.Header().Name = "x.wild.secure."
+	}
+	wildcardDenial := h.secure.signEach(t, newSOA("secure."), newWildcardNSEC())
+	wildcardOnlyDenial := h.secure.signEach(t, newSOA("secure."), newNSEC(
+		"*.wild.secure.", "a.wild.secure.", dns.TypeTXT, dns.TypeRRSIG, dns.TypeNSEC))
+
+	newNSEC3Denial := func(optOut, wildcardDenied bool) []dns.RR {
+		records := []dns.RR{newSOA("nsec3.secure."), newNSEC3Apex(),
+			newNSEC3Covering("x.nsec3.secure.", optOut)}
+		if wildcardDenied {
+			records = append(records, newNSEC3Covering("*.nsec3.secure.", false))
+		}
+		return h.nsec3.signEach(t, records...)
+	}
+
+	testCases := map[string]struct {
+		name      string
+		rcode     int
+		answer    []dns.RR
+		authority []dns.RR
+		secure    bool
+		err       error
+	}{
+		"secure answer": {
+			name:   "www.secure.",
+			answer: h.secure.sign(t, newA("www.secure.", "1.2.3.4")),
+			secure: true,
+		},
+		"tampered answer": {
+			name:   "www.secure.",
+			answer: tamperedAnswer,
+			err:    ErrBogus,
+		},
+		"unsigned answer in secure zone": {
+			name:   "www.secure.",
+			answer: []dns.RR{newA("www.secure.", "1.2.3.4")},
+			err:    ErrBogus,
+		},
+		"answer signed by other zone": {
+			name:   "www.insecure.",
+			answer: h.secure.sign(t, newA("www.insecure.", "1.2.3.4")),
+			err:    ErrBogus,
+		},
+		"answer signed by forged zone": {
+			name:      "www.secure.",
+			answer:    forged.sign(t, newA("www.secure.", "6.6.6.6")),
+			authority: forgedAuthority,
+			err:       ErrBogus,
+		},
+		"answer in unsigned delegation": {
+			name:   "www.delegated.secure.",
+			answer: []dns.RR{newA("www.delegated.secure.", "1.2.3.4")},
+		},
+		"answer with denial from child zone apex": {
+			name:   "www.apex.secure.",
+			answer: []dns.RR{newA("www.apex.secure.", "1.2.3.4")},
+			err:    ErrBogus,
+		},
+		"insecure answer": {
+			name:   "www.insecure.",
+			answer: []dns.RR{newA("www.insecure.", "1.2.3.4")},
+		},
+		"secure NXDOMAIN": {
+			name:      "abc.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: nxdomainAuthority,
+			secure:    true,
+		},
+		"NXDOMAIN not covered": {
+			name:      "xyz.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: nxdomainAuthority,
+			err:       ErrBogus,
+		},
+		"unsigned NXDOMAIN in secure zone": {
+			name:      "abc.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: []dns.RR{newSOA("secure.")},
+			err:       ErrBogus,
+		},
+		"insecure NXDOMAIN": {
+			name:      "abc.insecure.",
+			rcode:     dns.RcodeNameError,
+			authority: []dns.RR{newSOA("insecure.")},
+		},
+		"NXDOMAIN with delegation NSEC of parent zone": {
+			name:      "abc.child.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: delegationDenial,
+			err:       ErrBogus,
+		},
+		"secure NXDOMAIN in child zone": {
+			name:      "mail.child.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: childDenial,
+			secure:    true,
+		},
+		"NXDOMAIN without wildcard denial": {
+			name:      "mail.child.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: childNameOnlyDenial,
+			err:       ErrBogus,
+		},
+		"secure wildcard answer": {
+			name:      "x.wild.secure.",
+			answer:    wildcardAnswer,
+			authority: wildcardDenial,
+			secure:    true,
+		},
+		"wildcard answer without denial": {
+			name:   "x.wild.secure.",
+			answer: wildcardAnswer,
+			err:    ErrBogus,
+		},
+		"secure wildcard NODATA": {
+			name:      "x.wild.secure.",
+			authority: wildcardDenial,
+			secure:    true,
+		},
+		"wildcard NODATA without denial of the name": {
+			name:      "x.wild.secure.",
+			authority: wildcardOnlyDenial,
+			err:       ErrBogus,
+		},
+		"secure NSEC3 NXDOMAIN": {
+			name:      "x.nsec3.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: newNSEC3Denial(false, true),
+			secure:    true,
+		},
+		"NSEC3 NXDOMAIN in opt-out span": {
+			name:      "x.nsec3.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: newNSEC3Denial(true, true),
+		},
+		"NSEC3 NXDOMAIN without wildcard denial": {
+			name:      "x.nsec3.secure.",
+			rcode:     dns.RcodeNameError,
+			authority: newNSEC3Denial(false, false),
+			err:       ErrBogus,
+		},
+		"answer in NSEC3 opt-out span": {
+			name:   "y.nsec3.secure.",
+			answer: []dns.RR{newA("y.nsec3.secure.", "1.2.3.4")},
+		},
+		"answer in NSEC3 span without opt-out": {
+			name:   "z.nsec3.secure.",
+			answer: []dns.RR{newA("z.nsec3.secure.", "1.2.3.4")},
+			err:    ErrBogus,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			validator := NewValidator(Settings{
+				Enabled:      true,
+				TrustAnchors: []*dns.DS{h.root.ds()},
+			}, h.exchange)
+
+			request := validator.Prepare(new(dns.Msg).SetQuestion(testCase.name, dns.TypeA))
+			response := new(dns.Msg).SetRcode(request, testCase.rcode)
+			response.AuthenticatedData = true
+			response.Answer = testCase.answer
+			response.Ns = testCase.authority
+
+			err := validator.Validate(context.Background(), request, response)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.secure, response.AuthenticatedData)
+		})
+	}
+}
+
+func Test_Validator_Validate_wrongTrustAnchor(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHierarchy(t)
+	otherRoot := newTestZone(t, ".")
+	validator := NewValidator(Settings{
+		Enabled:      true,
+		TrustAnchors: []*dns.DS{otherRoot.ds()},
+	}, h.exchange)
+
+	request := validator.Prepare(new(dns.Msg).SetQuestion("www.secure.", dns.TypeA))
+	response := new(dns.Msg).SetReply(request)
+	response.Answer = h.secure.sign(t, newA("www.secure.", "1.2.3.4"))
+
+	err := validator.Validate(context.Background(), request, response)
+
+	assert.ErrorIs(t, err, ErrBogus)
+}
+
+func Test_Validator_Prepare(t *testing.T) {
+	t.Parallel()
+
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+
+	disabled := NewValidator(Settings{}, nil)
+	assert.Same(t, request, disabled.Prepare(request))
+
+	enabled := NewValidator(Settings{Enabled: true}, nil)
+	prepared := enabled.Prepare(request)
+	assert.True(t, prepared.CheckingDisabled)
+	opt := prepared.IsEdns0()
+	require.NotNil(t, opt)
+	assert.True(t, opt.Do())
+	assert.Nil(t, request.IsEdns0())
+}
+
+func Test_Validator_AdaptResponse(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHierarchy(t)
+
+	newResponse := func() *dns.Msg {
+		response := new(dns.Msg)
+		response.AuthenticatedData = true
+		response.Answer = h.secure.sign(t, newA("www.secure.", "1.2.3.4"))
+		response.SetEdns0(4096, true)
+		return response
+	}
+
+	validator := NewValidator(Settings{Enabled: true}, nil)
+
+	t.Run("client without EDNS", func(t *testing.T) {
+		t.Parallel()
+		request := new(dns.Msg).SetQuestion("www.secure.", dns.TypeA)
+		response := newResponse()
+		validator.AdaptResponse(request, response)
+		assert.False(t, response.AuthenticatedData)
+		assert.Len(t, response.Answer, 1)
+		assert.Empty(t, response.Extra)
+	})
+
+	t.Run("client with AD bit", func(t *testing.T) {
+		t.Parallel()
+		request := new(dns.Msg).SetQuestion("www.secure.", dns.TypeA)
+		request.AuthenticatedData = true
+		request.SetEdns0(4096, false)
+		response := newResponse()
+		validator.AdaptResponse(request, response)
+		assert.True(t, response.AuthenticatedData)
+		assert.Len(t, response.Answer, 1)
+		require.Len(t, response.Extra, 1)
+		assert.False(t, response.IsEdns0().Do())
+	})
+
+	t.Run("client with DO bit", func(t *testing.T) {
+		t.Parallel()
+		request := new(dns.Msg).SetQuestion("www.secure.", dns.TypeA)
+		request.SetEdns0(4096, true)
+		response := newResponse()
+		validator.AdaptResponse(request, response)
+		assert.True(t, response.AuthenticatedData)
+		assert.Len(t, response.Answer, 2)
+	})
+}
+
+func Test_ParseTrustAnchors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		rootKeys     []string
+		trustAnchors int
+		err          error
+	}{
+		"empty": {},
+		"root key": {
+			rootKeys: []string{
+				"; comment",
+				". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+				"",
+			},
+			trustAnchors: 1,
+		},
+		"malformed": {
+			rootKeys: []string{". IN DS 20326"},
+			err:      ErrTrustAnchorMalformed,
+		},
+		"not root": {
+			rootKeys: []string{
+				"com. IN DS 19718 13 2 8ACBB0CD28F41250A80A491389424D341522D946B0DA0C0291F2D3D771D7805A",
+			},
+			err: ErrTrustAnchorNotRootDS,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			trustAnchors, err := ParseTrustAnchors(testCase.rootKeys)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, trustAnchors, testCase.trustAnchors)
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package dnssec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	errSignatureMissing = errors.New("signature is missing")
+	errSignatureExpired = errors.New("signature is outside its validity period")
+	errSignatureInvalid = errors.New("no key verifies the signature")
+	errSignerInvalid    = errors.New("signer is not the zone of the records")
+	errUnsignedRecords  = errors.New("unsigned records in signed zone")
+)
+
+type rrsetKey struct {
+	name   string
+	rrtype uint16
+}
+
+// rrset is a set of records with the same owner name and type,
+// together with the signatures covering it.
+type rrset struct {
+	key        rrsetKey
+	records    []dns.RR
+	signatures []*dns.RRSIG
+}
+
+// groupRRsets groups the records given in RRsets, keeping the order in
+// which the RRsets first appear.
+func groupRRsets(rrs []dns.RR) (rrsets []*rrset) {
+	keyToRRset := make(map[rrsetKey]*rrset, len(rrs))
+	for _, rr := range rrs {
+		key := rrsetKey{
+			name:   dns.CanonicalName(rr.Header().Name),
+			rrtype: rr.Header().Rrtype,
+		}
+		signature, isSignature := rr.(*dns.RRSIG)
+		if isSignature {
+			key.rrtype = signature.TypeCovered
+		}
+
+		set, ok := keyToRRset[key]
+		if !ok {
+			set = &rrset{key: key}
+			keyToRRset[key] = set
+			rrsets = append(rrsets, set)
+		}
+
+		if isSignature {
+			set.signatures = append(set.signatures, signature)
+		} else {
+			set.records = append(set.records, rr)
+		}
+	}
+	return rrsets
+}
+
+func findRRset(rrsets []*rrset, name string, rrtype uint16) *rrset {
+	key := rrsetKey{name: dns.CanonicalName(name), rrtype: rrtype}
+	for _, set := range rrsets {
+		if set.key == key && len(set.records) > 0 {
+			return set
+		}
+	}
+	return nil
+}
+
+func hasSignatures(rrsets []*rrset) bool {
+	for _, set := range rrsets {
+		if len(set.signatures) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func minTTL(rrs []dns.RR) (ttl uint32) {
+	for i, rr := range rrs {
+		if i == 0 || rr.Header().Ttl < ttl {
+			ttl = rr.Header().Ttl
+		}
+	}
+	return ttl
+}
+
+// validate validates the RRsets of the response and the denial of existence
+// of the name if the response has no answer for the question.
+func (v *Validator) validate(ctx context.Context, question dns.Question,
+	response *dns.Msg) (secure bool, err error) {
+	answer := groupRRsets(response.Answer)
+	authority := groupRRsets(response.Ns)
+
+	// setZones maps each verified RRset to the zone containing it,
+	// such that only denial records from the right zone are used.
+	setZones := make(map[*rrset]string, len(answer)+len(authority))
+
+	secure = true
+	for _, set := range authority {
+		if set.key.rrtype == dns.TypeNS && len(set.signatures) == 0 {
+			continue // delegation records are not signed
+		}
+		zone, setSecure, err := v.verifyRRset(ctx, set)
+		if err != nil {
+			return false, err
+		}
+		setZones[set] = zone
+		secure = secure && setSecure
+	}
+
+	hasDNAME := false
+	for _, set := range answer {
+		if set.key.rrtype == dns.TypeDNAME {
+			hasDNAME = true
+		}
+	}
+
+	for _, set := range answer {
+		if set.key.rrtype == dns.TypeCNAME && len(set.signatures) == 0 && hasDNAME {
+			continue // synthesized from the DNAME record
+		}
+		zone, setSecure, err := v.verifyRRset(ctx, set)
+		if err != nil {
+			return false, err
+		}
+		secure = secure && setSecure
+
+		closestEncloser, isWildcard := wildcardClosestEncloser(set)
+		if !setSecure || !isWildcard {
+			continue
+		}
+		nameSecure, err := newDenial(authority, setZones, zone).
+			verifyWildcardAnswer(set.key.name, closestEncloser)
+		if err != nil {
+			return false, err
+		}
+		secure = secure && nameSecure
+	}
+
+	name := question.Name
+	if question.Qtype != dns.TypeCNAME {
+		name = finalName(name, response.Answer)
+	}
+
+	if response.Rcode == dns.RcodeSuccess &&
+		containsAnswer(answer, name, question.Qtype) {
+		return secure, nil
+	}
+
+	// DS records of a zone cut are in the parent zone.
+	zoneName := name
+	if question.Qtype == dns.TypeDS {
+		zoneName = parentName(name)
+	}
+	zone, _, zoneSecure, err := v.enclosingZone(ctx, zoneName)
+	if err != nil {
+		return false, err
+	} else if !zoneSecure {
+		return false, nil
+	} else if !hasSignatures(authority) {
+		return false, fmt.Errorf("%w: %s", errUnsignedRecords, zone)
+	}
+
+	denialSecure, err := newDenial(authority, setZones, zone).
+		verify(name, question.Qtype, response.Rcode)
+	if err != nil {
+		return false, err
+	}
+	return secure && denialSecure, nil
+}
+
+// verifyRRset verifies the RRset is signed by the zone containing it, and
+// returns this zone and false if it is insecure. The zone is found from the
+// root zone down, such that the signer name of the signatures cannot choose it.
+func (v *Validator) verifyRRset(ctx context.Context, set *rrset) (
+	zone string, secure bool, err error) {
+	if len(set.records) == 0 {
+		return "", true, nil
+	}
+
+	zone, keys, secure, err := v.enclosingZone(ctx, authoritativeName(set))
+	if err != nil {
+		return "", false, err
+	}
+
+	if !secure {
+		for _, signature := range set.signatures {
+			signer := dns.CanonicalName(signature.SignerName)
+			if signer != zone {
+				return "", false, fmt.Errorf("%w: %s instead of %s for %s",
+					errSignerInvalid, signer, zone, set.key.name)
+			}
+		}
+		return zone, false, nil
+	}
+
+	err = v.verifySigned(set, zone, keys)
+	if err != nil {
+		return "", false, err
+	}
+	return zone, true, nil
+}
+
+// authoritativeName returns the name from which to find the zone
+// containing the RRset given. DS records and NSEC records of a delegation
+// are in the parent zone of their owner, and NSEC3 records are owned by
+// hashed names directly below the apex of their zone.
+func authoritativeName(set *rrset) string {
+	switch set.key.rrtype {
+	case dns.TypeDS, dns.TypeNSEC3:
+		return parentName(set.key.name)
+	case dns.TypeNSEC:
+		nsec := set.records[0].(*dns.NSEC)
+		if !hasType(nsec.TypeBitMap, dns.TypeSOA) {
+			return parentName(set.key.name)
+		}
+	}
+	return set.key.name
+}
+
+// verifySigned verifies the RRset given is signed by the secure zone
+// given, using its keys given.
+func (v *Validator) verifySigned(set *rrset, zone string, keys []*dns.DNSKEY) (err error) {
+	if len(set.signatures) == 0 {
+		return fmt.Errorf("%w: %s", errUnsignedRecords, zone)
+	}
+
+	for _, signature := range set.signatures {
+		signer := dns.CanonicalName(signature.SignerName)
+		if signer != zone {
+			err = fmt.Errorf("%w: %s instead of %s", errSignerInvalid, signer, zone)
+			continue
+		}
+
+		err = verifySignature(signature, keys, set.records, v.timeNow())
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("for %s %s: %w", set.key.name,
+		dns.TypeToString[set.key.rrtype], err)
+}
+
+func verifySignature(signature *dns.RRSIG, keys []*dns.DNSKEY,
+	records []dns.RR, now time.Time) (err error) {
+	if !signature.ValidityPeriod(now) {
+		return errSignatureExpired
+	}
+
+	for _, key := range keys {
+		if key.Flags&dns.ZONE == 0 ||
+			key.Algorithm != signature.Algorithm ||
+			key.KeyTag() != signature.KeyTag {
+			continue
+		}
+		if signature.Verify(key, records) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: key tag %d", errSignatureInvalid, signature.KeyTag)
+}
+
+// wildcardClosestEncloser returns the closest encloser of the name of
+// the RRset given and true if the RRset is synthesized from a wildcard,
+// which is when its signatures have fewer labels than its name.
+func wildcardClosestEncloser(set *rrset) (closestEncloser string, ok bool) {
+	labels := dns.Split(set.key.name)
+	for _, signature := range set.signatures {
+		switch {
+		case int(signature.Labels) >= len(labels):
+			continue
+		case signature.Labels == 0:
+			return ".", true
+		default:
+			return set.key.name[labels[len(labels)-int(signature.Labels)]:], true
+		}
+	}
+	return "", false
+}
+
+// finalName returns the name at the end of the CNAME chain
+// starting at the name given, in canonical form.
+func finalName(name string, answer []dns.RR) string {
+	name = dns.CanonicalName(name)
+	const maxCNAMEs = 8
+	for i := 0; i < maxCNAMEs; i++ {
+		found := false
+		for _, rr := range answer {
+			cname, ok := rr.(*dns.CNAME)
+			if ok && dns.CanonicalName(cname.Hdr.Name) == name {
+				name = dns.CanonicalName(cname.Target)
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return name
+}
+
+func containsAnswer(rrsets []*rrset, name string, qtype uint16) bool {
+	for _, set := range rrsets {
+		if set.key.name == name && len(set.records) > 0 &&
+			(qtype == dns.TypeANY || set.key.rrtype == qtype) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package dnssec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	errDNSKEYMissing     = errors.New("DNSKEY records are missing")
+	errDNSKEYNotVerified = errors.New("no DNSKEY record matching a DS record verifies the DNSKEY records")
+	errUnsignedDenial    = errors.New("unsigned denial of DS records from signed zone")
+	errDenialFromChild   = errors.New("denial of DS records is from the child zone")
+	errResponseRcode     = errors.New("response has an unexpected rcode")
+)
+
+// zoneCut is the cached DNSSEC status of a name. The name is a zone
+// cut if it is the apex of a zone, in which case keys are the verified
+// DNSKEY records of the zone if it is secure.
+type zoneCut struct {
+	cut    bool
+	keys   []*dns.DNSKEY
+	secure bool
+	expiry time.Time
+}
+
+// enclosingZone returns the apex of the zone containing the name given,
+// and its verified DNSKEY records if it is secure. The zone cuts are found
+// from the root zone down, verifying the DS records of each ancestor of
+// the name, or their denial of existence, with the keys of the zone above
+// it. The search stops at the first zone proven to be insecure.
+func (v *Validator) enclosingZone(ctx context.Context, name string) (
+	zone string, keys []*dns.DNSKEY, secure bool, err error) {
+	name = dns.CanonicalName(name)
+	zone = "."
+	cut, err := v.findCut(ctx, zone, "", nil)
+	if err != nil {
+		return "", nil, false, err
+	}
+	keys, secure = cut.keys, cut.secure
+
+	labels := dns.Split(name)
+	for i := len(labels) - 1; i >= 0 && secure; i-- {
+		child := name[labels[i]:]
+		cut, err = v.findCut(ctx, child, zone, keys)
+		if err != nil {
+			return "", nil, false, err
+		} else if cut.cut {
+			zone, keys, secure = child, cut.keys, cut.secure
+		}
+	}
+	return zone, keys, secure, nil
+}
+
+// findCut returns the zone cut status of the name given, which is
+// directly below or in the secure zone given with the keys given.
+func (v *Validator) findCut(ctx context.Context, name, zone string,
+	zoneKeys []*dns.DNSKEY) (cut zoneCut, err error) {
+	now := v.timeNow()
+	cut, ok := v.zones.get(name, now)
+	if ok {
+		return cut, nil
+	}
+
+	var ttl uint32
+	if name == "." {
+		cut.cut = true
+		cut.keys, ttl, err = v.fetchKeys(ctx, name, v.anchors)
+		cut.secure = cut.keys != nil
+	} else {
+		cut, ttl, err = v.fetchCut(ctx, name, zone, zoneKeys)
+	}
+	if err != nil {
+		return zoneCut{}, err
+	}
+
+	// Only verified statuses are cached, such that
+	// failures to reach the upstream servers are retried.
+	cacheDuration := time.Duration(ttl) * time.Second
+	const maxCacheDuration = time.Hour
+	if cacheDuration > maxCacheDuration {
+		cacheDuration = maxCacheDuration
+	}
+	cut.expiry = now.Add(cacheDuration)
+	v.zones.add(name, cut)
+
+	return cut, nil
+}
+
+// fetchCut queries the DS records of the name given, and verifies them or
+// their denial of existence with the keys of the secure zone given. The
+// name is a secure zone cut if it has DS records, and an insecure zone
+// cut if their absence is proven for a delegation.
+func (v *Validator) fetchCut(ctx context.Context, name, zone string,
+	zoneKeys []*dns.DNSKEY) (cut zoneCut, ttl uint32, err error) {
+	response, err := v.query(ctx, name, dns.TypeDS)
+	if err != nil {
+		return zoneCut{}, 0, err
+	}
+
+	answer := groupRRsets(response.Answer)
+	if dsSet := findRRset(answer, name, dns.TypeDS); dsSet != nil {
+		err = v.verifySigned(dsSet, zone, zoneKeys)
+		if err != nil {
+			return zoneCut{}, 0, err
+		}
+
+		dsRecords := make([]*dns.DS, 0, len(dsSet.records))
+		for _, rr := range dsSet.records {
+			dsRecords = append(dsRecords, rr.(*dns.DS))
+		}
+		keys, keysTTL, err := v.fetchKeys(ctx, name, dsRecords)
+		if err != nil {
+			return zoneCut{}, 0, err
+		}
+		ttl = minTTL(dsSet.records)
+		if keys != nil && keysTTL < ttl {
+			ttl = keysTTL
+		}
+		return zoneCut{cut: true, keys: keys, secure: keys != nil}, ttl, nil
+	}
+
+	if cnameSet := findRRset(answer, name, dns.TypeCNAME); cnameSet != nil {
+		// A CNAME record cannot be at a zone apex.
+		err = v.verifySigned(cnameSet, zone, zoneKeys)
+		if err != nil {
+			return zoneCut{}, 0, err
+		}
+		return zoneCut{}, minTTL(cnameSet.records), nil
+	}
+
+	authority := groupRRsets(response.Ns)
+	if !hasSignatures(authority) {
+		return zoneCut{}, 0, fmt.Errorf("%w: %s", errUnsignedDenial, zone)
+	}
+	setZones := make(map[*rrset]string, len(authority))
+	for _, set := range authority {
+		if set.key.rrtype == dns.TypeNS && len(set.signatures) == 0 {
+			continue // delegation records are not signed
+		}
+		err = v.verifySigned(set, zone, zoneKeys)
+		if err != nil {
+			return zoneCut{}, 0, err
+		}
+		setZones[set] = zone
+	}
+
+	cut.cut, err = isUnsignedDelegation(name, response.Rcode,
+		newDenial(authority, setZones, zone))
+	if err != nil {
+		return zoneCut{}, 0, err
+	}
+	return cut, minTTL(response.Ns), nil
+}
+
+// isUnsignedDelegation returns true if the denial records given prove
+// the name given is a delegation without DS records, and false if they
+// prove the name is not a delegation. Only records with the NS bit set
+// and without the SOA bit set prove a delegation, since records of the
+// child zone apex also have the NS bit set. A name in an NSEC3 opt-out
+// span may be an unsigned delegation, and is treated as such.
+func isUnsignedDelegation(name string, rcode int, d denial) (
+	delegation bool, err error) {
+	var typeBitMap []uint16
+	if nsec := d.nsecMatching(name); nsec != nil {
+		typeBitMap = nsec.TypeBitMap
+	} else if nsec3 := d.nsec3Matching(name); nsec3 != nil {
+		typeBitMap = nsec3.TypeBitMap
+	} else {
+		// The name does not exist, is an empty non-terminal,
+		// matches a wildcard or is in an opt-out span.
+		secure, err := d.verify(name, dns.TypeDS, rcode)
+		if err != nil {
+			return false, err
+		}
+		return !secure && rcode != dns.RcodeNameError, nil
+	}
+
+	switch {
+	case hasType(typeBitMap, dns.TypeSOA):
+		return false, fmt.Errorf("%w: %s", errDenialFromChild, name)
+	case hasType(typeBitMap, dns.TypeDS):
+		return false, fmt.Errorf("%w: for type DS of %s", errDenialMissing, name)
+	default:
+		return hasType(typeBitMap, dns.TypeNS), nil
+	}
+}
+
+// fetchKeys fetches the DNSKEY records of the zone and verifies them with
+// a key matching one of the DS records given. It returns nil keys if none
+// of the DS records uses a supported algorithm and digest type, in which
+// case the zone is to be treated as insecure as defined in RFC 4035.
+func (v *Validator) fetchKeys(ctx context.Context, zone string,
+	dsRecords []*dns.DS) (keys []*dns.DNSKEY, ttl uint32, err error) {
+	supported := make([]*dns.DS, 0, len(dsRecords))
+	for _, ds := range dsRecords {
+		if isSupported(ds) {
+			supported = append(supported, ds)
+		}
+	}
+	if len(supported) == 0 {
+		return nil, 0, nil
+	}
+
+	response, err := v.query(ctx, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	set := findRRset(groupRRsets(response.Answer), zone, dns.TypeDNSKEY)
+	if set == nil {
+		return nil, 0, fmt.Errorf("%w: for %s", errDNSKEYMissing, zone)
+	}
+
+	keys = make([]*dns.DNSKEY, 0, len(set.records))
+	for _, rr := range set.records {
+		keys = append(keys, rr.(*dns.DNSKEY))
+	}
+
+	now := v.timeNow()
+	for _, ds := range supported {
+		for _, key := range keys {
+			if key.Algorithm != ds.Algorithm || key.KeyTag() != ds.KeyTag {
+				continue
+			}
+			keyDS := key.ToDS(ds.DigestType)
+			if keyDS == nil || !strings.EqualFold(keyDS.Digest, ds.Digest) {
+				continue
+			}
+			for _, signature := range set.signatures {
+				err := verifySignature(signature, []*dns.DNSKEY{key}, set.records, now)
+				if err == nil {
+					return keys, minTTL(set.records), nil
+				}
+			}
+		}
+	}
+
+	return nil, 0, fmt.Errorf("%w: for %s", errDNSKEYNotVerified, zone)
+}
+
+func isSupported(ds *dns.DS) bool {
+	switch ds.DigestType {
+	case dns.SHA1, dns.SHA256, dns.SHA384:
+	default:
+		return false
+	}
+	switch ds.Algorithm {
+	case dns.RSASHA1, dns.RSASHA1NSEC3SHA1, dns.RSASHA256, dns.RSASHA512,
+		dns.ECDSAP256SHA256, dns.ECDSAP384SHA384, dns.ED25519:
+		return true
+	default:
+		return false
+	}
+}
+
+func parentName(name string) string {
+	labels := dns.Split(name)
+	if len(labels) <= 1 {
+		return "."
+	}
+	return name[labels[1]:]
+}
+
+func (v *Validator) query(ctx context.Context, name string, qtype uint16) (
+	response *dns.Msg, err error) {
+	request := v.Prepare(new(dns.Msg).SetQuestion(name, qtype))
+	response, err = v.exchange(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query %s %s: %w",
+			dns.TypeToString[qtype], name, err)
+	}
+
+	if response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("%w: %s for %s %s", errResponseRcode,
+			dns.RcodeToString[response.Rcode], dns.TypeToString[qtype], name)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,141 @@
+package doh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/dot"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+var (
+	ErrIDMismatch = errors.New("DNS response ID does not match the request ID")
+)
+
+// Client is a DNS over HTTPS client exchanging DNS messages
+// with the DoH servers of the providers from its settings.
+type Client struct {
+	servers     []provider.DoHServer
+	httpClients map[string]*http.Client
+	bufferPool  *sync.Pool
+	picker      *exchange.Picker
+	method      string
+	timeout     time.Duration
+	// odoh is set to send queries with Oblivious DoH instead.
+	odoh *odohClient
+}
+
+// NewClient creates a DNS over HTTPS client. The settings are
+// expected to be valid, see ResolverSettings.Validate.
+func NewClient(settings ResolverSettings) *Client {
+	settings.setDefaults()
+
+	dohServers := make([]provider.DoHServer, len(settings.DoHProviders))
+	for i := range settings.DoHProviders {
+		dohServers[i] = settings.DoHProviders[i].DoH()
+	}
+
+	// DoT resolver settings to resolve the DoH URL hostname
+	DoTSettings := dot.ResolverSettings{
+		DoTProviders: settings.SelfDNS.DoTProviders,
+		DNSProviders: settings.SelfDNS.DNSProviders,
+		Timeout:      settings.Timeout, // http client timeout really
+		IPv6:         settings.SelfDNS.IPv6,
+	}
+	// Each DoH server has its own HTTP client and TLS configuration for
+	// its pins. The client dials the bootstrap IP addresses of the server
+	// if any are known, and resolves the URL hostname with DoT otherwise.
+	httpClients := make(map[string]*http.Client, len(dohServers))
+	for _, dohServer := range dohServers {
+		var client *http.Client
+		if len(dohServer.IPv4)+len(dohServer.IPv6) > 0 {
+			client = newBootstrapClient(dohServer, settings.Timeout, settings.SelfDNS.IPv6)
+		} else {
+			client = newDoTClient(DoTSettings, dohServer.TLSConfig())
+		}
+		httpClients[dohServer.URL.String()] = client
+	}
+
+	// HTTP bodies buffer pool
+	bufferPool := &sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(nil)
+		},
+	}
+
+	var odoh *odohClient
+	if settings.ODoHRelay != nil && settings.ODoHTarget != nil {
+		odoh = newODoHClient(settings.ODoHRelay, settings.ODoHTarget,
+			newDoTClient(DoTSettings, nil), bufferPool)
+	}
+
+	return &Client{
+		servers:     dohServers,
+		httpClients: httpClients,
+		bufferPool:  bufferPool,
+		picker:      exchange.NewPicker(), // fast thread safe random picker
+		method:      settings.Method,
+		timeout:     settings.Timeout,
+		odoh:        odoh,
+	}
+}
+
+// Exchange sends the DNS request to a DoH server picked pseudo-randomly
+// and returns its DNS response. It is safe to call concurrently.
+func (c *Client) Exchange(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, err error) {
+	server := c.pickServer()
+
+	query, err := request.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("cannot pack DNS request: %w", err)
+	}
+
+	buffer := c.bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	defer c.bufferPool.Put(buffer)
+
+	err = c.exchangeWire(ctx, server, query, buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	response = new(dns.Msg)
+	if err := response.Unpack(buffer.Bytes()); err != nil {
+		return nil, fmt.Errorf("cannot unpack DNS response: %w", err)
+	}
+
+	if response.Id != request.Id {
+		return nil, fmt.Errorf("%w: %d instead of %d", ErrIDMismatch, response.Id, request.Id)
+	}
+
+	return response, nil
+}
+
+func (c *Client) pickServer() provider.DoHServer {
+	return c.servers[c.picker.Index(len(c.servers))]
+}
+
+// exchangeWire sends the DNS query wire to the DoH server given and
+// appends the DNS response wire to the response buffer.
+// The query wire may be modified.
+func (c *Client) exchangeWire(ctx context.Context, server provider.DoHServer,
+	query []byte, response *bytes.Buffer) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if c.odoh != nil {
+		return c.odoh.exchange(ctx, query, response)
+	}
+
+	httpClient := c.httpClients[server.URL.String()]
+	return dohHTTPRequest(ctx, httpClient, c.bufferPool, server.URL,
+		c.method, query, response)
+}
@@ -0,0 +1,162 @@
+package doh
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a client using a test DoH server
+// answering with the handler given.
+func newTestClient(t *testing.T, handler dns.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			request := new(dns.Msg)
+			require.NoError(t, request.Unpack(body))
+			responseWriter := &testResponseWriter{}
+			handler(responseWriter, request)
+			wire, err := responseWriter.response.Pack()
+			require.NoError(t, err)
+			_, _ = w.Write(wire)
+		}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+	serverAddress := server.Listener.Addr().(*net.TCPAddr)
+
+	customProvider, err := provider.NewCustom(provider.CustomSettings{
+		Name: "test",
+		DoH: provider.DoHServer{
+			URL: &url.URL{
+				Scheme: "https",
+				Host:   net.JoinHostPort("example.com", fmt.Sprint(serverAddress.Port)),
+				Path:   "/dns-query",
+			},
+			IPv4:    []net.IP{serverAddress.IP},
+			RootCAs: rootCAs,
+		},
+	})
+	require.NoError(t, err)
+
+	return NewClient(ResolverSettings{
+		DoHProviders: []provider.Provider{customProvider},
+	})
+}
+
+type testResponseWriter struct {
+	dns.ResponseWriter
+	response *dns.Msg
+}
+
+func (w *testResponseWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+func answerHandler(w dns.ResponseWriter, request *dns.Msg) {
+	response := new(dns.Msg).SetReply(request)
+	question := request.Question[0]
+	header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: 300}
+	switch question.Qtype {
+	case dns.TypeA:
+		response.Answer = []dns.RR{&dns.A{Hdr: header, A: net.IP{1, 2, 3, 4}}}
+	case dns.TypeAAAA:
+		response.Answer = []dns.RR{&dns.AAAA{Hdr: header, AAAA: net.ParseIP("2001:db8::1")}}
+	}
+	_ = w.WriteMsg(response)
+}
+
+func Test_Client_Exchange(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		handler dns.HandlerFunc
+		err     error
+	}{
+		"success": {
+			handler: answerHandler,
+		},
+		"ID mismatch": {
+			handler: func(w dns.ResponseWriter, request *dns.Msg) {
+				response := new(dns.Msg).SetReply(request)
+				response.Id++
+				_ = w.WriteMsg(response)
+			},
+			err: ErrIDMismatch,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, testCase.handler)
+			request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+
+			response, err := client.Exchange(context.Background(), request)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, request.Id, response.Id)
+			require.Len(t, response.Answer, 1)
+			assert.Equal(t, "example.com.\t300\tIN\tA\t1.2.3.4", response.Answer[0].String())
+		})
+	}
+}
+
+func Test_Client_resolverDial(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, answerHandler)
+	resolver := &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: true,
+		Dial:         exchange.NewDial(client),
+	}
+
+	ips, err := resolver.LookupIP(context.Background(), "ip", "example.com")
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []net.IP{{1, 2, 3, 4}, net.ParseIP("2001:db8::1")}, ips)
+
+	conn, err := exchange.NewDial(client)(context.Background(), "", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	dnsConn := &dns.Conn{Conn: conn}
+	for _, qType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		request := new(dns.Msg).SetQuestion("example.com.", qType)
+		require.NoError(t, dnsConn.WriteMsg(request))
+	}
+	for _, qType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		response, err := dnsConn.ReadMsg()
+		require.NoError(t, err)
+		require.Len(t, response.Answer, 1)
+		assert.Equal(t, qType, response.Answer[0].Header().Rrtype)
+	}
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -3,24 +3,51 @@ package doh
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/miekg/dns"
 	"github.com/qdm12/dns/pkg/dot"
+	"github.com/qdm12/dns/pkg/provider"
 )
 
 var (
-	ErrHTTPStatus = errors.New("bad HTTP status")
+	ErrHTTPStatus       = errors.New("bad HTTP status")
+	ErrResponseTooLarge = errors.New("DNS response is too large")
+	ErrResponseTooShort = errors.New("DNS response is too short")
 )
 
-func newDoTClient(settings dot.ResolverSettings) *http.Client {
+// maxResponseSize is the maximum size of a DNS message,
+// to bound the size of the response body read.
+const maxResponseSize = dns.MaxMsgSize
+
+// newHTTPTransport returns an HTTP transport attempting HTTP/2 such that
+// queries to the DoH server are multiplexed over a single connection,
+// which is kept open for reuse by the next queries.
+func newHTTPTransport(tlsConfig *tls.Config) *http.Transport {
 	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.TLSClientConfig = tlsConfig
+	httpTransport.ForceAttemptHTTP2 = true
+	const idleConnTimeout = 5 * time.Minute
+	httpTransport.IdleConnTimeout = idleConnTimeout
+	return httpTransport
+}
+
+// newDoTClient returns an HTTP client resolving hostnames with DNS over TLS,
+// and using the TLS configuration given for its HTTPS connections.
+func newDoTClient(settings dot.ResolverSettings, tlsConfig *tls.Config) *http.Client {
+	httpTransport := newHTTPTransport(tlsConfig)
 	dialer := &net.Dialer{
 		Resolver: dot.NewResolver(settings),
 	}
@@ -32,44 +59,194 @@ func newDoTClient(settings dot.ResolverSettings) *http.Client {
 	}
 }
 
-func dohHTTPRequest(ctx context.Context, client *http.Client, bufferPool *sync.Pool,
-	url *url.URL, wire []byte) (respWire []byte, err error) { //nolint:interfacer
-	buffer := bufferPool.Get().(*bytes.Buffer)
-	buffer.Reset()
-	defer bufferPool.Put(buffer)
+// newBootstrapClient returns an HTTP client dialing the bootstrap IP
+// addresses of the DoH server instead of resolving its URL hostname,
+// trying IPv6 addresses first only if ipv6 is true, and using the URL
+// hostname as TLS server name.
+func newBootstrapClient(server provider.DoHServer, timeout time.Duration,
+	ipv6 bool) *http.Client {
+	ips := server.IPv4
+	if ipv6 || len(ips) == 0 {
+		ips = append(append([]net.IP{}, server.IPv6...), server.IPv4...)
+	}
 
-	_, err = buffer.Write(wire)
-	if err != nil {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+	}
+
+	httpTransport := newHTTPTransport(server.TLSConfig())
+	httpTransport.DialContext = func(ctx context.Context, network, address string) (
+		conn net.Conn, err error) {
+		_, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+		}
 		return nil, err
 	}
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), buffer)
+	const clientTimeout = 5 * time.Second
+	return &http.Client{
+		Timeout:   clientTimeout,
+		Transport: httpTransport,
+	}
+}
+
+// dohHTTPRequest sends the DNS query wire to the DoH server at the URL
+// given using the HTTP method given, and appends the DNS response wire
+// to the response buffer. For GET requests, the DNS message ID is set to
+// zero in the query to favour HTTP caching, as recommended by RFC 8484
+// section 4.1, and set back to its original value in the response.
+// The query wire may be modified.
+func dohHTTPRequest(ctx context.Context, client *http.Client, bufferPool *sync.Pool,
+	url *url.URL, method string, query []byte, response *bytes.Buffer) (err error) { //nolint:interfacer
+	var request *http.Request
+	var id uint16
+	switch method {
+	case http.MethodGet:
+		const idLength = 2
+		if len(query) >= idLength {
+			id = binary.BigEndian.Uint16(query)
+			binary.BigEndian.PutUint16(query, 0)
+		}
+		getURL := *url
+		values := getURL.Query()
+		values.Set("dns", base64.RawURLEncoding.EncodeToString(query))
+		getURL.RawQuery = values.Encode()
+		request, err = http.NewRequestWithContext(ctx, http.MethodGet, getURL.String(), nil)
+	default:
+		buffer := bufferPool.Get().(*bytes.Buffer)
+		buffer.Reset()
+		defer bufferPool.Put(buffer)
+		_, _ = buffer.Write(query)
+		request, err = http.NewRequestWithContext(ctx, http.MethodPost, url.String(), buffer)
+		if err == nil {
+			request.Header.Set("Content-Type", "application/dns-message")
+		}
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	request.Header.Set("Content-Type", "application/dns-message")
 	request.Header.Set("Accept", "application/dns-message")
 
-	response, err := client.Do(request)
+	httpResponse, err := client.Do(request)
+	if err != nil {
+		return err
+	}
 
+	start := response.Len()
+	err = readHTTPResponse(httpResponse, response, maxResponseSize)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer response.Body.Close()
 
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%w: %s", ErrHTTPStatus, response.Status)
+	wire := response.Bytes()[start:]
+	const headerLength = 12
+	if len(wire) < headerLength {
+		return fmt.Errorf("%w: %d bytes", ErrResponseTooShort, len(wire))
 	}
 
-	respWire, err = io.ReadAll(response.Body) // TODO copy to buffer
+	if method == http.MethodGet {
+		binary.BigEndian.PutUint16(wire, id)
+	}
+
+	maxAge, hasMaxAge, age := parseCacheHeaders(httpResponse.Header)
+	if !hasMaxAge && age == 0 {
+		return nil
+	}
+
+	// Decrease the TTLs of the response records according to the
+	// HTTP freshness lifetime of the response, see RFC 8484 section 5.1.
+	msg := new(dns.Msg)
+	if err := msg.Unpack(wire); err != nil {
+		return fmt.Errorf("cannot unpack DNS response: %w", err)
+	}
+	adjustTTLs(msg, maxAge, hasMaxAge, age)
+	wire, err = msg.Pack()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("cannot pack DNS response: %w", err)
 	}
+	response.Truncate(start)
+	_, err = response.Write(wire)
+	return err
+}
 
-	if err := response.Body.Close(); err != nil {
-		return nil, err
+// readHTTPResponse checks the status of the HTTP response, appends its body
+// to the buffer given and closes it. The body read is bounded to maxSize bytes.
+func readHTTPResponse(httpResponse *http.Response, buffer *bytes.Buffer,
+	maxSize int64) (err error) {
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", ErrHTTPStatus, httpResponse.Status)
+	}
+
+	limitedBody := io.LimitReader(httpResponse.Body, maxSize+1)
+	n, err := buffer.ReadFrom(limitedBody)
+	if err != nil {
+		return err
+	} else if n > maxSize {
+		return fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, maxSize)
+	}
+
+	return httpResponse.Body.Close()
+}
+
+// parseCacheHeaders returns the max-age directive of the Cache-Control
+// header and the Age header of an HTTP response, in seconds.
+// Invalid values are ignored.
+func parseCacheHeaders(header http.Header) (maxAge uint32, hasMaxAge bool, age uint32) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		const prefix = "max-age="
+		if !strings.HasPrefix(directive, prefix) {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimPrefix(directive, prefix), 10, 32)
+		if err == nil {
+			maxAge, hasMaxAge = uint32(value), true
+		}
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(header.Get("Age")), 10, 32)
+	if err == nil {
+		age = uint32(value)
 	}
 
-	return respWire, nil
+	return maxAge, hasMaxAge, age
+}
+
+// adjustTTLs decreases the TTL of each record of the message by the age
+// given, and caps it to the remaining freshness lifetime if hasMaxAge is true.
+func adjustTTLs(msg *dns.Msg, maxAge uint32, hasMaxAge bool, age uint32) {
+	freshness := uint32(0)
+	if maxAge > age {
+		freshness = maxAge - age
+	}
+
+	sections := [][]dns.RR{msg.Answer, msg.Ns, msg.Extra}
+	for _, section := range sections {
+		for _, rr := range section {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+
+			if header.Ttl > age {
+				header.Ttl -= age
+			} else {
+				header.Ttl = 0
+			}
+
+			if hasMaxAge && header.Ttl > freshness {
+				header.Ttl = freshness
+			}
+		}
+	}
 }
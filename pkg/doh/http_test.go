@@ -0,0 +1,212 @@
+package doh
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newBootstrapClient(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+
+	serverAddress := server.Listener.Addr().(*net.TCPAddr)
+	// The httptest certificate is valid for example.com, which
+	// is not resolved since the bootstrap IP address is dialed.
+	dohServer := provider.DoHServer{
+		URL: &url.URL{
+			Scheme: "https",
+			Host:   net.JoinHostPort("example.com", fmt.Sprint(serverAddress.Port)),
+			Path:   "/dns-query",
+		},
+		IPv4:    []net.IP{serverAddress.IP},
+		RootCAs: rootCAs,
+	}
+
+	client := newBootstrapClient(dohServer, time.Second, false)
+
+	response, err := client.Get(dohServer.URL.String())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NoError(t, response.Body.Close())
+}
+
+func Test_dohHTTPRequest(t *testing.T) {
+	t.Parallel()
+
+	query := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	query.Id = 1234
+	answer := new(dns.Msg).SetReply(query)
+	answer.Answer = []dns.RR{&dns.A{
+		Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   net.IP{1, 2, 3, 4},
+	}}
+
+	testCases := map[string]struct {
+		method   string
+		handler  http.HandlerFunc
+		ttl      uint32
+		err      error
+		errRegex string
+	}{
+		"POST": {
+			method: http.MethodPost,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				request := new(dns.Msg)
+				require.NoError(t, request.Unpack(body))
+				assert.Equal(t, query.Id, request.Id)
+				assert.Equal(t, "application/dns-message", r.Header.Get("Content-Type"))
+				wire, err := answer.Pack()
+				require.NoError(t, err)
+				_, _ = w.Write(wire)
+			},
+			ttl: 300,
+		},
+		"GET with cache headers": {
+			method: http.MethodGet,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				wire, err := base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+				require.NoError(t, err)
+				request := new(dns.Msg)
+				require.NoError(t, request.Unpack(wire))
+				assert.Equal(t, uint16(0), request.Id)
+				response := answer.Copy()
+				response.Id = 0
+				wire, err = response.Pack()
+				require.NoError(t, err)
+				w.Header().Set("Cache-Control", "public, max-age=60")
+				w.Header().Set("Age", "10")
+				_, _ = w.Write(wire)
+			},
+			ttl: 50,
+		},
+		"bad status": {
+			method: http.MethodPost,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			},
+			err:      ErrHTTPStatus,
+			errRegex: "bad HTTP status: 400 Bad Request",
+		},
+		"response too large": {
+			method: http.MethodPost,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(make([]byte, maxResponseSize+1))
+			},
+			err:      ErrResponseTooLarge,
+			errRegex: "DNS response is too large: exceeds 65535 bytes",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewUnstartedServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, 2, r.ProtoMajor)
+					assert.Equal(t, testCase.method, r.Method)
+					testCase.handler(w, r)
+				}))
+			server.EnableHTTP2 = true
+			server.StartTLS()
+			t.Cleanup(server.Close)
+
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(server.Certificate())
+			client := &http.Client{
+				Transport: newHTTPTransport(&tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}),
+			}
+			serverURL, err := url.Parse(server.URL + "/dns-query")
+			require.NoError(t, err)
+
+			bufferPool := &sync.Pool{New: func() interface{} { return bytes.NewBuffer(nil) }}
+			wire, err := query.Pack()
+			require.NoError(t, err)
+			response := bytes.NewBuffer(nil)
+
+			err = dohHTTPRequest(context.Background(), client, bufferPool,
+				serverURL, testCase.method, wire, response)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.Regexp(t, testCase.errRegex, err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			msg := new(dns.Msg)
+			require.NoError(t, msg.Unpack(response.Bytes()))
+			assert.Equal(t, query.Id, msg.Id)
+			require.Len(t, msg.Answer, 1)
+			assert.Equal(t, testCase.ttl, msg.Answer[0].Header().Ttl)
+		})
+	}
+}
+
+func Test_parseCacheHeaders(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		header    http.Header
+		maxAge    uint32
+		hasMaxAge bool
+		age       uint32
+	}{
+		"no header": {
+			header: http.Header{},
+		},
+		"max-age and age": {
+			header: http.Header{
+				"Cache-Control": []string{"public, Max-Age=3600"},
+				"Age":           []string{"42"},
+			},
+			maxAge:    3600,
+			hasMaxAge: true,
+			age:       42,
+		},
+		"invalid values": {
+			header: http.Header{
+				"Cache-Control": []string{"max-age=-1"},
+				"Age":           []string{"x"},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			maxAge, hasMaxAge, age := parseCacheHeaders(testCase.header)
+
+			assert.Equal(t, testCase.maxAge, maxAge)
+			assert.Equal(t, testCase.hasMaxAge, hasMaxAge)
+			assert.Equal(t, testCase.age, age)
+		})
+	}
+}
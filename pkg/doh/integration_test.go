@@ -39,7 +39,8 @@ func Test_Server(t *testing.T) {
 	logger := mock_logging.NewMockLogger(ctrl)
 	logger.EXPECT().Info("DNS server listening on :53")
 
-	server := NewServer(ctx, logger, ServerSettings{})
+	server, err := NewServer(ctx, logger, ServerSettings{})
+	require.NoError(t, err)
 
 	go server.Run(ctx, stopped)
 
@@ -77,6 +78,6 @@ func Test_Server(t *testing.T) {
 
 	endWg.Wait()
 	cancel()
-	err := <-stopped
+	err = <-stopped
 	assert.Nil(t, err)
 }
@@ -0,0 +1,393 @@
+package doh
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/kem"
+)
+
+// Oblivious DNS over HTTPS (ODoH), see RFC 9230.
+const (
+	odohContentType = "application/oblivious-dns-message"
+	odohConfigsPath = "/.well-known/odohconfigs"
+	odohVersion     = 0x0001
+
+	odohQueryType    byte = 0x01
+	odohResponseType byte = 0x02
+
+	// odohPaddingBlockSize is the size the queries with
+	// their padding are a multiple of, see RFC 8467.
+	odohPaddingBlockSize = 128
+
+	// maxODoHResponseSize bounds the size of ODoH responses, which contain
+	// the response nonce and the encrypted response, each length prefixed.
+	maxODoHResponseSize = 1 + 2*(2+math.MaxUint16)
+)
+
+var (
+	ErrODoHConfigNotFound    = errors.New("no supported Oblivious DoH config")
+	ErrODoHConfigsMalformed  = errors.New("Oblivious DoH configs are malformed")
+	ErrODoHResponseMalformed = errors.New("Oblivious DoH response is malformed")
+)
+
+// odohClient sends DNS queries encrypted for the ODoH target through
+// the ODoH relay, such that the relay does not see the queries and the
+// target does not see the client IP address.
+type odohClient struct {
+	relayURL   *url.URL // with the target host and path query parameters
+	configsURL *url.URL
+	httpClient *http.Client
+	bufferPool *sync.Pool
+
+	configMutex sync.Mutex
+	config      *odohConfig
+}
+
+func newODoHClient(relay, target *url.URL, httpClient *http.Client,
+	bufferPool *sync.Pool) *odohClient {
+	relayURL := *relay
+	values := relayURL.Query()
+	values.Set("targethost", target.Host)
+	values.Set("targetpath", target.Path)
+	relayURL.RawQuery = values.Encode()
+
+	return &odohClient{
+		relayURL: &relayURL,
+		configsURL: &url.URL{
+			Scheme: "https",
+			Host:   target.Host,
+			Path:   odohConfigsPath,
+		},
+		httpClient: httpClient,
+		bufferPool: bufferPool,
+	}
+}
+
+// exchange sends the DNS query wire encrypted to the ODoH target through
+// the relay, and appends the decrypted DNS response wire to the response
+// buffer. The configuration of the target is fetched again once if the
+// target does not know the key used, for example after a key rotation.
+func (c *odohClient) exchange(ctx context.Context, query []byte,
+	response *bytes.Buffer) (err error) {
+	config, err := c.getConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.exchangeWithConfig(ctx, config, query, response)
+	if !errors.Is(err, errODoHKeyUnknown) {
+		return err
+	}
+
+	c.configMutex.Lock()
+	if c.config == config {
+		c.config = nil
+	}
+	c.configMutex.Unlock()
+
+	config, err = c.getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	return c.exchangeWithConfig(ctx, config, query, response)
+}
+
+// errODoHKeyUnknown is returned if the ODoH target
+// answers with 401 since it does not know the key used.
+var errODoHKeyUnknown = errors.New("Oblivious DoH target key is unknown")
+
+func (c *odohClient) exchangeWithConfig(ctx context.Context, config *odohConfig,
+	query []byte, response *bytes.Buffer) (err error) {
+	queryPlain := encodeODoHPlaintext(query)
+	message, sealer, err := config.encryptQuery(queryPlain)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.relayURL.String(), bytes.NewReader(message))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", odohContentType)
+	request.Header.Set("Accept", odohContentType)
+
+	httpResponse, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	if httpResponse.StatusCode == http.StatusUnauthorized {
+		_ = httpResponse.Body.Close()
+		return errODoHKeyUnknown
+	}
+
+	buffer := c.bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	defer c.bufferPool.Put(buffer)
+	err = readHTTPResponse(httpResponse, buffer, maxODoHResponseSize)
+	if err != nil {
+		return err
+	}
+
+	responsePlain, err := decryptODoHResponse(config.suite, sealer,
+		queryPlain, buffer.Bytes())
+	if err != nil {
+		return err
+	}
+
+	wire, ok := decodeODoHPlaintext(responsePlain)
+	const headerLength = 12
+	if !ok {
+		return fmt.Errorf("%w: invalid plaintext", ErrODoHResponseMalformed)
+	} else if len(wire) < headerLength {
+		return fmt.Errorf("%w: %d bytes", ErrResponseTooShort, len(wire))
+	}
+
+	_, err = response.Write(wire)
+	return err
+}
+
+// getConfig returns the configuration of the ODoH target,
+// fetching it from the target if it is not known yet.
+func (c *odohClient) getConfig(ctx context.Context) (config *odohConfig, err error) {
+	c.configMutex.Lock()
+	defer c.configMutex.Unlock()
+
+	if c.config != nil {
+		return c.config, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.configsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResponse, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch Oblivious DoH configs: %w", err)
+	}
+
+	buffer := c.bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	defer c.bufferPool.Put(buffer)
+	err = readHTTPResponse(httpResponse, buffer, math.MaxUint16+2) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch Oblivious DoH configs: %w", err)
+	}
+
+	config, err = parseODoHConfigs(buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	c.config = config
+
+	return config, nil
+}
+
+// odohConfig is an ObliviousDoHConfig of the target.
+type odohConfig struct {
+	suite     hpke.Suite
+	publicKey kem.PublicKey
+	keyID     []byte
+}
+
+// parseODoHConfigs returns the first configuration of the ObliviousDoHConfigs
+// given which has a supported version and HPKE suite.
+func parseODoHConfigs(bin []byte) (config *odohConfig, err error) {
+	configs, rest, ok := readLengthPrefixed(bin)
+	if !ok || len(rest) > 0 {
+		return nil, fmt.Errorf("%w: bad length", ErrODoHConfigsMalformed)
+	}
+
+	const versionLength = 2
+	for len(configs) > 0 {
+		if len(configs) < versionLength {
+			return nil, fmt.Errorf("%w: truncated version", ErrODoHConfigsMalformed)
+		}
+		version := binary.BigEndian.Uint16(configs)
+		var contents []byte
+		contents, configs, ok = readLengthPrefixed(configs[versionLength:])
+		if !ok {
+			return nil, fmt.Errorf("%w: truncated config", ErrODoHConfigsMalformed)
+		}
+
+		if version != odohVersion {
+			continue
+		}
+
+		config, ok = parseODoHConfigContents(contents)
+		if ok {
+			return config, nil
+		}
+	}
+
+	return nil, ErrODoHConfigNotFound
+}
+
+// parseODoHConfigContents parses the ObliviousDoHConfigContents given,
+// and returns false if they are malformed or if the HPKE suite is not
+// supported.
+func parseODoHConfigContents(contents []byte) (config *odohConfig, ok bool) {
+	const suiteLength = 3 * 2
+	if len(contents) < suiteLength {
+		return nil, false
+	}
+	kemID := hpke.KEM(binary.BigEndian.Uint16(contents))
+	kdfID := hpke.KDF(binary.BigEndian.Uint16(contents[2:]))
+	aeadID := hpke.AEAD(binary.BigEndian.Uint16(contents[4:]))
+	if !kemID.IsValid() || !kdfID.IsValid() || !aeadID.IsValid() {
+		return nil, false
+	}
+
+	publicKeyBytes, rest, ok := readLengthPrefixed(contents[suiteLength:])
+	if !ok || len(rest) > 0 {
+		return nil, false
+	}
+	publicKey, err := kemID.Scheme().UnmarshalBinaryPublicKey(publicKeyBytes)
+	if err != nil {
+		return nil, false
+	}
+
+	// The key identifier is derived from the config contents,
+	// see RFC 9230 section 6.2.
+	pseudorandomKey := kdfID.Extract(contents, nil)
+	keyID := kdfID.Expand(pseudorandomKey, []byte("odoh key id"), uint(kdfID.ExtractSize()))
+
+	return &odohConfig{
+		suite:     hpke.NewSuite(kemID, kdfID, aeadID),
+		publicKey: publicKey,
+		keyID:     keyID,
+	}, true
+}
+
+// encryptQuery encrypts the ObliviousDoHMessagePlaintext query given for
+// the target, and returns the ObliviousDoHMessage to send and the HPKE
+// context to decrypt the response with, see RFC 9230 section 6.3.
+func (c *odohConfig) encryptQuery(queryPlain []byte) (message []byte,
+	sealer hpke.Sealer, err error) {
+	sender, err := c.suite.NewSender(c.publicKey, []byte("odoh query"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encapsulatedKey, sealer, err := sender.Setup(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aad := appendLengthPrefixed([]byte{odohQueryType}, c.keyID)
+	ciphertext, err := sealer.Seal(queryPlain, aad)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	message = appendLengthPrefixed([]byte{odohQueryType}, c.keyID)
+	encrypted := make([]byte, 0, len(encapsulatedKey)+len(ciphertext))
+	encrypted = append(encrypted, encapsulatedKey...)
+	encrypted = append(encrypted, ciphertext...)
+	message = appendLengthPrefixed(message, encrypted)
+	return message, sealer, nil
+}
+
+// decryptODoHResponse decrypts the ObliviousDoHMessage response of the
+// target with keys derived from the HPKE context of the query, and returns
+// the ObliviousDoHMessagePlaintext response, see RFC 9230 section 6.4.
+func decryptODoHResponse(suite hpke.Suite, sealer hpke.Sealer,
+	queryPlain, message []byte) (responsePlain []byte, err error) {
+	if len(message) == 0 || message[0] != odohResponseType {
+		return nil, fmt.Errorf("%w: bad message type", ErrODoHResponseMalformed)
+	}
+	responseNonce, rest, ok := readLengthPrefixed(message[1:])
+	if !ok {
+		return nil, fmt.Errorf("%w: truncated nonce", ErrODoHResponseMalformed)
+	}
+	ciphertext, rest, ok := readLengthPrefixed(rest)
+	if !ok || len(rest) > 0 {
+		return nil, fmt.Errorf("%w: bad encrypted message length", ErrODoHResponseMalformed)
+	}
+
+	_, kdfID, aeadID := suite.Params()
+	secret := sealer.Export([]byte("odoh response"), aeadID.KeySize())
+	salt := appendLengthPrefixed(append([]byte{}, queryPlain...), responseNonce)
+	pseudorandomKey := kdfID.Extract(secret, salt)
+	key := kdfID.Expand(pseudorandomKey, []byte("odoh key"), aeadID.KeySize())
+	nonce := kdfID.Expand(pseudorandomKey, []byte("odoh nonce"), aeadID.NonceSize())
+
+	aead, err := aeadID.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aad := appendLengthPrefixed([]byte{odohResponseType}, responseNonce)
+	responsePlain, err = aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt Oblivious DoH response: %w", err)
+	}
+	return responsePlain, nil
+}
+
+// encodeODoHPlaintext returns the ObliviousDoHMessagePlaintext of the DNS
+// message wire, padded with zeros to a multiple of 128 bytes.
+func encodeODoHPlaintext(wire []byte) (plaintext []byte) {
+	const prefixesLength = 2 * 2
+	paddingLength := 0
+	if remainder := (len(wire) + prefixesLength) % odohPaddingBlockSize; remainder != 0 {
+		paddingLength = odohPaddingBlockSize - remainder
+	}
+
+	plaintext = make([]byte, 0, len(wire)+prefixesLength+paddingLength)
+	plaintext = appendLengthPrefixed(plaintext, wire)
+	return appendLengthPrefixed(plaintext, make([]byte, paddingLength))
+}
+
+// decodeODoHPlaintext returns the DNS message wire of the
+// ObliviousDoHMessagePlaintext given, and false if it is malformed
+// or if its padding is not only zeros.
+func decodeODoHPlaintext(plaintext []byte) (wire []byte, ok bool) {
+	wire, rest, ok := readLengthPrefixed(plaintext)
+	if !ok {
+		return nil, false
+	}
+	padding, rest, ok := readLengthPrefixed(rest)
+	if !ok || len(rest) > 0 {
+		return nil, false
+	}
+	for _, b := range padding {
+		if b != 0 {
+			return nil, false
+		}
+	}
+	return wire, true
+}
+
+// readLengthPrefixed reads a value prefixed with its two bytes length,
+// and returns false if there are not enough bytes.
+func readLengthPrefixed(bin []byte) (value, rest []byte, ok bool) {
+	const prefixLength = 2
+	if len(bin) < prefixLength {
+		return nil, nil, false
+	}
+	length := int(binary.BigEndian.Uint16(bin))
+	bin = bin[prefixLength:]
+	if len(bin) < length {
+		return nil, nil, false
+	}
+	return bin[:length], bin[length:], true
+}
+
+// appendLengthPrefixed appends the value prefixed with its two bytes length.
+func appendLengthPrefixed(bin, value []byte) []byte {
+	bin = binary.BigEndian.AppendUint16(bin, uint16(len(value)))
+	return append(bin, value...)
+}
@@ -0,0 +1,344 @@
+package doh
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/kem"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// odohTestSuite is the HPKE suite of the test ODoH target.
+var odohTestSuite = hpke.NewSuite(hpke.KEM_X25519_HKDF_SHA256, //nolint:gochecknoglobals
+	hpke.KDF_HKDF_SHA256, hpke.AEAD_AES128GCM)
+
+func newODoHTestKey(t *testing.T) (publicKey kem.PublicKey, privateKey kem.PrivateKey) {
+	t.Helper()
+	publicKey, privateKey, err := hpke.KEM_X25519_HKDF_SHA256.Scheme().GenerateKeyPair()
+	require.NoError(t, err)
+	return publicKey, privateKey
+}
+
+// encodeODoHTestConfigs returns the ObliviousDoHConfigs for the public
+// key given, preceded by a config with an unsupported version.
+func encodeODoHTestConfigs(t *testing.T, publicKey kem.PublicKey) []byte {
+	t.Helper()
+	publicKeyBytes, err := publicKey.MarshalBinary()
+	require.NoError(t, err)
+
+	contents := binary.BigEndian.AppendUint16(nil, uint16(hpke.KEM_X25519_HKDF_SHA256))
+	contents = binary.BigEndian.AppendUint16(contents, uint16(hpke.KDF_HKDF_SHA256))
+	contents = binary.BigEndian.AppendUint16(contents, uint16(hpke.AEAD_AES128GCM))
+	contents = appendLengthPrefixed(contents, publicKeyBytes)
+
+	configs := binary.BigEndian.AppendUint16(nil, 0xff00)
+	configs = appendLengthPrefixed(configs, []byte{1, 2, 3})
+	configs = binary.BigEndian.AppendUint16(configs, odohVersion)
+	configs = appendLengthPrefixed(configs, contents)
+	return appendLengthPrefixed(nil, configs)
+}
+
+// odohTestTarget acts as both the ODoH relay and target, answering
+// A queries with 1.2.3.4 for the current key pair only.
+type odohTestTarget struct {
+	t          *testing.T
+	publicKey  kem.PublicKey
+	privateKey kem.PrivateKey
+	keyID      []byte
+	// tamper is set to corrupt the encrypted responses.
+	tamper bool
+}
+
+func newODoHTestTarget(t *testing.T) *odohTestTarget {
+	t.Helper()
+	publicKey, privateKey := newODoHTestKey(t)
+	config, err := parseODoHConfigs(encodeODoHTestConfigs(t, publicKey))
+	require.NoError(t, err)
+	return &odohTestTarget{
+		t:          t,
+		publicKey:  publicKey,
+		privateKey: privateKey,
+		keyID:      config.keyID,
+	}
+}
+
+func (o *odohTestTarget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := o.t
+
+	if r.Method == http.MethodGet && r.URL.Path == odohConfigsPath {
+		_, _ = w.Write(encodeODoHTestConfigs(t, o.publicKey))
+		return
+	}
+
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "/proxy", r.URL.Path)
+	assert.Equal(t, "/dns-query", r.URL.Query().Get("targetpath"))
+	assert.NotEmpty(t, r.URL.Query().Get("targethost"))
+	assert.Equal(t, odohContentType, r.Header.Get("Content-Type"))
+	assert.Equal(t, odohContentType, r.Header.Get("Accept"))
+
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	require.NotEmpty(t, body)
+	require.Equal(t, odohQueryType, body[0])
+	keyID, rest, ok := readLengthPrefixed(body[1:])
+	require.True(t, ok)
+	if !bytes.Equal(keyID, o.keyID) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	encrypted, rest, ok := readLengthPrefixed(rest)
+	require.True(t, ok)
+	require.Empty(t, rest)
+
+	encapsulatedKeySize := hpke.KEM_X25519_HKDF_SHA256.Scheme().CiphertextSize()
+	receiver, err := odohTestSuite.NewReceiver(o.privateKey, []byte("odoh query"))
+	require.NoError(t, err)
+	opener, err := receiver.Setup(encrypted[:encapsulatedKeySize])
+	require.NoError(t, err)
+	aad := appendLengthPrefixed([]byte{odohQueryType}, keyID)
+	queryPlain, err := opener.Open(encrypted[encapsulatedKeySize:], aad)
+	require.NoError(t, err)
+	assert.Zero(t, len(queryPlain)%odohPaddingBlockSize)
+
+	queryWire, ok := decodeODoHPlaintext(queryPlain)
+	require.True(t, ok)
+	query := new(dns.Msg)
+	require.NoError(t, query.Unpack(queryWire))
+	answer := new(dns.Msg).SetReply(query)
+	answer.Answer = []dns.RR{&dns.A{
+		Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   net.IP{1, 2, 3, 4},
+	}}
+	answerWire, err := answer.Pack()
+	require.NoError(t, err)
+
+	_, kdfID, aeadID := odohTestSuite.Params()
+	responseNonce := make([]byte, aeadID.KeySize())
+	_, err = rand.Read(responseNonce)
+	require.NoError(t, err)
+	secret := opener.Export([]byte("odoh response"), aeadID.KeySize())
+	salt := appendLengthPrefixed(append([]byte{}, queryPlain...), responseNonce)
+	pseudorandomKey := kdfID.Extract(secret, salt)
+	key := kdfID.Expand(pseudorandomKey, []byte("odoh key"), aeadID.KeySize())
+	nonce := kdfID.Expand(pseudorandomKey, []byte("odoh nonce"), aeadID.NonceSize())
+	aead, err := aeadID.New(key)
+	require.NoError(t, err)
+	responseAAD := appendLengthPrefixed([]byte{odohResponseType}, responseNonce)
+	ciphertext := aead.Seal(nil, nonce, encodeODoHPlaintext(answerWire), responseAAD)
+	if o.tamper {
+		ciphertext[0] ^= 0xff
+	}
+
+	message := appendLengthPrefixed([]byte{odohResponseType}, responseNonce)
+	message = appendLengthPrefixed(message, ciphertext)
+	w.Header().Set("Content-Type", odohContentType)
+	_, _ = w.Write(message)
+}
+
+func Test_odohClient_exchange(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		staleKey bool
+		tamper   bool
+		errRegex string
+	}{
+		"success": {},
+		"stale key refetched": {
+			staleKey: true,
+		},
+		"tampered response": {
+			tamper:   true,
+			errRegex: "cannot decrypt Oblivious DoH response: .+",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			target := newODoHTestTarget(t)
+			target.tamper = testCase.tamper
+			server := httptest.NewTLSServer(target)
+			t.Cleanup(server.Close)
+
+			serverURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			relay := &url.URL{Scheme: "https", Host: serverURL.Host, Path: "/proxy"}
+			targetURL := &url.URL{Scheme: "https", Host: serverURL.Host, Path: "/dns-query"}
+			bufferPool := &sync.Pool{
+				New: func() interface{} { return bytes.NewBuffer(nil) },
+			}
+			client := newODoHClient(relay, targetURL, server.Client(), bufferPool)
+
+			if testCase.staleKey {
+				stalePublicKey, _ := newODoHTestKey(t)
+				client.config, err = parseODoHConfigs(encodeODoHTestConfigs(t, stalePublicKey))
+				require.NoError(t, err)
+			}
+
+			query := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+			query.Id = 1234
+			queryWire, err := query.Pack()
+			require.NoError(t, err)
+
+			response := bytes.NewBuffer(nil)
+			err = client.exchange(context.Background(), queryWire, response)
+
+			if testCase.errRegex != "" {
+				require.Error(t, err)
+				assert.Regexp(t, testCase.errRegex, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			answer := new(dns.Msg)
+			require.NoError(t, answer.Unpack(response.Bytes()))
+			assert.Equal(t, query.Id, answer.Id)
+			require.Len(t, answer.Answer, 1)
+			assert.Equal(t, net.IP{1, 2, 3, 4}, answer.Answer[0].(*dns.A).A.To4())
+			assert.Equal(t, target.keyID, client.config.keyID)
+		})
+	}
+}
+
+func Test_parseODoHConfigs(t *testing.T) {
+	t.Parallel()
+
+	publicKey, _ := newODoHTestKey(t)
+
+	testCases := map[string]struct {
+		bin      []byte
+		err      error
+		errRegex string
+	}{
+		"empty": {
+			err:      ErrODoHConfigsMalformed,
+			errRegex: "Oblivious DoH configs are malformed: bad length",
+		},
+		"no config": {
+			bin: []byte{0, 0},
+			err: ErrODoHConfigNotFound,
+		},
+		"unsupported version only": {
+			bin: []byte{0, 5, 0xff, 0, 0, 1, 0},
+			err: ErrODoHConfigNotFound,
+		},
+		"truncated config": {
+			bin:      []byte{0, 4, 0, 1, 0, 9},
+			err:      ErrODoHConfigsMalformed,
+			errRegex: "Oblivious DoH configs are malformed: truncated config",
+		},
+		"valid": {
+			bin: encodeODoHTestConfigs(t, publicKey),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := parseODoHConfigs(testCase.bin)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				if testCase.errRegex != "" {
+					assert.Regexp(t, testCase.errRegex, err.Error())
+				}
+				assert.Nil(t, config)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, config.publicKey.Equal(publicKey))
+			assert.Len(t, config.keyID, hpke.KDF_HKDF_SHA256.ExtractSize())
+		})
+	}
+}
+
+func Test_encodeODoHPlaintext(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		wire   []byte
+		length int
+	}{
+		"empty": {
+			length: 128,
+		},
+		"exact block": {
+			wire:   make([]byte, 124),
+			length: 128,
+		},
+		"one block and a byte": {
+			wire:   make([]byte, 125),
+			length: 256,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			plaintext := encodeODoHPlaintext(testCase.wire)
+
+			assert.Len(t, plaintext, testCase.length)
+			wire, ok := decodeODoHPlaintext(plaintext)
+			assert.True(t, ok)
+			assert.Equal(t, len(testCase.wire), len(wire))
+		})
+	}
+}
+
+func Test_decodeODoHPlaintext(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		plaintext []byte
+		wire      []byte
+		ok        bool
+	}{
+		"empty": {},
+		"no padding length": {
+			plaintext: []byte{0, 1, 9},
+		},
+		"non zero padding": {
+			plaintext: []byte{0, 1, 9, 0, 1, 1},
+		},
+		"trailing bytes": {
+			plaintext: []byte{0, 1, 9, 0, 0, 0},
+		},
+		"valid": {
+			plaintext: []byte{0, 1, 9, 0, 2, 0, 0},
+			wire:      []byte{9},
+			ok:        true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			wire, ok := decodeODoHPlaintext(testCase.plaintext)
+
+			assert.Equal(t, testCase.ok, ok)
+			assert.Equal(t, testCase.wire, wire)
+		})
+	}
+}
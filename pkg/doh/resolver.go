@@ -2,14 +2,15 @@ package doh
 
 import (
 	"net"
+
+	"github.com/qdm12/dns/pkg/exchange"
 )
 
 // NewResolver creates a DNS over HTTPs resolver.
 func NewResolver(settings ResolverSettings) *net.Resolver {
-	settings.setDefaults()
 	return &net.Resolver{
 		PreferGo:     true,
 		StrictErrors: true,
-		Dial:         newDoHDial(settings),
+		Dial:         exchange.NewDial(NewClient(settings)),
 	}
 }
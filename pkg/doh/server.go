@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/handler"
+	"github.com/qdm12/dns/pkg/localdata"
 	"github.com/qdm12/golibs/logging"
 )
 
@@ -14,29 +17,47 @@ import (
 
 type Server interface {
 	Run(ctx context.Context, stopped chan<- error)
+	// SetBlacklist swaps the blacklist used by the server,
+	// and is safe to call while the server is running.
+	SetBlacklist(settings blacklist.Settings)
+	// SetLocalData swaps the local records answered by the server,
+	// and is safe to call while the server is running.
+	SetLocalData(settings localdata.Settings)
 }
 
 type server struct {
 	dnsServer dns.Server
+	handler   *handler.Handler
 	logger    logging.Logger
 }
 
 func NewServer(ctx context.Context, logger logging.Logger,
-	settings ServerSettings) Server {
+	settings ServerSettings) (Server, error) {
 	if runtime.GOOS == "windows" {
 		logger.Warn("The Windows host cannot use the DoH server as its DNS")
 	}
 
 	settings.setDefaults()
+	err := settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(settings.Resolver)
+	dnsHandler, err := handler.New(ctx, logger, client, settings.Settings)
+	if err != nil {
+		return nil, err
+	}
 
 	return &server{
 		dnsServer: dns.Server{
 			Addr:    ":" + strconv.Itoa(int(settings.Port)),
 			Net:     "udp",
-			Handler: newDNSHandler(ctx, logger, settings),
+			Handler: dnsHandler,
 		},
-		logger: logger,
-	}
+		handler: dnsHandler,
+		logger:  logger,
+	}, nil
 }
 
 func (s *server) Run(ctx context.Context, stopped chan<- error) {
@@ -54,3 +75,11 @@ func (s *server) Run(ctx context.Context, stopped chan<- error) {
 	s.logger.Info("DNS server listening on " + s.dnsServer.Addr)
 	stopped <- s.dnsServer.ListenAndServe()
 }
+
+func (s *server) SetBlacklist(settings blacklist.Settings) {
+	s.handler.SetBlacklist(settings)
+}
+
+func (s *server) SetLocalData(settings localdata.Settings) {
+	s.handler.SetLocalData(settings)
+}
@@ -1,30 +1,47 @@
 package doh
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/qdm12/dns/pkg/blacklist"
-	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/handler"
 	"github.com/qdm12/dns/pkg/provider"
 )
 
 type ServerSettings struct {
-	Resolver  ResolverSettings
-	Port      uint16
-	Cache     cache.Settings
-	Blacklist blacklist.Settings
+	Resolver ResolverSettings
+	Port     uint16
+	// Settings are the DNS handler settings,
+	// see pkg/handler/settings.go
+	handler.Settings
 }
 
 type ResolverSettings struct {
 	DoHProviders []provider.Provider
 	SelfDNS      SelfDNS
 	Timeout      time.Duration
+	// Method is the HTTP method to send queries with, either
+	// POST or GET, and defaults to POST. GET queries have their
+	// ID set to zero such that responses can be cached by HTTP caches.
+	Method string
+	// ODoHRelay is the URL of the Oblivious DoH relay to send
+	// encrypted queries through, see RFC 9230. It must be set
+	// together with ODoHTarget, in which case the DoH providers
+	// are not used.
+	ODoHRelay *url.URL
+	// ODoHTarget is the URL of the Oblivious DoH target decrypting
+	// and resolving the queries forwarded by the relay.
+	ODoHTarget *url.URL
 }
 
 type SelfDNS struct {
-	// for the internal HTTP client to resolve the DoH url hostname.
+	// for the internal HTTP client to resolve the DoH url hostname,
+	// only used for DoH servers without bootstrap IP addresses.
 	DoTProviders []provider.Provider
 	DNSProviders []provider.Provider
 	Timeout      time.Duration
@@ -39,8 +56,7 @@ func (s *ServerSettings) setDefaults() {
 		s.Port = defaultPort
 	}
 
-	// Cache defaults to disabled, see pkg/cache/settings.go
-	s.Cache.SetDefaults()
+	s.Settings.SetDefaults()
 }
 
 func (s *ResolverSettings) setDefaults() {
@@ -54,6 +70,10 @@ func (s *ResolverSettings) setDefaults() {
 		const defaultTimeout = 5 * time.Second
 		s.Timeout = defaultTimeout
 	}
+
+	if s.Method == "" {
+		s.Method = http.MethodPost
+	}
 }
 
 func (s *SelfDNS) setDefaults() {
@@ -69,6 +89,35 @@ func (s *SelfDNS) setDefaults() {
 	// to avoid leaking we are using a DoH server.
 }
 
+// Validate returns an error if the settings cannot be used by
+// the server, and is to be called once the defaults are set.
+func (s *ServerSettings) Validate() (err error) {
+	err = s.Resolver.Validate()
+	if err != nil {
+		return fmt.Errorf("resolver settings: %w", err)
+	}
+
+	return s.Settings.Validate()
+}
+
+var (
+	ErrODoHRelayMissing  = errors.New("ODoH relay is not set")
+	ErrODoHTargetMissing = errors.New("ODoH target is not set")
+)
+
+// Validate returns an error if the settings cannot be used by
+// the client, and is to be called once the defaults are set.
+func (s *ResolverSettings) Validate() (err error) {
+	switch {
+	case s.ODoHRelay == nil && s.ODoHTarget != nil:
+		return fmt.Errorf("%w: for target %s", ErrODoHRelayMissing, s.ODoHTarget)
+	case s.ODoHRelay != nil && s.ODoHTarget == nil:
+		return fmt.Errorf("%w: for relay %s", ErrODoHTargetMissing, s.ODoHRelay)
+	}
+
+	return nil
+}
+
 const (
 	subSection = " |--"
 	indent     = "    " // used if lines already contain the subSection
@@ -95,15 +144,7 @@ func (s *ServerSettings) Lines(indent, subSection string) (lines []string) {
 		lines = append(lines, indent+line)
 	}
 
-	lines = append(lines, subSection+"Caching:")
-	for _, line := range s.Cache.Lines(indent, subSection) {
-		lines = append(lines, indent+line)
-	}
-
-	lines = append(lines, subSection+"Blacklist:")
-	for _, line := range s.Blacklist.Lines(indent, subSection) {
-		lines = append(lines, indent+line)
-	}
+	lines = append(lines, s.Settings.Lines(indent, subSection)...)
 
 	return lines
 }
@@ -112,9 +153,16 @@ func (s *ResolverSettings) Lines(indent, subSection string) (lines []string) {
 	lines = append(lines,
 		subSection+"Query timeout: "+s.Timeout.String())
 
-	lines = append(lines, subSection+"DNS over HTTPS providers:")
-	for _, provider := range s.DoHProviders {
-		lines = append(lines, indent+subSection+provider.String())
+	lines = append(lines, subSection+"HTTP method: "+s.Method)
+
+	if s.ODoHRelay != nil && s.ODoHTarget != nil {
+		lines = append(lines, subSection+"Oblivious DoH relay: "+s.ODoHRelay.String())
+		lines = append(lines, subSection+"Oblivious DoH target: "+s.ODoHTarget.String())
+	} else {
+		lines = append(lines, subSection+"DNS over HTTPS providers:")
+		for _, provider := range s.DoHProviders {
+			lines = append(lines, indent+subSection+provider.String())
+		}
 	}
 
 	lines = append(lines, subSection+"Internal DNS:")
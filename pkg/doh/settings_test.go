@@ -1,13 +1,19 @@
 package doh
 
 import (
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/qdm12/dns/pkg/accesscontrol"
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/dns/pkg/forward"
+	"github.com/qdm12/dns/pkg/handler"
 	"github.com/qdm12/dns/pkg/provider"
 	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
 )
 
 func Test_ServerSettings_setDefaults(t *testing.T) {
@@ -31,12 +37,25 @@ func Test_ServerSettings_setDefaults(t *testing.T) {
 				IPv6:         false,
 			},
 			Timeout: 5 * time.Second,
+			Method:  "POST",
 		},
 		Port: 53,
-		Cache: cache.Settings{
-			Type: cache.Disabled,
+		Settings: handler.Settings{
+			Cache: cache.Settings{
+				Type: cache.Disabled,
+			},
+			AccessControl: accesscontrol.Settings{
+				Rules: accesscontrol.DefaultRules(),
+			},
+			Forward: forward.Settings{
+				Timeout: 5 * time.Second,
+			},
+			DNS64: dns64.Settings{
+				Prefix: netaddr.MustParseIPPrefix("64:ff9b::/96"),
+			},
 		},
 	}
+	expectedSettings.DNSSEC.SetDefaults()
 	assert.Equal(t, expectedSettings, s)
 }
 
@@ -44,11 +63,13 @@ func Test_ServerSettings_Lines(t *testing.T) {
 	t.Parallel()
 
 	s := ServerSettings{
-		Blacklist: blacklist.Settings{
-			FqdnHostnames: []string{"abc.com"},
-		},
-		Cache: cache.Settings{
-			Type: cache.LRU,
+		Settings: handler.Settings{
+			Blacklist: blacklist.Settings{
+				FqdnHostnames: []string{"abc.com"},
+			},
+			Cache: cache.Settings{
+				Type: cache.LRU,
+			},
 		},
 	}
 	s.setDefaults()
@@ -59,6 +80,7 @@ func Test_ServerSettings_Lines(t *testing.T) {
 		" |--Listening port: 53",
 		" |--Resolver:",
 		"     |--Query timeout: 5s",
+		"     |--HTTP method: POST",
 		"     |--DNS over HTTPS providers:",
 		"         |--Cloudflare",
 		"     |--Internal DNS:",
@@ -71,6 +93,109 @@ func Test_ServerSettings_Lines(t *testing.T) {
 		"     |--Max entries: 100000",
 		" |--Blacklist:",
 		"     |--Hostnames blocked: 1",
+		" |--Access control:",
+		"     |--127.0.0.0/8: allow",
+		"     |--10.0.0.0/8: allow",
+		"     |--172.16.0.0/12: allow",
+		"     |--192.168.0.0/16: allow",
+		"     |--::1/128: allow",
+		"     |--fc00::/7: allow",
+		"     |--fe80::/10: allow",
+		"     |--0.0.0.0/0: refuse",
+		"     |--::/0: refuse",
+		" |--Local records: 0",
+		" |--DNS64: disabled",
+		" |--DNSSEC validation: disabled",
+	}
+	assert.Equal(t, expectedLines, lines)
+}
+
+func Test_ResolverSettings_Lines_odoh(t *testing.T) {
+	t.Parallel()
+
+	s := ResolverSettings{
+		ODoHRelay:  &url.URL{Scheme: "https", Host: "relay.example.com", Path: "/proxy"},
+		ODoHTarget: &url.URL{Scheme: "https", Host: "target.example.com", Path: "/dns-query"},
+	}
+	s.setDefaults()
+
+	lines := s.Lines(indent, subSection)
+
+	expectedLines := []string{
+		" |--Query timeout: 5s",
+		" |--HTTP method: POST",
+		" |--Oblivious DoH relay: https://relay.example.com/proxy",
+		" |--Oblivious DoH target: https://target.example.com/dns-query",
+		" |--Internal DNS:",
+		"     |--Connecting using IPv4 DNS addresses",
+		"     |--Query timeout: 5s",
+		"     |--DNS over TLS providers:",
+		"         |--Cloudflare",
 	}
 	assert.Equal(t, expectedLines, lines)
 }
+
+func Test_ServerSettings_Validate(t *testing.T) {
+	t.Parallel()
+
+	relay := &url.URL{Scheme: "https", Host: "relay.example.com", Path: "/proxy"}
+	target := &url.URL{Scheme: "https", Host: "target.example.com", Path: "/dns-query"}
+
+	testCases := map[string]struct {
+		settings ServerSettings
+		err      error
+		errMsg   string
+	}{
+		"defaults": {},
+		"Oblivious DoH": {
+			settings: ServerSettings{
+				Resolver: ResolverSettings{ODoHRelay: relay, ODoHTarget: target},
+			},
+		},
+		"Oblivious DoH relay only": {
+			settings: ServerSettings{
+				Resolver: ResolverSettings{ODoHRelay: relay},
+			},
+			err: ErrODoHTargetMissing,
+			errMsg: "resolver settings: ODoH target is not set: " +
+				"for relay https://relay.example.com/proxy",
+		},
+		"Oblivious DoH target only": {
+			settings: ServerSettings{
+				Resolver: ResolverSettings{ODoHTarget: target},
+			},
+			err: ErrODoHRelayMissing,
+			errMsg: "resolver settings: ODoH relay is not set: " +
+				"for target https://target.example.com/dns-query",
+		},
+		"DNS64 with invalid prefix": {
+			settings: ServerSettings{
+				Settings: handler.Settings{
+					DNS64: dns64.Settings{
+						Enabled: true,
+						Prefix:  netaddr.MustParseIPPrefix("2001:db8::/33"),
+					},
+				},
+			},
+			err: dns64.ErrPrefixLengthInvalid,
+			errMsg: "DNS64 settings: prefix length is not one of " +
+				"32, 40, 48, 56, 64 or 96: 2001:db8::/33",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			testCase.settings.setDefaults()
+
+			err := testCase.settings.Validate()
+
+			assert.ErrorIs(t, err, testCase.err)
+			if testCase.err != nil {
+				assert.EqualError(t, err, testCase.errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package doq
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/quic-go/quic-go"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrIDMismatch       = errors.New("DNS response ID does not match the request ID")
+	ErrNoServerIP       = errors.New("no IP address for DoQ server")
+	ErrQueryTooShort    = errors.New("DNS query is too short")
+	ErrResponseTooShort = errors.New("DNS response is too short")
+)
+
+// Client is a DNS over QUIC client exchanging DNS messages
+// with the DoQ servers of the providers from its settings.
+type Client struct {
+	servers []provider.DoQServer
+	picker  *exchange.Picker
+	timeout time.Duration
+	ipv6    bool
+
+	// connections maps a server address to its QUIC connection,
+	// which is kept open and reused by the next queries.
+	connections      map[string]quic.Connection
+	connectionsMutex sync.Mutex
+	// dials ensures a single dial is in flight for each server address.
+	dials singleflight.Group
+}
+
+// NewClient creates a DNS over QUIC client.
+func NewClient(settings ResolverSettings) *Client {
+	settings.setDefaults()
+
+	doqServers := make([]provider.DoQServer, len(settings.DoQProviders))
+	for i := range settings.DoQProviders {
+		doqServers[i] = settings.DoQProviders[i].DoQ()
+	}
+
+	return &Client{
+		servers:     doqServers,
+		picker:      exchange.NewPicker(), // fast thread safe random picker
+		timeout:     settings.Timeout,
+		ipv6:        settings.IPv6,
+		connections: make(map[string]quic.Connection, len(doqServers)),
+	}
+}
+
+// Exchange sends the DNS request to a DoQ server picked pseudo-randomly
+// and returns its DNS response. It is safe to call concurrently.
+func (c *Client) Exchange(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, err error) {
+	server := c.pickServer()
+
+	query, err := request.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("cannot pack DNS request: %w", err)
+	}
+
+	wire, err := c.exchangeWire(ctx, server, query)
+	if err != nil {
+		return nil, err
+	}
+
+	response = new(dns.Msg)
+	if err := response.Unpack(wire); err != nil {
+		return nil, fmt.Errorf("cannot unpack DNS response: %w", err)
+	}
+
+	if response.Id != request.Id {
+		return nil, fmt.Errorf("%w: %d instead of %d", ErrIDMismatch, response.Id, request.Id)
+	}
+
+	return response, nil
+}
+
+func (c *Client) pickServer() provider.DoQServer {
+	return c.servers[c.picker.Index(len(c.servers))]
+}
+
+// exchangeWire sends the DNS query wire to the DoQ server given over
+// a new QUIC stream, and returns the DNS response wire.
+// The query wire may be modified.
+func (c *Client) exchangeWire(ctx context.Context, server provider.DoQServer,
+	query []byte) (response []byte, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.getConnection(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	return exchangeStream(ctx, conn, query)
+}
+
+// getConnection returns the open QUIC connection to the server given,
+// or dials a new one if there is none or if it was closed, for example
+// because of its idle timeout.
+func (c *Client) getConnection(ctx context.Context, server provider.DoQServer) (
+	conn quic.Connection, err error) {
+	ip := c.picker.ServerIP(server.IPv4, server.IPv6, c.ipv6)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoServerIP, server.Name)
+	}
+	address := net.JoinHostPort(ip.String(), strconv.Itoa(int(server.Port)))
+
+	if conn := c.openConnection(address); conn != nil {
+		return conn, nil
+	}
+
+	// Dial without holding the connections mutex, such that exchanges
+	// with other servers are not blocked while dialing this one.
+	result := c.dials.DoChan(address, func() (interface{}, error) {
+		if conn := c.openConnection(address); conn != nil {
+			return conn, nil // dialed just before by another exchange
+		}
+
+		conn, err := quic.DialAddr(ctx, address, server.TLSConfig(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("cannot dial DoQ server %s: %w", address, err)
+		}
+
+		c.connectionsMutex.Lock()
+		c.connections[address] = conn
+		c.connectionsMutex.Unlock()
+		return conn, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case dial := <-result:
+		if dial.Err != nil {
+			return nil, dial.Err
+		}
+		return dial.Val.(quic.Connection), nil
+	}
+}
+
+// openConnection returns the QUIC connection to the address given,
+// or nil if there is none or if it was closed.
+func (c *Client) openConnection(address string) (conn quic.Connection) {
+	c.connectionsMutex.Lock()
+	defer c.connectionsMutex.Unlock()
+	conn, ok := c.connections[address]
+	if !ok || conn.Context().Err() != nil {
+		return nil
+	}
+	return conn
+}
+
+// lengthPrefix is the size of the message length prefix
+// of DNS messages over QUIC streams.
+const lengthPrefix = 2
+
+// exchangeStream sends the DNS query wire over a new stream of the
+// QUIC connection, and returns the DNS response wire. As required by
+// RFC 9250 section 4.2.1, the message ID is set to zero in the query,
+// and set back to its original value in the response.
+// The query wire may be modified.
+func exchangeStream(ctx context.Context, conn quic.Connection,
+	query []byte) (response []byte, err error) {
+	const headerLength = 12
+	if len(query) < headerLength {
+		return nil, fmt.Errorf("%w: %d bytes", ErrQueryTooShort, len(query))
+	}
+	id := binary.BigEndian.Uint16(query)
+	binary.BigEndian.PutUint16(query, 0)
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open QUIC stream: %w", err)
+	}
+	defer stream.CancelRead(0)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = stream.SetDeadline(deadline)
+	}
+
+	message := make([]byte, lengthPrefix+len(query))
+	binary.BigEndian.PutUint16(message, uint16(len(query)))
+	copy(message[lengthPrefix:], query)
+	if _, err := stream.Write(message); err != nil {
+		return nil, fmt.Errorf("cannot write to QUIC stream: %w", err)
+	}
+
+	// The client must indicate the end of its query, see RFC 9250 section 4.2.
+	if err := stream.Close(); err != nil {
+		return nil, fmt.Errorf("cannot close QUIC stream: %w", err)
+	}
+
+	response, err = readMessage(stream)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read from QUIC stream: %w", err)
+	}
+
+	if len(response) < headerLength {
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooShort, len(response))
+	}
+	binary.BigEndian.PutUint16(response, id)
+
+	return response, nil
+}
+
+// readMessage reads a DNS message prefixed with its two bytes length.
+func readMessage(reader io.Reader) (message []byte, err error) {
+	prefix := make([]byte, lengthPrefix)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return nil, err
+	}
+
+	message = make([]byte, binary.BigEndian.Uint16(prefix))
+	if _, err := io.ReadFull(reader, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
@@ -0,0 +1,73 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/doq (interfaces: Server)
+
+// Package mock_doq is a generated GoMock package.
+package mock_doq
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	blacklist "github.com/qdm12/dns/pkg/blacklist"
+	localdata "github.com/qdm12/dns/pkg/localdata"
+)
+
+// MockServer is a mock of Server interface.
+type MockServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockServerMockRecorder
+}
+
+// MockServerMockRecorder is the mock recorder for MockServer.
+type MockServerMockRecorder struct {
+	mock *MockServer
+}
+
+// NewMockServer creates a new mock instance.
+func NewMockServer(ctrl *gomock.Controller) *MockServer {
+	mock := &MockServer{ctrl: ctrl}
+	mock.recorder = &MockServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServer) EXPECT() *MockServerMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method.
+func (m *MockServer) Run(arg0 context.Context, arg1 chan<- error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", arg0, arg1)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockServerMockRecorder) Run(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockServer)(nil).Run), arg0, arg1)
+}
+
+// SetBlacklist mocks base method.
+func (m *MockServer) SetBlacklist(arg0 blacklist.Settings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetBlacklist", arg0)
+}
+
+// SetBlacklist indicates an expected call of SetBlacklist.
+func (mr *MockServerMockRecorder) SetBlacklist(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlacklist", reflect.TypeOf((*MockServer)(nil).SetBlacklist), arg0)
+}
+
+// SetLocalData mocks base method.
+func (m *MockServer) SetLocalData(arg0 localdata.Settings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLocalData", arg0)
+}
+
+// SetLocalData indicates an expected call of SetLocalData.
+func (mr *MockServerMockRecorder) SetLocalData(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocalData", reflect.TypeOf((*MockServer)(nil).SetLocalData), arg0)
+}
@@ -0,0 +1,16 @@
+package doq
+
+import (
+	"net"
+
+	"github.com/qdm12/dns/pkg/exchange"
+)
+
+// NewResolver creates a DNS over QUIC resolver.
+func NewResolver(settings ResolverSettings) *net.Resolver {
+	return &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: true,
+		Dial:         exchange.NewDial(NewClient(settings)),
+	}
+}
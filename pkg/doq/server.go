@@ -0,0 +1,203 @@
+package doq
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/handler"
+	"github.com/qdm12/dns/pkg/localdata"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/golibs/logging"
+	"github.com/quic-go/quic-go"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Server
+
+type Server interface {
+	Run(ctx context.Context, stopped chan<- error)
+	// SetBlacklist swaps the blacklist used by the server,
+	// and is safe to call while the server is running.
+	SetBlacklist(settings blacklist.Settings)
+	// SetLocalData swaps the local records answered by the server,
+	// and is safe to call while the server is running.
+	SetLocalData(settings localdata.Settings)
+}
+
+type server struct {
+	address    string
+	tlsConfig  *tls.Config
+	dnsHandler dns.Handler
+	handler    *handler.Handler
+	logger     logging.Logger
+}
+
+func NewServer(ctx context.Context, logger logging.Logger,
+	settings ServerSettings) (Server, error) {
+	settings.setDefaults()
+	client := NewClient(settings.Resolver)
+	dnsHandler, err := handler.New(ctx, logger, client, settings.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{} //nolint:gosec
+	if settings.TLSConfig != nil {
+		tlsConfig = settings.TLSConfig.Clone()
+	}
+	tlsConfig.MinVersion = tls.VersionTLS13
+	tlsConfig.NextProtos = []string{provider.DoQALPN}
+
+	return &server{
+		address:    ":" + strconv.Itoa(int(settings.Port)),
+		tlsConfig:  tlsConfig,
+		dnsHandler: dnsHandler,
+		handler:    dnsHandler,
+		logger:     logger,
+	}, nil
+}
+
+func (s *server) Run(ctx context.Context, stopped chan<- error) {
+	listener, err := quic.ListenAddr(s.address, s.tlsConfig, nil)
+	if err != nil {
+		stopped <- err
+		return
+	}
+
+	s.logger.Info("DNS over QUIC server listening on " + listener.Addr().String())
+	stopped <- s.serve(ctx, listener)
+}
+
+func (s *server) SetBlacklist(settings blacklist.Settings) {
+	s.handler.SetBlacklist(settings)
+}
+
+func (s *server) SetLocalData(settings localdata.Settings) {
+	s.handler.SetLocalData(settings)
+}
+
+// DoQ error codes, used both as connection and stream
+// error codes, see RFC 9250 section 4.3.
+const (
+	doqNoError          = 0x0
+	doqProtocolError    = 0x2
+	doqRequestCancelled = 0x3
+)
+
+// serve accepts QUIC connections from the listener until the
+// context is canceled, and then closes the listener.
+func (s *server) serve(ctx context.Context, listener *quic.Listener) (err error) {
+	go func() { // shutdown goroutine
+		<-ctx.Done()
+		if err := listener.Close(); err != nil {
+			s.logger.Error("DNS over QUIC listener shutdown error: " + err.Error())
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, quic.ErrServerClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleConnection(ctx, conn)
+	}
+}
+
+// handleConnection accepts the streams of the QUIC connection,
+// each of them carrying a single DNS query and its response.
+func (s *server) handleConnection(ctx context.Context, conn quic.Connection) {
+	for {
+		stream, err := conn.AcceptStream(ctx)
+		if err != nil {
+			_ = conn.CloseWithError(doqNoError, "")
+			return
+		}
+		go s.handleStream(conn, stream)
+	}
+}
+
+func (s *server) handleStream(conn quic.Connection, stream quic.Stream) {
+	const readTimeout = 10 * time.Second
+	_ = stream.SetReadDeadline(time.Now().Add(readTimeout))
+
+	wire, err := readMessage(stream)
+	if err != nil {
+		stream.CancelRead(doqProtocolError)
+		stream.CancelWrite(doqProtocolError)
+		return
+	}
+
+	request := new(dns.Msg)
+	if err := request.Unpack(wire); err != nil {
+		stream.CancelRead(doqProtocolError)
+		stream.CancelWrite(doqProtocolError)
+		return
+	}
+
+	// The message ID must be zero, see RFC 9250 section 4.2.1.
+	if request.Id != 0 {
+		_ = conn.CloseWithError(doqProtocolError, "DNS message ID is not zero")
+		return
+	}
+
+	writer := &responseWriter{
+		conn:   conn,
+		stream: stream,
+	}
+	s.dnsHandler.ServeDNS(writer, request)
+
+	if !writer.written {
+		// For example if the client is not allowed.
+		stream.CancelWrite(doqRequestCancelled)
+	}
+}
+
+// responseWriter is a dns.ResponseWriter writing
+// the DNS response to a QUIC stream.
+type responseWriter struct {
+	conn    quic.Connection
+	stream  quic.Stream
+	written bool
+}
+
+func (w *responseWriter) LocalAddr() net.Addr  { return w.conn.LocalAddr() }
+func (w *responseWriter) RemoteAddr() net.Addr { return w.conn.RemoteAddr() }
+
+// WriteMsg writes the DNS response prefixed with its two bytes
+// length, and closes the stream to indicate the end of the response.
+func (w *responseWriter) WriteMsg(response *dns.Msg) error {
+	wire, err := response.Pack()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(wire)
+	return err
+}
+
+func (w *responseWriter) Write(wire []byte) (n int, err error) {
+	w.written = true
+	message := make([]byte, lengthPrefix+len(wire))
+	binary.BigEndian.PutUint16(message, uint16(len(wire)))
+	copy(message[lengthPrefix:], wire)
+	if _, err := w.stream.Write(message); err != nil {
+		return 0, err
+	}
+	return len(wire), w.stream.Close()
+}
+
+func (w *responseWriter) Close() error {
+	return w.stream.Close()
+}
+
+func (w *responseWriter) TsigStatus() error   { return nil }
+func (w *responseWriter) TsigTimersOnly(bool) {}
+func (w *responseWriter) Hijack()             {}
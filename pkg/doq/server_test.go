@@ -0,0 +1,230 @@
+package doq
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/golibs/logging/mock_logging"
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCertificate returns a self signed TLS certificate for example.com.
+func newTestCertificate(t *testing.T) (certificate tls.Certificate, leaf *x509.Certificate) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		DNSNames:              []string{"example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	leaf, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	certificate = tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  privateKey,
+		Leaf:        leaf,
+	}
+	return certificate, leaf
+}
+
+// newTestServer starts an in-process DoQ server answering with the
+// handler given, and returns the DoQ server description to reach it.
+func newTestServer(t *testing.T, handler dns.HandlerFunc) provider.DoQServer {
+	t.Helper()
+
+	certificate, leaf := newTestCertificate(t)
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS13,
+		NextProtos:   []string{provider.DoQALPN},
+	}
+	listener, err := quic.ListenAddr("127.0.0.1:0", tlsConfig, nil)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	logger := mock_logging.NewMockLogger(ctrl)
+	logger.EXPECT().Error(gomock.Any()).AnyTimes()
+
+	s := &server{
+		dnsHandler: handler,
+		logger:     logger,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(leaf)
+	listenAddress := listener.Addr().(*net.UDPAddr)
+
+	return provider.DoQServer{
+		IPv4:    []net.IP{listenAddress.IP},
+		Name:    "example.com",
+		Port:    uint16(listenAddress.Port),
+		RootCAs: rootCAs,
+	}
+}
+
+// newTestClient returns a client using a test DoQ server
+// answering with the handler given.
+func newTestClient(t *testing.T, handler dns.HandlerFunc) *Client {
+	t.Helper()
+
+	customProvider, err := provider.NewCustom(provider.CustomSettings{
+		Name: "test",
+		DoQ:  newTestServer(t, handler),
+	})
+	require.NoError(t, err)
+
+	return NewClient(ResolverSettings{
+		DoQProviders: []provider.Provider{customProvider},
+	})
+}
+
+func answerHandler(w dns.ResponseWriter, request *dns.Msg) {
+	response := new(dns.Msg).SetReply(request)
+	question := request.Question[0]
+	header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: 300}
+	switch question.Qtype {
+	case dns.TypeA:
+		response.Answer = []dns.RR{&dns.A{Hdr: header, A: net.IP{1, 2, 3, 4}}}
+	case dns.TypeAAAA:
+		response.Answer = []dns.RR{&dns.AAAA{Hdr: header, AAAA: net.ParseIP("2001:db8::1")}}
+	}
+	_ = w.WriteMsg(response)
+}
+
+func Test_Client_Exchange(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		handler dns.HandlerFunc
+		err     error
+	}{
+		"success": {
+			handler: answerHandler,
+		},
+		"no response": {
+			handler: func(w dns.ResponseWriter, request *dns.Msg) {},
+			err:     &quic.StreamError{ErrorCode: doqRequestCancelled, Remote: true},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, testCase.handler)
+			request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+
+			response, err := client.Exchange(context.Background(), request)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, request.Id, response.Id)
+			require.Len(t, response.Answer, 1)
+			assert.Equal(t, "example.com.\t300\tIN\tA\t1.2.3.4", response.Answer[0].String())
+
+			// The second exchange reuses the QUIC connection.
+			_, err = client.Exchange(context.Background(), request)
+			require.NoError(t, err)
+			assert.Len(t, client.connections, 1)
+		})
+	}
+}
+
+func Test_server_nonZeroID(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, answerHandler)
+	address := net.JoinHostPort(server.IPv4[0].String(), fmt.Sprint(server.Port))
+	conn, err := quic.DialAddr(context.Background(), address, server.TLSConfig(), nil)
+	require.NoError(t, err)
+
+	stream, err := conn.OpenStreamSync(context.Background())
+	require.NoError(t, err)
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	request.Id = 1
+	wire, err := request.Pack()
+	require.NoError(t, err)
+	_, err = stream.Write(append([]byte{0, byte(len(wire))}, wire...))
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+
+	_, err = io.ReadAll(stream)
+
+	var applicationErr *quic.ApplicationError
+	require.ErrorAs(t, err, &applicationErr)
+	assert.Equal(t, quic.ApplicationErrorCode(doqProtocolError), applicationErr.ErrorCode)
+}
+
+func Test_Client_resolverDial(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, answerHandler)
+	resolver := &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: true,
+		Dial:         exchange.NewDial(client),
+	}
+
+	ips, err := resolver.LookupIP(context.Background(), "ip", "example.com")
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []net.IP{{1, 2, 3, 4}, net.ParseIP("2001:db8::1")}, ips)
+
+	conn, err := exchange.NewDial(client)(context.Background(), "", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	dnsConn := &dns.Conn{Conn: conn}
+	for _, qType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		request := new(dns.Msg).SetQuestion("example.com.", qType)
+		require.NoError(t, dnsConn.WriteMsg(request))
+	}
+	for _, qType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		response, err := dnsConn.ReadMsg()
+		require.NoError(t, err)
+		require.Len(t, response.Answer, 1)
+		assert.Equal(t, qType, response.Answer[0].Header().Rrtype)
+	}
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,99 @@
+package doq
+
+import (
+	"crypto/tls"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qdm12/dns/pkg/handler"
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+type ServerSettings struct {
+	Resolver ResolverSettings
+	// Port is the UDP port the QUIC listener listens on,
+	// and defaults to 853.
+	Port uint16
+	// TLSConfig is the TLS configuration of the QUIC listener,
+	// which must contain the certificates of the server.
+	TLSConfig *tls.Config
+	// Settings are the DNS handler settings,
+	// see pkg/handler/settings.go
+	handler.Settings
+}
+
+type ResolverSettings struct {
+	// DoQProviders are the providers whose DNS over QUIC
+	// servers are used, and defaults to AdGuard.
+	DoQProviders []provider.Provider
+	Timeout      time.Duration
+	IPv6         bool
+}
+
+func (s *ServerSettings) setDefaults() {
+	s.Resolver.setDefaults()
+
+	if s.Port == 0 {
+		const defaultPort = 853
+		s.Port = defaultPort
+	}
+
+	s.Settings.SetDefaults()
+}
+
+func (s *ResolverSettings) setDefaults() {
+	if len(s.DoQProviders) == 0 {
+		s.DoQProviders = []provider.Provider{provider.AdGuard()}
+	}
+
+	if s.Timeout == 0 {
+		const defaultTimeout = 5 * time.Second
+		s.Timeout = defaultTimeout
+	}
+}
+
+const (
+	subSection = " |--"
+	indent     = "    " // used if lines already contain the subSection
+)
+
+func (s *ServerSettings) String() string {
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *ResolverSettings) String() string {
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *ServerSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Resolver:")
+	for _, line := range s.Resolver.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines,
+		subSection+"Listening port: "+strconv.Itoa(int(s.Port))+"/udp")
+
+	lines = append(lines, s.Settings.Lines(indent, subSection)...)
+
+	return lines
+}
+
+func (s *ResolverSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"DNS over QUIC providers:")
+	for _, provider := range s.DoQProviders {
+		lines = append(lines, indent+subSection+provider.String())
+	}
+
+	lines = append(lines,
+		subSection+"Query timeout: "+s.Timeout.String())
+
+	connectOver := "IPv4"
+	if s.IPv6 {
+		connectOver = "IPv6"
+	}
+	lines = append(lines, subSection+"Connecting over: "+connectOver)
+
+	return lines
+}
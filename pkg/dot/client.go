@@ -0,0 +1,46 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/miekg/dns"
+)
+
+// Client is a DNS over TLS client exchanging DNS messages
+// with the DoT servers of the providers from its settings,
+// falling back on their plaintext DNS servers if set.
+type Client struct {
+	dial   dialFunc
+	client *dns.Client
+}
+
+// NewClient creates a DNS over TLS client.
+func NewClient(settings ResolverSettings) *Client {
+	settings.setDefaults()
+	return &Client{
+		dial:   newDoTDial(settings),
+		client: &dns.Client{},
+	}
+}
+
+// Exchange sends the DNS request to a DoT server picked pseudo-randomly
+// and returns its DNS response. It is safe to call concurrently.
+func (c *Client) Exchange(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, err error) {
+	DoTConn, err := c.dial(ctx, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial: %w", err)
+	}
+	conn := &dns.Conn{Conn: DoTConn}
+
+	response, _, err = c.client.ExchangeWithConn(request, conn)
+
+	_ = conn.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot exchange over DoT connection: %w", err)
+	}
+
+	return response, nil
+}
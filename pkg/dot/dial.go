@@ -3,8 +3,10 @@ package dot
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/qdm12/dns/pkg/provider"
 )
@@ -45,11 +47,15 @@ func newDoTDial(settings ResolverSettings) dialFunc {
 			return nil, err
 		}
 
-		tlsConf := &tls.Config{
-			MinVersion: tls.VersionTLS12,
-			ServerName: DoTServer.Name,
+		// TLS handshake errors, including certificate pin mismatches,
+		// are not worked around with the plain DNS fallback.
+		tlsConn := tls.Client(conn, DoTServer.TLSConfig())
+		_ = conn.SetDeadline(time.Now().Add(settings.Timeout))
+		if err := tlsConn.Handshake(); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("TLS handshake with %s: %w", tlsAddr, err)
 		}
-		// TODO handshake? See tls.DialWithDialer
-		return tls.Client(conn, tlsConf), nil
+		_ = conn.SetDeadline(time.Time{})
+		return tlsConn, nil
 	}
 }
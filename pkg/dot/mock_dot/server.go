@@ -9,6 +9,8 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	blacklist "github.com/qdm12/dns/pkg/blacklist"
+	localdata "github.com/qdm12/dns/pkg/localdata"
 )
 
 // MockServer is a mock of Server interface.
@@ -45,3 +47,27 @@ func (mr *MockServerMockRecorder) Run(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockServer)(nil).Run), arg0, arg1)
 }
+
+// SetBlacklist mocks base method.
+func (m *MockServer) SetBlacklist(arg0 blacklist.Settings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetBlacklist", arg0)
+}
+
+// SetBlacklist indicates an expected call of SetBlacklist.
+func (mr *MockServerMockRecorder) SetBlacklist(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlacklist", reflect.TypeOf((*MockServer)(nil).SetBlacklist), arg0)
+}
+
+// SetLocalData mocks base method.
+func (m *MockServer) SetLocalData(arg0 localdata.Settings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLocalData", arg0)
+}
+
+// SetLocalData indicates an expected call of SetLocalData.
+func (mr *MockServerMockRecorder) SetLocalData(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocalData", reflect.TypeOf((*MockServer)(nil).SetLocalData), arg0)
+}
@@ -5,16 +5,16 @@ import (
 	"strings"
 	"time"
 
-	"github.com/qdm12/dns/pkg/blacklist"
-	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/handler"
 	"github.com/qdm12/dns/pkg/provider"
 )
 
 type ServerSettings struct {
-	Resolver  ResolverSettings
-	Port      uint16
-	Cache     cache.Settings
-	Blacklist blacklist.Settings
+	Resolver ResolverSettings
+	Port     uint16
+	// Settings are the DNS handler settings,
+	// see pkg/handler/settings.go
+	handler.Settings
 }
 
 type ResolverSettings struct {
@@ -32,8 +32,7 @@ func (s *ServerSettings) setDefaults() {
 		s.Port = defaultPort
 	}
 
-	// Cache defaults to disabled, see pkg/cache/settings.go
-	s.Cache.SetDefaults()
+	s.Settings.SetDefaults()
 }
 
 func (s *ResolverSettings) setDefaults() {
@@ -69,15 +68,7 @@ func (s *ServerSettings) Lines(indent, subSection string) (lines []string) {
 	lines = append(lines,
 		subSection+"Listening port: "+strconv.Itoa(int(s.Port)))
 
-	lines = append(lines, subSection+"Caching:")
-	for _, line := range s.Cache.Lines(indent, subSection) {
-		lines = append(lines, indent+line)
-	}
-
-	lines = append(lines, subSection+"Blacklist:")
-	for _, line := range s.Blacklist.Lines(indent, subSection) {
-		lines = append(lines, indent+line)
-	}
+	lines = append(lines, s.Settings.Lines(indent, subSection)...)
 
 	return lines
 }
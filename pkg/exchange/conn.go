@@ -0,0 +1,209 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// DialFunc is the dial function type of a net.Resolver.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// NewDial returns a dial function for a net.Resolver, creating
+// connections exchanging DNS messages with the exchanger given.
+func NewDial(exchanger Exchanger) DialFunc {
+	return func(ctx context.Context, _, _ string) (conn net.Conn, err error) {
+		// Create connection object (no actual IO yet)
+		return NewConn(ctx, exchanger), nil
+	}
+}
+
+// NewConn returns a stream net.Conn adapter for the exchanger given,
+// such that it can be used by a net.Resolver. DNS messages written
+// are prefixed with their two bytes length, and each of them is
+// exchanged with the exchanger once the connection is read.
+// The exchanger is expected to manage its own connections to the
+// upstream servers, so creating a connection for each net.Resolver
+// dial does not create a new connection to an upstream server.
+func NewConn(ctx context.Context, exchanger Exchanger) net.Conn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &conn{
+		ctx:       ctx,
+		exchanger: exchanger,
+		inBuffer:  bytes.NewBuffer(nil),
+		outBuffer: bytes.NewBuffer(nil),
+		cancel:    cancel,
+	}
+}
+
+type conn struct {
+	// External objects injected at creation
+	ctx       context.Context
+	exchanger Exchanger
+
+	// Internals
+	mutex     sync.Mutex
+	inBuffer  *bytes.Buffer
+	outBuffer *bytes.Buffer
+	cancel    context.CancelFunc
+	deadline  time.Time
+	closed    bool
+}
+
+// lengthPrefix is the size of the message length prefix
+// of DNS messages over stream connections.
+const lengthPrefix = 2
+
+// Read reads the DNS responses to the queries written. If there is
+// no response left to read, the queries written since are exchanged
+// with the exchanger. It returns io.EOF if there is no query to exchange.
+func (c *conn) Read(b []byte) (n int, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	if c.outBuffer.Len() == 0 {
+		err = c.exchangeQueries()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return c.outBuffer.Read(b)
+}
+
+// exchangeQueries exchanges each complete query from the input
+// buffer with the exchanger, and writes their responses with
+// their length prefix to the output buffer.
+func (c *conn) exchangeQueries() (err error) {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	c.outBuffer.Reset()
+	for {
+		query := c.nextQuery()
+		if query == nil {
+			break
+		}
+
+		request := new(dns.Msg)
+		if err := request.Unpack(query); err != nil {
+			c.outBuffer.Reset()
+			return fmt.Errorf("cannot unpack DNS request: %w", err)
+		}
+
+		response, err := c.exchanger.Exchange(ctx, request)
+		if err != nil {
+			c.outBuffer.Reset()
+			return err
+		}
+
+		wire, err := response.Pack()
+		if err != nil {
+			c.outBuffer.Reset()
+			return fmt.Errorf("cannot pack DNS response: %w", err)
+		}
+
+		prefix := make([]byte, lengthPrefix)
+		binary.BigEndian.PutUint16(prefix, uint16(len(wire)))
+		_, _ = c.outBuffer.Write(prefix)
+		_, _ = c.outBuffer.Write(wire)
+	}
+
+	if c.outBuffer.Len() == 0 {
+		if c.inBuffer.Len() > 0 {
+			return io.ErrUnexpectedEOF
+		}
+		return io.EOF
+	}
+
+	return nil
+}
+
+// nextQuery removes the next complete query from the input buffer
+// and returns it without its length prefix, or returns nil if
+// there is no complete query in the input buffer.
+func (c *conn) nextQuery() (query []byte) {
+	in := c.inBuffer.Bytes()
+	if len(in) < lengthPrefix {
+		return nil
+	}
+
+	length := int(binary.BigEndian.Uint16(in))
+	if len(in) < lengthPrefix+length {
+		return nil
+	}
+
+	return c.inBuffer.Next(lengthPrefix + length)[lengthPrefix:]
+}
+
+// Write only writes the bytes to send in the connection
+// to a buffer. The exchanges are done in Read instead
+// such that response data can be read at the same time.
+func (c *conn) Write(b []byte) (n int, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	return c.inBuffer.Write(b)
+}
+
+// Close cancels any ongoing exchange. The connections
+// of the exchanger are left open for the next queries.
+func (c *conn) Close() error {
+	c.cancel()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+	return nil
+}
+
+// LocalAddr returns an empty address since the underlying
+// connections are managed by the exchanger.
+func (c *conn) LocalAddr() net.Addr {
+	return &net.UDPAddr{}
+}
+
+// RemoteAddr returns an empty address since the upstream
+// server is picked by the exchanger for each exchange.
+func (c *conn) RemoteAddr() net.Addr {
+	return &net.UDPAddr{}
+}
+
+func (c *conn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	// IO happens in read only so no timeout to set here
+	return nil
+}
@@ -0,0 +1,108 @@
+package exchange
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/exchange/mock_exchange"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prefixMessage(t *testing.T, message *dns.Msg) []byte {
+	t.Helper()
+	wire, err := message.Pack()
+	require.NoError(t, err)
+	prefixed := make([]byte, lengthPrefix+len(wire))
+	binary.BigEndian.PutUint16(prefixed, uint16(len(wire)))
+	copy(prefixed[lengthPrefix:], wire)
+	return prefixed
+}
+
+func Test_conn(t *testing.T) {
+	t.Parallel()
+
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	response := new(dns.Msg).SetReply(request)
+	errDummy := errors.New("dummy")
+
+	testCases := map[string]struct {
+		written     []byte
+		exchange    bool
+		exchangeErr error
+		read        []byte
+		err         error
+		errMsg      string
+	}{
+		"no query": {
+			read:   []byte{},
+			err:    io.EOF,
+			errMsg: "EOF",
+		},
+		"partial query": {
+			written: prefixMessage(t, request)[:5],
+			read:    []byte{},
+			err:     io.ErrUnexpectedEOF,
+			errMsg:  "unexpected EOF",
+		},
+		"exchange error": {
+			written:     prefixMessage(t, request),
+			exchange:    true,
+			exchangeErr: errDummy,
+			read:        []byte{},
+			err:         errDummy,
+			errMsg:      "dummy",
+		},
+		"success": {
+			written:  prefixMessage(t, request),
+			exchange: true,
+			read:     prefixMessage(t, response),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			exchanger := mock_exchange.NewMockExchanger(ctrl)
+			if testCase.exchange {
+				exchanger.EXPECT().Exchange(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, r *dns.Msg) (*dns.Msg, error) {
+						assert.Equal(t, request.Question, r.Question)
+						assert.Equal(t, request.Id, r.Id)
+						if testCase.exchangeErr != nil {
+							return nil, testCase.exchangeErr
+						}
+						return response, nil
+					})
+			}
+
+			conn := NewConn(context.Background(), exchanger)
+
+			_, err := conn.Write(testCase.written)
+			require.NoError(t, err)
+
+			buffer := make([]byte, dns.MaxMsgSize)
+			n, err := conn.Read(buffer)
+
+			assert.ErrorIs(t, err, testCase.err)
+			if testCase.err != nil {
+				assert.EqualError(t, err, testCase.errMsg)
+			}
+			assert.Equal(t, testCase.read, buffer[:n:n])
+
+			err = conn.Close()
+			require.NoError(t, err)
+			_, err = conn.Read(buffer)
+			assert.ErrorIs(t, err, net.ErrClosed)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// Package exchange defines the Exchanger interface to exchange DNS
+// messages with upstream servers, implemented by the plain DNS,
+// DNS over TLS and DNS over HTTPS clients. It also provides the
+// net.Conn adapter for exchangers to be used by a net.Resolver,
+// and the picker of upstream servers shared by the clients.
+package exchange
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Exchanger
+
+// Exchanger exchanges DNS messages with upstream servers.
+type Exchanger interface {
+	// Exchange sends the request to an upstream server and returns
+	// its response. It must be safe to call concurrently.
+	Exchange(ctx context.Context, request *dns.Msg) (response *dns.Msg, err error)
+}
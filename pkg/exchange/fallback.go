@@ -0,0 +1,41 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+var ErrNoExchanger = errors.New("no exchanger")
+
+type fallback struct {
+	exchangers []Exchanger
+}
+
+// NewFallback returns an exchanger trying each of the exchangers given
+// in order until one of them succeeds, for example to use DNS over HTTPS
+// with DNS over TLS as backup. Certificate pin mismatch errors are returned
+// right away, since these can indicate a man in the middle attack.
+func NewFallback(exchangers ...Exchanger) Exchanger {
+	return &fallback{
+		exchangers: exchangers,
+	}
+}
+
+func (f *fallback) Exchange(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, err error) {
+	err = ErrNoExchanger
+	for i, exchanger := range f.exchangers {
+		response, err = exchanger.Exchange(ctx, request)
+		if err == nil {
+			return response, nil
+		} else if errors.Is(err, provider.ErrPinMismatch) {
+			return nil, err
+		}
+		err = fmt.Errorf("exchanger %d of %d: %w", i+1, len(f.exchangers), err)
+	}
+	return nil, err
+}
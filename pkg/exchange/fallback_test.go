@@ -0,0 +1,89 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/exchange/mock_exchange"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fallback_Exchange(t *testing.T) {
+	t.Parallel()
+
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	response := new(dns.Msg).SetReply(request)
+	errDummy := errors.New("dummy")
+
+	type exchangeCall struct {
+		response *dns.Msg
+		err      error
+	}
+
+	testCases := map[string]struct {
+		calls    []exchangeCall
+		response *dns.Msg
+		err      error
+		errMsg   string
+	}{
+		"no exchanger": {
+			err:    ErrNoExchanger,
+			errMsg: "no exchanger",
+		},
+		"first succeeds": {
+			calls:    []exchangeCall{{response: response}},
+			response: response,
+		},
+		"second succeeds": {
+			calls:    []exchangeCall{{err: errDummy}, {response: response}},
+			response: response,
+		},
+		"all fail": {
+			calls:  []exchangeCall{{err: errDummy}, {err: errDummy}},
+			err:    errDummy,
+			errMsg: "exchanger 2 of 2: dummy",
+		},
+		"pin mismatch": {
+			calls:  []exchangeCall{{err: provider.ErrPinMismatch}},
+			err:    provider.ErrPinMismatch,
+			errMsg: "certificate pin mismatch",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			exchangers := make([]Exchanger, len(testCase.calls))
+			for i, call := range testCase.calls {
+				exchanger := mock_exchange.NewMockExchanger(ctrl)
+				exchanger.EXPECT().Exchange(ctx, request).Return(call.response, call.err)
+				exchangers[i] = exchanger
+			}
+			if testCase.err == provider.ErrPinMismatch {
+				// the next exchanger must not be called
+				exchangers = append(exchangers, mock_exchange.NewMockExchanger(ctrl))
+			}
+
+			fallback := NewFallback(exchangers...)
+
+			response, err := fallback.Exchange(ctx, request)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.EqualError(t, err, testCase.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.response, response)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package exchange
+
+import (
+	"math/rand"
+	"net"
+
+	"github.com/qdm12/golibs/crypto/random/sources/maphash"
+)
+
+// Picker is a fast thread safe pseudo-random picker of upstream
+// servers and of their IP addresses.
+type Picker struct {
+	rand *rand.Rand
+}
+
+// NewPicker creates a pseudo-random picker.
+func NewPicker() *Picker {
+	source := maphash.New()
+	return &Picker{
+		rand: rand.New(source), //nolint:gosec
+	}
+}
+
+// Index returns a pseudo-random index for a slice of the length
+// given, and 0 if the length is 0 or 1.
+func (p *Picker) Index(length int) (index int) {
+	if length > 1 {
+		index = p.rand.Intn(length)
+	}
+	return index
+}
+
+// IP returns one of the IP addresses given, or nil if there is none.
+func (p *Picker) IP(ips []net.IP) net.IP {
+	if len(ips) == 0 {
+		return nil
+	}
+	return ips[p.Index(len(ips))]
+}
+
+// ServerIP returns one of the IPv6 addresses given if preferIPv6 is true,
+// and otherwise or if there is none, one of the IPv4 addresses given,
+// falling back on the IPv6 addresses. It returns nil if there is none.
+func (p *Picker) ServerIP(ipv4, ipv6 []net.IP, preferIPv6 bool) net.IP {
+	if preferIPv6 {
+		if ip := p.IP(ipv6); ip != nil {
+			return ip
+		}
+		// if there is no IPv6, fall back to an IPv4 address.
+	}
+	if ip := p.IP(ipv4); ip != nil {
+		return ip
+	}
+	return p.IP(ipv6)
+}
@@ -0,0 +1,57 @@
+package exchange
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Picker_ServerIP(t *testing.T) {
+	t.Parallel()
+
+	ipv4 := []net.IP{{1, 1, 1, 1}}
+	ipv6 := []net.IP{net.ParseIP("2606:4700:4700::1111")}
+
+	testCases := map[string]struct {
+		ipv4       []net.IP
+		ipv6       []net.IP
+		preferIPv6 bool
+		ip         net.IP
+	}{
+		"no IP": {},
+		"IPv4": {
+			ipv4: ipv4,
+			ipv6: ipv6,
+			ip:   ipv4[0],
+		},
+		"IPv6 preferred": {
+			ipv4:       ipv4,
+			ipv6:       ipv6,
+			preferIPv6: true,
+			ip:         ipv6[0],
+		},
+		"IPv4 fallback": {
+			ipv4:       ipv4,
+			preferIPv6: true,
+			ip:         ipv4[0],
+		},
+		"IPv6 fallback": {
+			ipv6: ipv6,
+			ip:   ipv6[0],
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			picker := NewPicker()
+
+			ip := picker.ServerIP(testCase.ipv4, testCase.ipv6, testCase.preferIPv6)
+
+			assert.Equal(t, testCase.ip, ip)
+		})
+	}
+}
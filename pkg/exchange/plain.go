@@ -0,0 +1,76 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+type PlainSettings struct {
+	DNSProviders []provider.Provider
+	// Timeout is the timeout for each query,
+	// and defaults to 5 seconds.
+	Timeout time.Duration
+	// IPv6 makes the IPv6 addresses of the servers
+	// be tried before their IPv4 addresses.
+	IPv6 bool
+}
+
+func (s *PlainSettings) SetDefaults() {
+	if s.Timeout == 0 {
+		const defaultTimeout = 5 * time.Second
+		s.Timeout = defaultTimeout
+	}
+}
+
+var ErrNoServer = errors.New("no DNS server")
+
+type plain struct {
+	addresses []string
+	udpClient *dns.Client
+	tcpClient *dns.Client
+}
+
+// NewPlain returns an exchanger for the plaintext DNS servers of the
+// providers given, trying each of their addresses in order until one
+// answers. Truncated UDP responses are retried over TCP.
+func NewPlain(settings PlainSettings) Exchanger {
+	settings.SetDefaults()
+
+	var addresses []string
+	for _, dnsProvider := range settings.DNSProviders {
+		server := dnsProvider.DNS()
+		ips := append(append([]net.IP{}, server.IPv4...), server.IPv6...)
+		if settings.IPv6 {
+			ips = append(append([]net.IP{}, server.IPv6...), server.IPv4...)
+		}
+		for _, ip := range ips {
+			addresses = append(addresses, net.JoinHostPort(ip.String(), "53"))
+		}
+	}
+
+	return &plain{
+		addresses: addresses,
+		udpClient: &dns.Client{Net: "udp", Timeout: settings.Timeout},
+		tcpClient: &dns.Client{Net: "tcp", Timeout: settings.Timeout},
+	}
+}
+
+func (p *plain) Exchange(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, err error) {
+	err = ErrNoServer
+	for _, address := range p.addresses {
+		response, _, err = p.udpClient.ExchangeContext(ctx, request, address)
+		if err == nil && response.Truncated {
+			response, _, err = p.tcpClient.ExchangeContext(ctx, request, address)
+		}
+		if err == nil {
+			return response, nil
+		}
+	}
+	return nil, err
+}
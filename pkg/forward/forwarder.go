@@ -0,0 +1,178 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Forwarder
+
+// Forwarder forwards queries for the domains of its zones.
+type Forwarder interface {
+	// Forward exchanges the request with the upstream servers of the zone
+	// with the longest domain suffix matching the request question name.
+	// It returns forwarded as false if no zone matches the request.
+	Forward(ctx context.Context, request *dns.Msg) (
+		response *dns.Msg, forwarded bool, err error)
+}
+
+type forwarder struct {
+	// fqdnDomainToZone maps each lowercase fully
+	// qualified domain name to its zone.
+	fqdnDomainToZone map[string]*zone
+}
+
+type zone struct {
+	settings   Zone
+	udpClient  *dns.Client
+	tcpClient  *dns.Client
+	httpClient *http.Client
+}
+
+func NewForwarder(settings Settings) Forwarder {
+	settings.SetDefaults()
+
+	fqdnDomainToZone := make(map[string]*zone)
+	for _, zoneSettings := range settings.Zones {
+		z := &zone{
+			settings:   zoneSettings,
+			udpClient:  &dns.Client{Net: "udp", Timeout: settings.Timeout},
+			tcpClient:  &dns.Client{Net: "tcp", Timeout: settings.Timeout},
+			httpClient: &http.Client{Timeout: settings.Timeout},
+		}
+		if zoneSettings.Protocol == DoT {
+			z.tcpClient.Net = "tcp-tls"
+			z.tcpClient.TLSConfig = &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				ServerName: zoneSettings.TLSName,
+			}
+		}
+		for _, fqdnDomain := range zoneSettings.FqdnDomains {
+			fqdnDomainToZone[strings.ToLower(dns.Fqdn(fqdnDomain))] = z
+		}
+	}
+
+	return &forwarder{
+		fqdnDomainToZone: fqdnDomainToZone,
+	}
+}
+
+func (f *forwarder) Forward(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, forwarded bool, err error) {
+	if len(request.Question) == 0 || len(f.fqdnDomainToZone) == 0 {
+		return nil, false, nil
+	}
+
+	z := f.match(request.Question[0].Name)
+	if z == nil {
+		return nil, false, nil
+	}
+
+	response, err = z.exchange(ctx, request)
+	if err != nil {
+		return nil, true, fmt.Errorf("cannot forward to zone %s: %w", z.settings.Name, err)
+	}
+	return response, true, nil
+}
+
+// match returns the zone with the longest domain suffix
+// matching the name given, or nil if no zone matches.
+func (f *forwarder) match(name string) (z *zone) {
+	name = strings.ToLower(dns.Fqdn(name))
+	for {
+		if z, ok := f.fqdnDomainToZone[name]; ok {
+			return z
+		}
+		i := strings.IndexByte(name, '.')
+		if i == -1 || i == len(name)-1 {
+			return nil
+		}
+		name = name[i+1:]
+	}
+}
+
+var ErrNoUpstream = errors.New("no upstream server")
+
+// exchange exchanges the request with the first upstream server of
+// the zone answering it, trying the upstream servers in order.
+func (z *zone) exchange(ctx context.Context, request *dns.Msg) (response *dns.Msg, err error) {
+	if z.settings.Protocol == DoH {
+		return z.exchangeDoH(ctx, request)
+	}
+
+	err = ErrNoUpstream
+	for _, upstream := range z.settings.Upstreams {
+		response, err = z.exchangeDNS(ctx, request, upstream.String())
+		if err == nil {
+			return response, nil
+		}
+	}
+	return nil, err
+}
+
+// exchangeDNS exchanges the request over UDP for the plain DNS protocol,
+// retrying over TCP if the response is truncated, or over TLS for the
+// DNS over TLS protocol.
+func (z *zone) exchangeDNS(ctx context.Context, request *dns.Msg, address string) (
+	response *dns.Msg, err error) {
+	if z.settings.Protocol == Plain {
+		response, _, err = z.udpClient.ExchangeContext(ctx, request, address)
+		if err != nil || !response.Truncated {
+			return response, err
+		}
+	}
+	response, _, err = z.tcpClient.ExchangeContext(ctx, request, address)
+	return response, err
+}
+
+var ErrHTTPStatus = errors.New("bad HTTP status")
+
+func (z *zone) exchangeDoH(ctx context.Context, request *dns.Msg) (response *dns.Msg, err error) {
+	if z.settings.URL == nil {
+		return nil, ErrNoUpstream
+	}
+
+	wire, err := request.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		z.settings.URL.String(), bytes.NewReader(wire))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/dns-message")
+	httpRequest.Header.Set("Accept", "application/dns-message")
+
+	httpResponse, err := z.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrHTTPStatus, httpResponse.Status)
+	}
+
+	const maxMessageSize = 65535
+	wire, err = io.ReadAll(io.LimitReader(httpResponse.Body, maxMessageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	response = new(dns.Msg)
+	err = response.Unpack(wire)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,145 @@
+package forward
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_forwarder_match(t *testing.T) {
+	t.Parallel()
+
+	corp := Zone{Name: "corp", FqdnDomains: []string{"corp.example."}}
+	lab := Zone{Name: "lab", FqdnDomains: []string{"lab.corp.example.", "arpa."}}
+	f := NewForwarder(Settings{Zones: []Zone{corp, lab}}).(*forwarder)
+
+	testCases := map[string]struct {
+		name string
+		zone string
+	}{
+		"no match":          {name: "github.com."},
+		"root":              {name: "."},
+		"domain":            {name: "corp.example.", zone: "corp"},
+		"subdomain":         {name: "host.corp.example.", zone: "corp"},
+		"longest suffix":    {name: "host.lab.corp.example.", zone: "lab"},
+		"case insensitive":  {name: "Host.CORP.example.", zone: "corp"},
+		"not fqdn":          {name: "host.corp.example", zone: "corp"},
+		"top level domain":  {name: "1.1.168.192.in-addr.arpa.", zone: "lab"},
+		"label suffix only": {name: "notcorp.example."},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			z := f.match(testCase.name)
+			if testCase.zone == "" {
+				assert.Nil(t, z)
+				return
+			}
+			require.NotNil(t, z)
+			assert.Equal(t, testCase.zone, z.settings.Name)
+		})
+	}
+}
+
+func answerWith(ip net.IP) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, r *dns.Msg) {
+		response := new(dns.Msg).SetReply(r)
+		response.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   ip,
+		}}
+		_ = w.WriteMsg(response)
+	}
+}
+
+func Test_forwarder_Forward(t *testing.T) {
+	t.Parallel()
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	dnsServer := &dns.Server{
+		PacketConn:        packetConn,
+		Handler:           answerWith(net.IP{10, 0, 0, 1}),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = dnsServer.ActivateAndServe() }()
+	t.Cleanup(func() { _ = dnsServer.Shutdown() })
+	<-started
+	udpAddr := packetConn.LocalAddr().(*net.UDPAddr)
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wire, _ := io.ReadAll(r.Body)
+		request := new(dns.Msg)
+		_ = request.Unpack(wire)
+		response := new(dns.Msg).SetReply(request)
+		response.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: request.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.IP{10, 0, 0, 2},
+		}}
+		wire, _ = response.Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(wire)
+	}))
+	t.Cleanup(httpServer.Close)
+	dohURL, err := url.Parse(httpServer.URL + "/dns-query")
+	require.NoError(t, err)
+
+	f := NewForwarder(Settings{
+		Zones: []Zone{
+			{
+				Name:        "plain",
+				FqdnDomains: []string{"plain.example."},
+				Protocol:    Plain,
+				Upstreams: []Upstream{{
+					IP:   netaddr.IPv4(127, 0, 0, 1),
+					Port: uint16(udpAddr.Port),
+				}},
+			},
+			{
+				Name:        "doh",
+				FqdnDomains: []string{"doh.example."},
+				Protocol:    DoH,
+				URL:         dohURL,
+			},
+		},
+	})
+
+	testCases := map[string]struct {
+		name      string
+		forwarded bool
+		ip        net.IP
+	}{
+		"not forwarded": {name: "github.com."},
+		"plain":         {name: "host.plain.example.", forwarded: true, ip: net.IP{10, 0, 0, 1}},
+		"doh":           {name: "host.doh.example.", forwarded: true, ip: net.IP{10, 0, 0, 2}},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			request := new(dns.Msg).SetQuestion(testCase.name, dns.TypeA)
+
+			response, forwarded, err := f.Forward(context.Background(), request)
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.forwarded, forwarded)
+			if !testCase.forwarded {
+				assert.Nil(t, response)
+				return
+			}
+			require.Len(t, response.Answer, 1)
+			assert.Equal(t, testCase.ip.String(), response.Answer[0].(*dns.A).A.String())
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/forward (interfaces: Forwarder)
+
+// Package mock_forward is a generated GoMock package.
+package mock_forward
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dns "github.com/miekg/dns"
+)
+
+// MockForwarder is a mock of Forwarder interface.
+type MockForwarder struct {
+	ctrl     *gomock.Controller
+	recorder *MockForwarderMockRecorder
+}
+
+// MockForwarderMockRecorder is the mock recorder for MockForwarder.
+type MockForwarderMockRecorder struct {
+	mock *MockForwarder
+}
+
+// NewMockForwarder creates a new mock instance.
+func NewMockForwarder(ctrl *gomock.Controller) *MockForwarder {
+	mock := &MockForwarder{ctrl: ctrl}
+	mock.recorder = &MockForwarderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockForwarder) EXPECT() *MockForwarderMockRecorder {
+	return m.recorder
+}
+
+// Forward mocks base method.
+func (m *MockForwarder) Forward(arg0 context.Context, arg1 *dns.Msg) (*dns.Msg, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Forward", arg0, arg1)
+	ret0, _ := ret[0].(*dns.Msg)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Forward indicates an expected call of Forward.
+func (mr *MockForwarderMockRecorder) Forward(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forward", reflect.TypeOf((*MockForwarder)(nil).Forward), arg0, arg1)
+}
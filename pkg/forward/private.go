@@ -0,0 +1,33 @@
+package forward
+
+import "strings"
+
+// privateFqdnDomains are special use domains and reverse zones of private
+// IP networks, which are not delegated in the public DNS and so cannot be
+// validated with DNSSEC.
+var privateFqdnDomains = []string{ //nolint:gochecknoglobals
+	"home.arpa.", "local.", "lan.", "internal.", "localdomain.",
+	"home.", "corp.", "private.", "intranet.", "test.", "invalid.",
+	"10.in-addr.arpa.", "168.192.in-addr.arpa.", "254.169.in-addr.arpa.",
+	"16.172.in-addr.arpa.", "17.172.in-addr.arpa.", "18.172.in-addr.arpa.",
+	"19.172.in-addr.arpa.", "20.172.in-addr.arpa.", "21.172.in-addr.arpa.",
+	"22.172.in-addr.arpa.", "23.172.in-addr.arpa.", "24.172.in-addr.arpa.",
+	"25.172.in-addr.arpa.", "26.172.in-addr.arpa.", "27.172.in-addr.arpa.",
+	"28.172.in-addr.arpa.", "29.172.in-addr.arpa.", "30.172.in-addr.arpa.",
+	"31.172.in-addr.arpa.",
+	"c.f.ip6.arpa.", "d.f.ip6.arpa.",
+	"8.e.f.ip6.arpa.", "9.e.f.ip6.arpa.", "a.e.f.ip6.arpa.", "b.e.f.ip6.arpa.",
+}
+
+// IsPrivateDomain returns true if the fully qualified domain name given
+// is or is a subdomain of a special use domain or of a reverse zone of a
+// private IP network, such as home.arpa. or 168.192.in-addr.arpa.
+func IsPrivateDomain(fqdnDomain string) bool {
+	fqdnDomain = strings.ToLower(fqdnDomain)
+	for _, privateDomain := range privateFqdnDomains {
+		if fqdnDomain == privateDomain || strings.HasSuffix(fqdnDomain, "."+privateDomain) {
+			return true
+		}
+	}
+	return false
+}
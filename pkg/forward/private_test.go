@@ -0,0 +1,53 @@
+package forward
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsPrivateDomain(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		fqdnDomain string
+		private    bool
+	}{
+		"special use domain": {
+			fqdnDomain: "home.arpa.",
+			private:    true,
+		},
+		"special use subdomain": {
+			fqdnDomain: "NAS.Lan.",
+			private:    true,
+		},
+		"private reverse zone": {
+			fqdnDomain: "1.168.192.in-addr.arpa.",
+			private:    true,
+		},
+		"private IPv6 reverse zone": {
+			fqdnDomain: "d.f.ip6.arpa.",
+			private:    true,
+		},
+		"public domain": {
+			fqdnDomain: "example.com.",
+		},
+		"public domain with private suffix label": {
+			fqdnDomain: "mylan.",
+		},
+		"public reverse zone": {
+			fqdnDomain: "32.172.in-addr.arpa.",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			private := IsPrivateDomain(testCase.fqdnDomain)
+
+			assert.Equal(t, testCase.private, private)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package forward
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Protocol is the protocol used to forward queries to upstream servers.
+type Protocol string
+
+const (
+	Plain Protocol = "plain"
+	DoT   Protocol = "dot"
+	DoH   Protocol = "doh"
+)
+
+func ListProtocols() (protocols []Protocol) {
+	return []Protocol{
+		Plain,
+		DoT,
+		DoH,
+	}
+}
+
+var ErrParseProtocol = errors.New("cannot parse forwarding protocol")
+
+func ParseProtocol(s string) (protocol Protocol, err error) {
+	for _, p := range ListProtocols() {
+		if strings.EqualFold(string(p), s) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is unknown", ErrParseProtocol, s)
+}
@@ -0,0 +1,126 @@
+package forward
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"inet.af/netaddr"
+)
+
+type Settings struct {
+	// Zones are the forwarding zones, where the zone with the longest
+	// domain suffix matching the query name is used.
+	Zones []Zone
+	// Timeout is the timeout for each upstream query,
+	// and defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// Zone forwards queries for its domains and their subdomains to
+// its upstream servers. Since internal domains usually resolve to
+// private IP addresses, the domains should also be allowed to resolve
+// to private IP addresses.
+type Zone struct {
+	// Name identifies the zone in logs and settings.
+	Name string
+	// FqdnDomains are the fully qualified domain names
+	// forwarded, such as corp.example. or 168.192.in-addr.arpa.
+	FqdnDomains []string
+	Protocol    Protocol
+	// Upstreams are the upstream servers addresses,
+	// for the plain DNS and DNS over TLS protocols.
+	Upstreams []Upstream
+	// TLSName is the TLS server name of the DNS over TLS upstream servers.
+	TLSName string
+	// URL is the URL of the DNS over HTTPS upstream server.
+	URL *url.URL
+	// Authoritative is true if the upstream servers are authoritative
+	// for the domains instead of being recursive resolvers.
+	Authoritative bool
+	// Insecure is true if the domains are not signed and must not be
+	// validated with DNSSEC by Unbound, which also answers them instead
+	// of its default local zones such as home.arpa. This is usually the
+	// case for private domains, see IsPrivateDomain.
+	Insecure bool
+}
+
+type Upstream struct {
+	IP   netaddr.IP
+	Port uint16
+}
+
+func (u Upstream) String() string {
+	return net.JoinHostPort(u.IP.String(), strconv.Itoa(int(u.Port)))
+}
+
+func (s *Settings) SetDefaults() {
+	if s.Timeout == 0 {
+		const defaultTimeout = 5 * time.Second
+		s.Timeout = defaultTimeout
+	}
+}
+
+// FqdnDomains returns the domains of all the zones.
+func (s *Settings) FqdnDomains() (fqdnDomains []string) {
+	for _, zone := range s.Zones {
+		fqdnDomains = append(fqdnDomains, zone.FqdnDomains...)
+	}
+	return fqdnDomains
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	for _, zone := range s.Zones {
+		lines = append(lines, subSection+zone.Name+":")
+		for _, line := range zone.Lines(indent, subSection) {
+			lines = append(lines, indent+line)
+		}
+	}
+
+	if len(s.Zones) > 0 {
+		lines = append(lines, subSection+"Query timeout: "+s.Timeout.String())
+	}
+
+	return lines
+}
+
+func (z *Zone) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Domains: "+strings.Join(z.FqdnDomains, ", "))
+	lines = append(lines, subSection+"Protocol: "+string(z.Protocol))
+
+	switch z.Protocol {
+	case Plain, DoT:
+		upstreams := make([]string, len(z.Upstreams))
+		for i, upstream := range z.Upstreams {
+			upstreams[i] = upstream.String()
+		}
+		lines = append(lines, subSection+"Upstreams: "+strings.Join(upstreams, ", "))
+		if z.Protocol == DoT {
+			lines = append(lines, subSection+"TLS name: "+z.TLSName)
+		}
+	case DoH:
+		if z.URL != nil {
+			lines = append(lines, subSection+"URL: "+z.URL.String())
+		}
+	}
+
+	if z.Authoritative {
+		lines = append(lines, subSection+"Authoritative upstreams: yes")
+	}
+
+	if z.Insecure {
+		lines = append(lines, subSection+"DNSSEC validation: disabled")
+	}
+
+	return lines
+}
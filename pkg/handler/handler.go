@@ -0,0 +1,239 @@
+// Package handler implements the DNS handler shared by the DNS servers,
+// answering requests through its access control, local data, blacklist,
+// safe search and cache stages before exchanging them upstream, and
+// filtering, validating and caching the responses.
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/accesscontrol"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/dns/pkg/dnssec"
+	"github.com/qdm12/dns/pkg/exchange"
+	"github.com/qdm12/dns/pkg/forward"
+	"github.com/qdm12/dns/pkg/localdata"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/safesearch"
+	"github.com/qdm12/golibs/logging"
+	"inet.af/netaddr"
+)
+
+// Handler is a DNS handler exchanging requests with its
+// exchanger, which can use any upstream transport.
+type Handler struct {
+	// External objects
+	ctx       context.Context
+	logger    logging.Logger
+	exchanger exchange.Exchanger
+
+	// Internal objects
+	cache  cache.Cache
+	blist  atomic.Value // blacklist.Selector
+	safe   *safesearch.Rewriter
+	access accesscontrol.Checker
+	fwd    forward.Forwarder
+	local  atomic.Value // localdata.Answerer
+	dns64  *dns64.Synthesizer
+	dnssec *dnssec.Validator
+}
+
+// New creates a DNS handler exchanging requests upstream with the
+// exchanger given, for example a DNS over TLS or DNS over HTTPS client.
+// It returns an error if the settings are invalid, see Settings.Validate.
+func New(ctx context.Context, logger logging.Logger,
+	exchanger exchange.Exchanger, settings Settings) (*Handler, error) {
+	settings.SetDefaults()
+	err := settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handler{
+		ctx:       ctx,
+		logger:    logger,
+		exchanger: exchanger,
+		cache:     cache.New(settings.Cache), // defaults to NOOP
+		safe:      safesearch.NewRewriter(),
+		access:    accesscontrol.NewChecker(settings.AccessControl),
+		fwd:       forward.NewForwarder(settings.Forward),
+		dns64:     dns64.NewSynthesizer(settings.DNS64),
+	}
+	h.dnssec = dnssec.NewValidator(settings.DNSSEC, h.exchange)
+	h.SetBlacklist(settings.Blacklist)
+	h.SetLocalData(settings.LocalData)
+	return h, nil
+}
+
+// SetBlacklist swaps the blacklist used by the handler,
+// and is safe to call while the handler serves requests.
+func (h *Handler) SetBlacklist(settings blacklist.Settings) {
+	h.blist.Store(blacklist.NewSelector(settings))
+}
+
+// SetLocalData swaps the local records answered by the handler,
+// and is safe to call while the handler serves requests.
+func (h *Handler) SetLocalData(settings localdata.Settings) {
+	h.local.Store(localdata.NewAnswerer(settings))
+}
+
+func (h *Handler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	clientIP := getClientIP(w.RemoteAddr())
+
+	action := h.access.Action(clientIP)
+	if !accesscontrol.Answers(action, r) {
+		if action == accesscontrol.Deny {
+			return
+		}
+		response := new(dns.Msg).SetRcode(r, dns.RcodeRefused)
+		if err := w.WriteMsg(response); err != nil {
+			h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+		}
+		return
+	}
+
+	answerer := h.local.Load().(localdata.Answerer)
+	if response := answerer.Answer(r); response != nil {
+		if err := w.WriteMsg(response); err != nil {
+			h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+		}
+		return
+	}
+
+	selector := h.blist.Load().(blacklist.Selector)
+	blist, safeSearch := selector.Select(clientIP, r)
+
+	if blist.FilterRequest(r) {
+		response := new(dns.Msg).SetRcode(r, dns.RcodeRefused)
+		if err := w.WriteMsg(response); err != nil {
+			h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+		}
+		return
+	}
+
+	// request is the request sent upstream and cached, which differs
+	// from the client request r if it carries the MAC address of the
+	// client, which must not leak upstream, or if it is rewritten for
+	// safe search.
+	request := blacklist.RemoveMAC(r)
+	var cname *dns.CNAME
+	if safeSearch {
+		request, cname = h.safe.Rewrite(request)
+	}
+
+	if h.cache != nil {
+		if response := h.cache.Get(request); response != nil {
+			// The cache is shared by all client groups, so the cached
+			// response is filtered with the blacklist of the client.
+			if blist.FilterResponse(response) {
+				response := new(dns.Msg).SetRcode(r, dns.RcodeRefused)
+				if err := w.WriteMsg(response); err != nil {
+					h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+				}
+				return
+			}
+			if cname != nil {
+				response.Answer = append([]dns.RR{cname}, response.Answer...)
+			}
+			response.SetReply(r)
+			h.dnssec.AdaptResponse(r, response)
+			if err := w.WriteMsg(response); err != nil {
+				h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+			}
+			return
+		}
+	}
+
+	response, err := h.resolve(request)
+	if err != nil {
+		if errors.Is(err, provider.ErrPinMismatch) {
+			h.logger.Error("possible man in the middle attack: " + err.Error())
+		} else {
+			h.logger.Warn(err.Error())
+		}
+		_ = w.WriteMsg(new(dns.Msg).SetRcode(r, dns.RcodeServerFailure))
+		return
+	}
+
+	if h.dns64.Needed(request, response) {
+		aResponse, err := h.resolve(dns64.ARequest(request))
+		if err != nil {
+			h.logger.Warn("cannot synthesize DNS64 response: " + err.Error())
+		} else if !blist.FilterResponse(aResponse) {
+			if synthesized := h.dns64.Synthesize(request, aResponse); synthesized != nil {
+				response = synthesized
+			}
+		}
+	}
+
+	if blist.FilterResponse(response) {
+		response := new(dns.Msg).SetRcode(r, dns.RcodeRefused)
+		if err := w.WriteMsg(response); err != nil {
+			h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+		}
+		return
+	}
+
+	if h.cache != nil {
+		h.cache.Add(request, response)
+	}
+
+	if cname != nil {
+		response.Answer = append([]dns.RR{cname}, response.Answer...)
+	}
+	response.SetReply(r)
+	h.dnssec.AdaptResponse(r, response)
+	if err := w.WriteMsg(response); err != nil {
+		h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+	}
+}
+
+// resolve forwards the request if it matches a forwarding zone,
+// and exchanges it with the exchanger otherwise, in which case
+// the response is validated if DNSSEC validation is enabled.
+func (h *Handler) resolve(request *dns.Msg) (response *dns.Msg, err error) {
+	response, forwarded, err := h.fwd.Forward(h.ctx, request)
+	if forwarded {
+		return response, err
+	}
+
+	upstreamRequest := h.dnssec.Prepare(request)
+	response, err = h.exchange(h.ctx, upstreamRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.dnssec.Validate(h.ctx, upstreamRequest, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// exchange exchanges the request with the exchanger.
+func (h *Handler) exchange(ctx context.Context, request *dns.Msg) (
+	response *dns.Msg, err error) {
+	response, err = h.exchanger.Exchange(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot exchange: %w", err)
+	}
+	return response, nil
+}
+
+func getClientIP(address net.Addr) (ip netaddr.IP) {
+	switch addr := address.(type) {
+	case *net.UDPAddr:
+		ip, _ = netaddr.FromStdIP(addr.IP)
+	case *net.TCPAddr:
+		ip, _ = netaddr.FromStdIP(addr.IP)
+	}
+	return ip
+}
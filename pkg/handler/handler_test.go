@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/dns/pkg/exchange/mock_exchange"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/golibs/logging/mock_logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+type testResponseWriter struct {
+	dns.ResponseWriter
+	responses []*dns.Msg
+}
+
+func (w *testResponseWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IP{127, 0, 0, 1}, Port: 5353}
+}
+
+func (w *testResponseWriter) WriteMsg(response *dns.Msg) error {
+	w.responses = append(w.responses, response)
+	return nil
+}
+
+func Test_Handler_ServeDNS(t *testing.T) {
+	t.Parallel()
+
+	const macOptionCode = 65001
+	newRequest := func() *dns.Msg {
+		request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+		request.SetEdns0(dns.DefaultMsgSize, false)
+		opt := request.IsEdns0()
+		opt.Option = append(opt.Option, &dns.EDNS0_LOCAL{
+			Code: macOptionCode,
+			Data: []byte{1, 2, 3, 4, 5, 6},
+		})
+		return request
+	}
+	newResponse := func() *dns.Msg {
+		response := new(dns.Msg).SetReply(newRequest())
+		response.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.IP{1, 2, 3, 4},
+		}}
+		return response
+	}
+	errDummy := errors.New("dummy")
+
+	testCases := map[string]struct {
+		settings      Settings
+		requests      int
+		exchangeCalls int
+		exchangeErr   error
+		warn          string
+		errorLog      string
+		rcode         int
+		answers       int
+	}{
+		"exchanged": {
+			requests:      1,
+			exchangeCalls: 1,
+			rcode:         dns.RcodeSuccess,
+			answers:       1,
+		},
+		"exchange error": {
+			requests:      1,
+			exchangeCalls: 1,
+			exchangeErr:   errDummy,
+			warn:          "cannot exchange: dummy",
+			rcode:         dns.RcodeServerFailure,
+		},
+		"certificate pin mismatch": {
+			requests:      1,
+			exchangeCalls: 1,
+			exchangeErr:   provider.ErrPinMismatch,
+			errorLog:      "possible man in the middle attack: cannot exchange: certificate pin mismatch",
+			rcode:         dns.RcodeServerFailure,
+		},
+		"blacklisted": {
+			settings: Settings{
+				Blacklist: blacklist.Settings{FqdnHostnames: []string{"example.com."}},
+			},
+			requests: 1,
+			rcode:    dns.RcodeRefused,
+		},
+		"cached": {
+			settings: Settings{
+				Cache: cache.Settings{Type: cache.LRU},
+			},
+			requests:      2,
+			exchangeCalls: 1,
+			rcode:         dns.RcodeSuccess,
+			answers:       1,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			logger := mock_logging.NewMockLogger(ctrl)
+			if testCase.warn != "" {
+				logger.EXPECT().Warn(testCase.warn)
+			}
+			if testCase.errorLog != "" {
+				logger.EXPECT().Error(testCase.errorLog)
+			}
+
+			exchanger := mock_exchange.NewMockExchanger(ctrl)
+			if testCase.exchangeCalls > 0 {
+				var response *dns.Msg
+				if testCase.exchangeErr == nil {
+					response = newResponse()
+				}
+				exchanger.EXPECT().Exchange(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, request *dns.Msg) (*dns.Msg, error) {
+						// The MAC address of the client must not leak upstream.
+						for _, option := range request.IsEdns0().Option {
+							assert.NotEqual(t, uint16(macOptionCode), option.Option())
+						}
+						return response, testCase.exchangeErr
+					}).
+					Times(testCase.exchangeCalls)
+			}
+
+			handler, err := New(ctx, logger, exchanger, testCase.settings)
+			require.NoError(t, err)
+
+			writer := &testResponseWriter{}
+			for i := 0; i < testCase.requests; i++ {
+				handler.ServeDNS(writer, newRequest())
+			}
+
+			require.Len(t, writer.responses, testCase.requests)
+			for _, response := range writer.responses {
+				assert.Equal(t, testCase.rcode, response.Rcode)
+				assert.Len(t, response.Answer, testCase.answers)
+			}
+		})
+	}
+}
+
+func Test_New_invalidSettings(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	settings := Settings{
+		DNS64: dns64.Settings{
+			Enabled: true,
+			// The IPv4 address would be embedded past the IPv6 address.
+			Prefix: netaddr.MustParseIPPrefix("64:ff9b::/104"),
+		},
+	}
+
+	handler, err := New(context.Background(), mock_logging.NewMockLogger(ctrl),
+		mock_exchange.NewMockExchanger(ctrl), settings)
+
+	assert.Nil(t, handler)
+	assert.ErrorIs(t, err, dns64.ErrPrefixLengthInvalid)
+	assert.EqualError(t, err, "DNS64 settings: prefix length is not "+
+		"one of 32, 40, 48, 56, 64 or 96: 64:ff9b::/104")
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/accesscontrol"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/dns/pkg/dnssec"
+	"github.com/qdm12/dns/pkg/forward"
+	"github.com/qdm12/dns/pkg/localdata"
+)
+
+type Settings struct {
+	Cache         cache.Settings
+	Blacklist     blacklist.Settings
+	AccessControl accesscontrol.Settings
+	Forward       forward.Settings
+	LocalData     localdata.Settings
+	DNS64         dns64.Settings
+	// DNSSEC validation of the responses from the exchanger,
+	// where responses from forwarding zones are not validated.
+	DNSSEC dnssec.Settings
+}
+
+func (s *Settings) SetDefaults() {
+	// Cache defaults to disabled, see pkg/cache/settings.go
+	s.Cache.SetDefaults()
+
+	// Access control defaults to allow all, see pkg/accesscontrol/settings.go
+	s.AccessControl.SetDefaults()
+
+	s.Forward.SetDefaults()
+
+	s.DNS64.SetDefaults()
+
+	s.DNSSEC.SetDefaults()
+}
+
+// Validate returns an error if the settings cannot be used by the handler,
+// and is to be called once the defaults are set.
+func (s *Settings) Validate() (err error) {
+	if s.DNS64.Enabled {
+		err = s.DNS64.Validate()
+		if err != nil {
+			return fmt.Errorf("DNS64 settings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Caching:")
+	for _, line := range s.Cache.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines, subSection+"Blacklist:")
+	for _, line := range s.Blacklist.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines, subSection+"Access control:")
+	for _, line := range s.AccessControl.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	if len(s.Forward.Zones) > 0 {
+		lines = append(lines, subSection+"Forwarding zones:")
+		for _, line := range s.Forward.Lines(indent, subSection) {
+			lines = append(lines, indent+line)
+		}
+	}
+
+	lines = append(lines, s.LocalData.Lines(indent, subSection)...)
+
+	lines = append(lines, s.DNS64.Lines(indent, subSection)...)
+
+	lines = append(lines, s.DNSSEC.Lines(indent, subSection)...)
+
+	return lines
+}
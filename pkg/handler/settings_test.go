@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_Settings_Validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings Settings
+		err      error
+	}{
+		"defaults": {},
+		"DNS64 disabled with invalid prefix": {
+			settings: Settings{
+				DNS64: dns64.Settings{
+					Prefix: netaddr.MustParseIPPrefix("2001:db8::/33"),
+				},
+			},
+		},
+		"DNS64 with invalid prefix": {
+			settings: Settings{
+				DNS64: dns64.Settings{
+					Enabled: true,
+					Prefix:  netaddr.MustParseIPPrefix("2001:db8::/33"),
+				},
+			},
+			err: dns64.ErrPrefixLengthInvalid,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			testCase.settings.SetDefaults()
+
+			err := testCase.settings.Validate()
+
+			assert.ErrorIs(t, err, testCase.err)
+		})
+	}
+}
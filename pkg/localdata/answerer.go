@@ -0,0 +1,81 @@
+package localdata
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Answerer
+
+// Answerer answers requests for names having local records.
+type Answerer interface {
+	// Answer returns an authoritative response for the request if its
+	// question name has local records, and nil otherwise. As for the
+	// Unbound local-data, a name without records of the question type
+	// is answered with no data, and a CNAME record is answered for any
+	// question type, followed by the local records of its target.
+	Answer(request *dns.Msg) (response *dns.Msg)
+}
+
+type answerer struct {
+	nameToRecords map[string][]dns.RR
+}
+
+func NewAnswerer(settings Settings) Answerer {
+	nameToRecords := make(map[string][]dns.RR, len(settings.Records))
+	for _, record := range settings.Records {
+		name := strings.ToLower(record.Header().Name)
+		nameToRecords[name] = append(nameToRecords[name], record)
+	}
+	return &answerer{
+		nameToRecords: nameToRecords,
+	}
+}
+
+func (a *answerer) Answer(request *dns.Msg) (response *dns.Msg) {
+	if len(request.Question) != 1 || len(a.nameToRecords) == 0 {
+		return nil
+	}
+
+	question := request.Question[0]
+	if question.Qclass != dns.ClassINET {
+		return nil
+	}
+
+	name := strings.ToLower(question.Name)
+	if _, ok := a.nameToRecords[name]; !ok {
+		return nil
+	}
+
+	response = new(dns.Msg).SetReply(request)
+	response.Authoritative = true
+	response.RecursionAvailable = true
+
+	// Follow CNAME records of local names, with a
+	// maximum number of CNAME records to avoid loops.
+	const maxCNAMEs = 8
+	for i := 0; i <= maxCNAMEs; i++ {
+		records, ok := a.nameToRecords[name]
+		if !ok {
+			break
+		}
+
+		var cname *dns.CNAME
+		for _, record := range records {
+			if question.Qtype == dns.TypeANY || record.Header().Rrtype == question.Qtype {
+				response.Answer = append(response.Answer, dns.Copy(record))
+			} else if record.Header().Rrtype == dns.TypeCNAME {
+				cname = record.(*dns.CNAME)
+			}
+		}
+
+		if cname == nil {
+			break
+		}
+		response.Answer = append(response.Answer, dns.Copy(cname))
+		name = strings.ToLower(cname.Target)
+	}
+
+	return response
+}
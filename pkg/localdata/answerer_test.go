@@ -0,0 +1,118 @@
+package localdata
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_answerer_Answer(t *testing.T) {
+	t.Parallel()
+
+	records, err := ParseRecords([]string{
+		"nas.lan. 300 IN A 192.168.1.10",
+		"nas.lan. 300 IN A 192.168.1.11",
+		"files.lan. 300 IN CNAME nas.lan.",
+		"web.lan. 300 IN CNAME example.com.",
+		"loop1.lan. 300 IN CNAME loop2.lan.",
+		"loop2.lan. 300 IN CNAME loop1.lan.",
+	})
+	require.NoError(t, err)
+	answerer := NewAnswerer(Settings{Records: records})
+
+	testCases := map[string]struct {
+		name     string
+		qtype    uint16
+		answered bool
+		answer   []string
+	}{
+		"no local record": {
+			name:  "github.com.",
+			qtype: dns.TypeA,
+		},
+		"records": {
+			name:     "NAS.lan.",
+			qtype:    dns.TypeA,
+			answered: true,
+			answer: []string{
+				"nas.lan.\t300\tIN\tA\t192.168.1.10",
+				"nas.lan.\t300\tIN\tA\t192.168.1.11",
+			},
+		},
+		"no data": {
+			name:     "nas.lan.",
+			qtype:    dns.TypeAAAA,
+			answered: true,
+		},
+		"local CNAME target": {
+			name:     "files.lan.",
+			qtype:    dns.TypeA,
+			answered: true,
+			answer: []string{
+				"files.lan.\t300\tIN\tCNAME\tnas.lan.",
+				"nas.lan.\t300\tIN\tA\t192.168.1.10",
+				"nas.lan.\t300\tIN\tA\t192.168.1.11",
+			},
+		},
+		"CNAME question": {
+			name:     "files.lan.",
+			qtype:    dns.TypeCNAME,
+			answered: true,
+			answer: []string{
+				"files.lan.\t300\tIN\tCNAME\tnas.lan.",
+			},
+		},
+		"external CNAME target": {
+			name:     "web.lan.",
+			qtype:    dns.TypeA,
+			answered: true,
+			answer: []string{
+				"web.lan.\t300\tIN\tCNAME\texample.com.",
+			},
+		},
+		"CNAME loop": {
+			name:     "loop1.lan.",
+			qtype:    dns.TypeA,
+			answered: true,
+			answer: []string{
+				"loop1.lan.\t300\tIN\tCNAME\tloop2.lan.",
+				"loop2.lan.\t300\tIN\tCNAME\tloop1.lan.",
+				"loop1.lan.\t300\tIN\tCNAME\tloop2.lan.",
+				"loop2.lan.\t300\tIN\tCNAME\tloop1.lan.",
+				"loop1.lan.\t300\tIN\tCNAME\tloop2.lan.",
+				"loop2.lan.\t300\tIN\tCNAME\tloop1.lan.",
+				"loop1.lan.\t300\tIN\tCNAME\tloop2.lan.",
+				"loop2.lan.\t300\tIN\tCNAME\tloop1.lan.",
+				"loop1.lan.\t300\tIN\tCNAME\tloop2.lan.",
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			request := new(dns.Msg).SetQuestion(testCase.name, testCase.qtype)
+
+			response := answerer.Answer(request)
+
+			if !testCase.answered {
+				assert.Nil(t, response)
+				return
+			}
+			require.NotNil(t, response)
+			assert.True(t, response.Authoritative)
+			assert.Equal(t, dns.RcodeSuccess, response.Rcode)
+			answer := make([]string, len(response.Answer))
+			for i, record := range response.Answer {
+				answer[i] = record.String()
+			}
+			if len(testCase.answer) == 0 {
+				assert.Empty(t, answer)
+				return
+			}
+			assert.Equal(t, testCase.answer, answer)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package localdata
+
+import (
+	"strings"
+)
+
+// LoaderSettings contains the sources of the local records.
+type LoaderSettings struct {
+	// Records are records in the zone file format,
+	// such as "nas.lan. 300 IN A 192.168.1.10".
+	Records []string
+	// RecordsFilepath is the path of a file containing
+	// one record per line in the zone file format.
+	RecordsFilepath string
+	// HostsFilepath is the path of a hosts file, from which address
+	// and reverse PTR records are generated for each hostname.
+	HostsFilepath string
+}
+
+// Filepaths returns the non empty file paths of the settings.
+func (s *LoaderSettings) Filepaths() (paths []string) {
+	for _, path := range []string{s.RecordsFilepath, s.HostsFilepath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Load loads the local records from the records and files of the settings.
+func (s *LoaderSettings) Load() (settings Settings, err error) {
+	settings.Records, err = ParseRecords(s.Records)
+	if err != nil {
+		return settings, err
+	}
+
+	if s.RecordsFilepath != "" {
+		records, err := ParseRecordsFile(s.RecordsFilepath)
+		if err != nil {
+			return settings, err
+		}
+		settings.Records = append(settings.Records, records...)
+	}
+
+	if s.HostsFilepath != "" {
+		records, err := ParseHostsFile(s.HostsFilepath)
+		if err != nil {
+			return settings, err
+		}
+		settings.Records = append(settings.Records, records...)
+	}
+
+	return settings, nil
+}
+
+func (s *LoaderSettings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *LoaderSettings) Lines(indent, subSection string) (lines []string) {
+	if len(s.Records) > 0 {
+		lines = append(lines, subSection+"Records:")
+		for _, record := range s.Records {
+			lines = append(lines, indent+subSection+record)
+		}
+	}
+
+	if s.RecordsFilepath != "" {
+		lines = append(lines, subSection+"Records file: "+s.RecordsFilepath)
+	}
+
+	if s.HostsFilepath != "" {
+		lines = append(lines, subSection+"Hosts file: "+s.HostsFilepath)
+	}
+
+	return lines
+}
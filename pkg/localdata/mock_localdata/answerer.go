@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/localdata (interfaces: Answerer)
+
+// Package mock_localdata is a generated GoMock package.
+package mock_localdata
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dns "github.com/miekg/dns"
+)
+
+// MockAnswerer is a mock of Answerer interface.
+type MockAnswerer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnswererMockRecorder
+}
+
+// MockAnswererMockRecorder is the mock recorder for MockAnswerer.
+type MockAnswererMockRecorder struct {
+	mock *MockAnswerer
+}
+
+// NewMockAnswerer creates a new mock instance.
+func NewMockAnswerer(ctrl *gomock.Controller) *MockAnswerer {
+	mock := &MockAnswerer{ctrl: ctrl}
+	mock.recorder = &MockAnswererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnswerer) EXPECT() *MockAnswererMockRecorder {
+	return m.recorder
+}
+
+// Answer mocks base method.
+func (m *MockAnswerer) Answer(arg0 *dns.Msg) *dns.Msg {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Answer", arg0)
+	ret0, _ := ret[0].(*dns.Msg)
+	return ret0
+}
+
+// Answer indicates an expected call of Answer.
+func (mr *MockAnswererMockRecorder) Answer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Answer", reflect.TypeOf((*MockAnswerer)(nil).Answer), arg0)
+}
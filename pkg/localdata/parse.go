@@ -0,0 +1,173 @@
+package localdata
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// hostsTTL is the time to live of the records generated from hosts files.
+const hostsTTL = 300
+
+var (
+	ErrRecordMalformed   = errors.New("record is malformed")
+	ErrRecordTypeInvalid = errors.New("record type is not supported")
+	ErrHostsLineInvalid  = errors.New("hosts file line is invalid")
+)
+
+// ParseRecords parses records in the zone file format, which
+// must be of the A, AAAA, CNAME, TXT, SRV or PTR type.
+func ParseRecords(values []string) (records []dns.RR, err error) {
+	records = make([]dns.RR, 0, len(values))
+	for _, value := range values {
+		record, err := parseRecord(value)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func parseRecord(s string) (record dns.RR, err error) {
+	record, err = dns.NewRR(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRecordMalformed, err)
+	} else if record == nil { // empty or comment only
+		return nil, fmt.Errorf("%w: %q", ErrRecordMalformed, s)
+	}
+
+	switch record.Header().Rrtype {
+	case dns.TypeA, dns.TypeAAAA, dns.TypeCNAME,
+		dns.TypeTXT, dns.TypeSRV, dns.TypePTR:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrRecordTypeInvalid, s)
+	}
+
+	record.Header().Name = strings.ToLower(record.Header().Name)
+	return record, nil
+}
+
+// ParseRecordsFile parses the file given containing one record per line
+// in the zone file format, ignoring empty lines and comment lines.
+func ParseRecordsFile(path string) (records []dns.RR, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		record, err := parseRecord(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, file.Close()
+}
+
+// ParseHostsFile parses the hosts file given, see ParseHosts.
+func ParseHostsFile(path string) (records []dns.RR, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err = ParseHosts(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return records, file.Close()
+}
+
+// ParseHosts parses hosts file lines in the format "<ip> <hostname> [aliases...]".
+// An A or AAAA record is generated for each hostname and alias, and a PTR
+// record is generated for the IP address pointing to the hostname.
+// Loopback and unspecified IP addresses, such as the localhost entries,
+// are ignored, as well as IP addresses already seen for the PTR records.
+func ParseHosts(reader io.Reader) (records []dns.RR, err error) {
+	ptrDone := make(map[netaddr.IP]struct{})
+
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		const minFields = 2
+		if len(fields) < minFields {
+			return nil, fmt.Errorf("line %d: %w: %s", lineNumber, ErrHostsLineInvalid, line)
+		}
+
+		ip, err := netaddr.ParseIP(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: %s", lineNumber, ErrHostsLineInvalid, err)
+		}
+		if ip.IsLoopback() || ip.IsUnspecified() {
+			continue
+		}
+
+		for _, hostname := range fields[1:] {
+			records = append(records, newAddressRecord(hostname, ip))
+		}
+
+		if _, ok := ptrDone[ip]; ok {
+			continue
+		}
+		ptrDone[ip] = struct{}{}
+		records = append(records, newPTRRecord(ip, fields[1]))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func newAddressRecord(hostname string, ip netaddr.IP) (record dns.RR) {
+	name := strings.ToLower(dns.Fqdn(hostname))
+	if ip.Is4() {
+		return &dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: hostsTTL},
+			A:   ip.IPAddr().IP,
+		}
+	}
+	return &dns.AAAA{
+		Hdr:  dns.RR_Header{Name: name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: hostsTTL},
+		AAAA: ip.IPAddr().IP,
+	}
+}
+
+func newPTRRecord(ip netaddr.IP, hostname string) (record dns.RR) {
+	// dns.ReverseAddr cannot fail for a valid IP address.
+	reverseName, _ := dns.ReverseAddr(ip.String())
+	return &dns.PTR{
+		Hdr: dns.RR_Header{Name: reverseName, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: hostsTTL},
+		Ptr: strings.ToLower(dns.Fqdn(hostname)),
+	}
+}
@@ -0,0 +1,129 @@
+package localdata
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRecords(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		values  []string
+		records []string
+		err     error
+	}{
+		"no record": {
+			records: []string{},
+		},
+		"records": {
+			values: []string{
+				"NAS.lan. 300 IN A 192.168.1.10",
+				"nas.lan. 300 IN AAAA fd00::10",
+				"files.lan. 300 IN CNAME nas.lan.",
+				`nas.lan. 300 IN TXT "hello"`,
+				"_smb._tcp.lan. 300 IN SRV 0 5 445 nas.lan.",
+				"10.1.168.192.in-addr.arpa. 300 IN PTR nas.lan.",
+			},
+			records: []string{
+				"nas.lan.\t300\tIN\tA\t192.168.1.10",
+				"nas.lan.\t300\tIN\tAAAA\tfd00::10",
+				"files.lan.\t300\tIN\tCNAME\tnas.lan.",
+				"nas.lan.\t300\tIN\tTXT\t\"hello\"",
+				"_smb._tcp.lan.\t300\tIN\tSRV\t0 5 445 nas.lan.",
+				"10.1.168.192.in-addr.arpa.\t300\tIN\tPTR\tnas.lan.",
+			},
+		},
+		"malformed record": {
+			values: []string{"nas.lan. IN A 192.168.1"},
+			err:    ErrRecordMalformed,
+		},
+		"empty record": {
+			values: []string{"; comment"},
+			err:    ErrRecordMalformed,
+		},
+		"unsupported record type": {
+			values: []string{"lan. 300 IN MX 10 mail.lan."},
+			err:    ErrRecordTypeInvalid,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			records, err := ParseRecords(testCase.values)
+
+			if testCase.err != nil {
+				assert.True(t, errors.Is(err, testCase.err))
+				return
+			}
+			require.NoError(t, err)
+			recordStrings := make([]string, len(records))
+			for i, record := range records {
+				recordStrings[i] = record.String()
+			}
+			assert.Equal(t, testCase.records, recordStrings)
+		})
+	}
+}
+
+func Test_ParseHosts(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		hosts   string
+		records []string
+		err     string
+	}{
+		"empty": {},
+		"hosts file": {
+			hosts: `# comment
+127.0.0.1 localhost
+::1 localhost ip6-localhost
+192.168.1.10	NAS nas.lan # inline comment
+192.168.1.10 files.lan
+fd00::10 nas.lan
+`,
+			records: []string{
+				"nas.\t300\tIN\tA\t192.168.1.10",
+				"nas.lan.\t300\tIN\tA\t192.168.1.10",
+				"10.1.168.192.in-addr.arpa.\t300\tIN\tPTR\tnas.",
+				"files.lan.\t300\tIN\tA\t192.168.1.10",
+				"nas.lan.\t300\tIN\tAAAA\tfd00::10",
+				"0.1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa.\t300\tIN\tPTR\tnas.lan.",
+			},
+		},
+		"missing hostname": {
+			hosts: "192.168.1.10\n",
+			err:   "line 1: hosts file line is invalid: 192.168.1.10",
+		},
+		"invalid IP address": {
+			hosts: "192.168.1 nas\n",
+			err:   `line 1: hosts file line is invalid: ParseIP("192.168.1"): IPv4 address too short`,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			records, err := ParseHosts(strings.NewReader(testCase.hosts))
+
+			if testCase.err != "" {
+				assert.EqualError(t, err, testCase.err)
+				return
+			}
+			require.NoError(t, err)
+			recordStrings := make([]string, len(records))
+			for i, record := range records {
+				recordStrings[i] = record.String()
+			}
+			assert.ElementsMatch(t, testCase.records, recordStrings)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package localdata
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+type Settings struct {
+	// Records are the local records answered authoritatively.
+	Records []dns.RR
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	return []string{subSection + "Local records: " + strconv.Itoa(len(s.Records))}
+}
@@ -0,0 +1,102 @@
+package localdata
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/qdm12/golibs/logging"
+)
+
+// Watcher watches the files of the local records for changes.
+type Watcher interface {
+	// Run checks the files for changes at each period, and sends the
+	// local data settings loaded to the channel given each time one
+	// of the files changed, until the context is canceled.
+	Run(ctx context.Context, changes chan<- Settings)
+}
+
+type watcher struct {
+	settings LoaderSettings
+	period   time.Duration
+	logger   logging.Logger
+	states   map[string]fileState
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher creates a Watcher checking the files of the loader
+// settings given every period, which must be strictly positive.
+func NewWatcher(settings LoaderSettings, period time.Duration,
+	logger logging.Logger) Watcher {
+	w := &watcher{
+		settings: settings,
+		period:   period,
+		logger:   logger,
+	}
+	w.states = w.stat()
+	return w
+}
+
+func (w *watcher) Run(ctx context.Context, changes chan<- Settings) {
+	ticker := time.NewTicker(w.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		states := w.stat()
+		if !statesChanged(w.states, states) {
+			continue
+		}
+		w.states = states
+
+		settings, err := w.settings.Load()
+		if err != nil {
+			w.logger.Warn("cannot load local records: " + err.Error())
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case changes <- settings:
+		}
+	}
+}
+
+// stat returns the states of the files, where
+// a missing file has the zero state.
+func (w *watcher) stat() (states map[string]fileState) {
+	paths := w.settings.Filepaths()
+	states = make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			states[path] = fileState{}
+			continue
+		}
+		states[path] = fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+	}
+	return states
+}
+
+func statesChanged(oldStates, newStates map[string]fileState) (changed bool) {
+	for path, newState := range newStates {
+		oldState := oldStates[path]
+		if !newState.modTime.Equal(oldState.modTime) || newState.size != oldState.size {
+			return true
+		}
+	}
+	return false
+}
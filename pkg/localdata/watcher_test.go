@@ -0,0 +1,45 @@
+package localdata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdm12/golibs/logging/mock_logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_watcher_Run(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	hostsPath := filepath.Join(t.TempDir(), "hosts")
+	err := os.WriteFile(hostsPath, []byte("192.168.1.10 nas\n"), 0600)
+	require.NoError(t, err)
+
+	logger := mock_logging.NewMockLogger(ctrl)
+	const period = time.Millisecond
+	watcher := NewWatcher(LoaderSettings{HostsFilepath: hostsPath}, period, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan Settings)
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx, changes)
+		close(done)
+	}()
+
+	err = os.WriteFile(hostsPath, []byte("192.168.1.10 nas\n192.168.1.11 printer\n"), 0600)
+	require.NoError(t, err)
+
+	settings := <-changes
+	const expectedRecords = 4 // 2 A and 2 PTR records
+	assert.Len(t, settings.Records, expectedRecords)
+
+	cancel()
+	<-done
+}
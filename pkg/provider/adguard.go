@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"net"
+	"net/url"
+)
+
+type adGuard struct{}
+
+func AdGuard() Provider {
+	return &adGuard{}
+}
+
+func (a *adGuard) String() string {
+	return "AdGuard"
+}
+
+func (a *adGuard) DNS() DNSServer {
+	// see https://adguard-dns.io/en/public-dns.html
+	return DNSServer{
+		IPv4: []net.IP{{94, 140, 14, 14}, {94, 140, 15, 15}},
+		IPv6: []net.IP{
+			{0x2a, 0x10, 0x50, 0xc0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa, 0xd1, 0x0, 0xff},
+			{0x2a, 0x10, 0x50, 0xc0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa, 0xd2, 0x0, 0xff},
+		},
+	}
+}
+
+func (a *adGuard) DoT() DoTServer {
+	return DoTServer{
+		IPv4: []net.IP{{94, 140, 14, 14}, {94, 140, 15, 15}},
+		IPv6: []net.IP{
+			{0x2a, 0x10, 0x50, 0xc0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa, 0xd1, 0x0, 0xff},
+			{0x2a, 0x10, 0x50, 0xc0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa, 0xd2, 0x0, 0xff},
+		},
+		Name: "dns.adguard-dns.com",
+		Port: defaultDoTPort,
+	}
+}
+
+func (a *adGuard) DoH() DoHServer {
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := a.DoT()
+	return DoHServer{
+		URL: &url.URL{
+			Scheme: "https",
+			Host:   "dns.adguard-dns.com",
+			Path:   "/dns-query",
+		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
+	}
+}
+
+func (a *adGuard) DoQ() DoQServer {
+	// The DoQ hostname resolves to the DoT IP addresses
+	dot := a.DoT()
+	return DoQServer{
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
+		Name: "dns.adguard-dns.com",
+		Port: defaultDoQPort,
+	}
+}
+
+func (a *adGuard) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is offered but not described, use a DNS stamp instead
+	return DNSCryptServer{}
+}
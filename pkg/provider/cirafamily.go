@@ -38,11 +38,25 @@ func (c *ciraFamily) DoT() DoTServer {
 }
 
 func (c *ciraFamily) DoH() DoHServer {
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := c.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "family.canadianshield.cira.ca",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (c *ciraFamily) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *ciraFamily) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
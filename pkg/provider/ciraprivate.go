@@ -38,11 +38,25 @@ func (c *ciraPrivate) DoT() DoTServer {
 }
 
 func (c *ciraPrivate) DoH() DoHServer {
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := c.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "private.canadianshield.cira.ca",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (c *ciraPrivate) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *ciraPrivate) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
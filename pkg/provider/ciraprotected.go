@@ -38,11 +38,25 @@ func (c *ciraProtected) DoT() DoTServer {
 }
 
 func (c *ciraProtected) DoH() DoHServer {
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := c.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "protected.canadianshield.cira.ca",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (c *ciraProtected) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *ciraProtected) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
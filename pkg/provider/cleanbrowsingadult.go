@@ -47,3 +47,13 @@ func (c *cleanBrowsingAdult) DoH() DoHServer {
 		},
 	}
 }
+
+func (c *cleanBrowsingAdult) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *cleanBrowsingAdult) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is offered but not described, use a DNS stamp instead
+	return DNSCryptServer{}
+}
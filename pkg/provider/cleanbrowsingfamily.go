@@ -47,3 +47,13 @@ func (c *cleanBrowsingFamily) DoH() DoHServer {
 		},
 	}
 }
+
+func (c *cleanBrowsingFamily) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *cleanBrowsingFamily) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is offered but not described, use a DNS stamp instead
+	return DNSCryptServer{}
+}
@@ -47,3 +47,13 @@ func (c *cleanBrowsingSecurity) DoH() DoHServer {
 		},
 	}
 }
+
+func (c *cleanBrowsingSecurity) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *cleanBrowsingSecurity) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is offered but not described, use a DNS stamp instead
+	return DNSCryptServer{}
+}
@@ -38,11 +38,25 @@ func (c *cloudflare) DoT() DoTServer {
 }
 
 func (c *cloudflare) DoH() DoHServer {
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := c.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "cloudflare-dns.com",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (c *cloudflare) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *cloudflare) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
@@ -39,11 +39,25 @@ func (c *cloudflareFamily) DoT() DoTServer {
 
 func (c *cloudflareFamily) DoH() DoHServer {
 	// see // see https://developers.cloudflare.com/1.1.1.1/1.1.1.1-for-families/setup-instructions/dns-over-https
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := c.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "family.cloudflare-dns.com",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (c *cloudflareFamily) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (c *cloudflareFamily) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
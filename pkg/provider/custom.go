@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrCustomNameEmpty     = errors.New("custom provider name is empty")
+	ErrCustomNameBuiltIn   = errors.New("custom provider name is the name of a built-in provider")
+	ErrCustomNoServer      = errors.New("custom provider has no server")
+	ErrCustomDoTNameEmpty  = errors.New("custom provider DoT server name is empty")
+	ErrCustomDoTNoIP       = errors.New("custom provider DoT server has no IP address")
+	ErrCustomDoHURLInvalid = errors.New("custom provider DoH server URL is invalid")
+	ErrCustomDoQNameEmpty  = errors.New("custom provider DoQ server name is empty")
+	ErrCustomDoQNoIP       = errors.New("custom provider DoQ server has no IP address")
+
+	ErrCustomDNSCryptNameEmpty  = errors.New("custom provider DNSCrypt provider name is empty")
+	ErrCustomDNSCryptNoIP       = errors.New("custom provider DNSCrypt server has no IP address")
+	ErrCustomDNSCryptKeyInvalid = errors.New("custom provider DNSCrypt public key is invalid")
+)
+
+// CustomSettings are the settings of a provider defined by the user,
+// where the servers not offered by the provider are left empty.
+type CustomSettings struct {
+	Name string
+	DNS  DNSServer
+	// DoT is the DNS over TLS server, and its port defaults to 853.
+	DoT DoTServer
+	DoH DoHServer
+	// DoQ is the DNS over QUIC server, and its port defaults to 853.
+	DoQ DoQServer
+	// DNSCrypt is the DNSCrypt server, and its port defaults to 443.
+	DNSCrypt DNSCryptServer
+}
+
+type custom struct {
+	name string
+	dns  DNSServer
+	dot  DoTServer
+	doh  DoHServer
+	doq  DoQServer
+
+	dnsCrypt DNSCryptServer
+}
+
+// NewCustom returns a provider defined by the settings given,
+// which must define at least one of the DNS, DoT, DoH, DoQ and DNSCrypt servers.
+// Its name cannot be the name of a built-in provider, which it would shadow.
+func NewCustom(settings CustomSettings) (provider Provider, err error) {
+	if settings.Name == "" {
+		return nil, ErrCustomNameEmpty
+	}
+	for _, builtIn := range All() {
+		if strings.EqualFold(settings.Name, builtIn.String()) {
+			return nil, fmt.Errorf("%w: %s", ErrCustomNameBuiltIn, settings.Name)
+		}
+	}
+
+	hasDNS := len(settings.DNS.IPv4)+len(settings.DNS.IPv6) > 0
+
+	dotIPsCount := len(settings.DoT.IPv4) + len(settings.DoT.IPv6)
+	hasDoT := settings.DoT.Name != "" || dotIPsCount > 0
+	if hasDoT {
+		if settings.DoT.Name == "" {
+			return nil, fmt.Errorf("%w: for %s", ErrCustomDoTNameEmpty, settings.Name)
+		} else if dotIPsCount == 0 {
+			return nil, fmt.Errorf("%w: for %s", ErrCustomDoTNoIP, settings.Name)
+		}
+		if settings.DoT.Port == 0 {
+			settings.DoT.Port = defaultDoTPort
+		}
+	}
+
+	hasDoH := settings.DoH.URL != nil
+	if hasDoH && (settings.DoH.URL.Scheme != "https" || settings.DoH.URL.Host == "") {
+		return nil, fmt.Errorf("%w: for %s: %s", ErrCustomDoHURLInvalid,
+			settings.Name, settings.DoH.URL)
+	}
+
+	doqIPsCount := len(settings.DoQ.IPv4) + len(settings.DoQ.IPv6)
+	hasDoQ := settings.DoQ.Name != "" || doqIPsCount > 0
+	if hasDoQ {
+		if settings.DoQ.Name == "" {
+			return nil, fmt.Errorf("%w: for %s", ErrCustomDoQNameEmpty, settings.Name)
+		} else if doqIPsCount == 0 {
+			return nil, fmt.Errorf("%w: for %s", ErrCustomDoQNoIP, settings.Name)
+		}
+		if settings.DoQ.Port == 0 {
+			settings.DoQ.Port = defaultDoQPort
+		}
+	}
+
+	dnsCryptIPsCount := len(settings.DNSCrypt.IPv4) + len(settings.DNSCrypt.IPv6)
+	hasDNSCrypt := settings.DNSCrypt.ProviderName != "" || dnsCryptIPsCount > 0
+	if hasDNSCrypt {
+		const publicKeySize = 32 // Ed25519 public key
+		switch {
+		case settings.DNSCrypt.ProviderName == "":
+			return nil, fmt.Errorf("%w: for %s", ErrCustomDNSCryptNameEmpty, settings.Name)
+		case dnsCryptIPsCount == 0:
+			return nil, fmt.Errorf("%w: for %s", ErrCustomDNSCryptNoIP, settings.Name)
+		case len(settings.DNSCrypt.PublicKey) != publicKeySize:
+			return nil, fmt.Errorf("%w: for %s: %d bytes instead of %d",
+				ErrCustomDNSCryptKeyInvalid, settings.Name,
+				len(settings.DNSCrypt.PublicKey), publicKeySize)
+		}
+		if settings.DNSCrypt.Port == 0 {
+			settings.DNSCrypt.Port = defaultDNSCryptPort
+		}
+	}
+
+	if !hasDNS && !hasDoT && !hasDoH && !hasDoQ && !hasDNSCrypt {
+		return nil, fmt.Errorf("%w: %s", ErrCustomNoServer, settings.Name)
+	}
+
+	return &custom{
+		name: settings.Name,
+		dns:  settings.DNS,
+		dot:  settings.DoT,
+		doh:  settings.DoH,
+		doq:  settings.DoQ,
+
+		dnsCrypt: settings.DNSCrypt,
+	}, nil
+}
+
+func (c *custom) String() string {
+	return c.name
+}
+
+func (c *custom) DNS() DNSServer {
+	return c.dns
+}
+
+func (c *custom) DoT() DoTServer {
+	return c.dot
+}
+
+func (c *custom) DoH() DoHServer {
+	return c.doh
+}
+
+func (c *custom) DoQ() DoQServer {
+	return c.doq
+}
+
+func (c *custom) DNSCrypt() DNSCryptServer {
+	return c.dnsCrypt
+}
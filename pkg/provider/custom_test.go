@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewCustom(t *testing.T) {
+	t.Parallel()
+
+	dohURL := &url.URL{Scheme: "https", Host: "dns.example.com", Path: "/dns-query"}
+
+	testCases := map[string]struct {
+		settings CustomSettings
+		dot      DoTServer
+		doq      DoQServer
+		dnsCrypt DNSCryptServer
+		err      error
+	}{
+		"empty name": {
+			err: ErrCustomNameEmpty,
+		},
+		"built-in name": {
+			settings: CustomSettings{
+				Name: "Cloudflare",
+				DNS:  DNSServer{IPv4: []net.IP{{10, 0, 0, 53}}},
+			},
+			err: ErrCustomNameBuiltIn,
+		},
+		"no server": {
+			settings: CustomSettings{Name: "x"},
+			err:      ErrCustomNoServer,
+		},
+		"DoT without name": {
+			settings: CustomSettings{
+				Name: "x",
+				DoT:  DoTServer{IPv4: []net.IP{{10, 0, 0, 53}}},
+			},
+			err: ErrCustomDoTNameEmpty,
+		},
+		"DoT without IP": {
+			settings: CustomSettings{
+				Name: "x",
+				DoT:  DoTServer{Name: "dns.example.com"},
+			},
+			err: ErrCustomDoTNoIP,
+		},
+		"DoH URL without https": {
+			settings: CustomSettings{
+				Name: "x",
+				DoH:  DoHServer{URL: &url.URL{Scheme: "http", Host: "dns.example.com"}},
+			},
+			err: ErrCustomDoHURLInvalid,
+		},
+		"DoQ without IP": {
+			settings: CustomSettings{
+				Name: "x",
+				DoQ:  DoQServer{Name: "dns.example.com"},
+			},
+			err: ErrCustomDoQNoIP,
+		},
+		"DoQ with default port": {
+			settings: CustomSettings{
+				Name: "x",
+				DoQ: DoQServer{
+					IPv4: []net.IP{{10, 0, 0, 53}},
+					Name: "dns.example.com",
+				},
+			},
+			doq: DoQServer{
+				IPv4: []net.IP{{10, 0, 0, 53}},
+				Name: "dns.example.com",
+				Port: 853,
+			},
+		},
+		"DNSCrypt with invalid public key": {
+			settings: CustomSettings{
+				Name: "x",
+				DNSCrypt: DNSCryptServer{
+					IPv4:         []net.IP{{10, 0, 0, 53}},
+					ProviderName: "2.dnscrypt-cert.example.com",
+					PublicKey:    []byte{1, 2, 3},
+				},
+			},
+			err: ErrCustomDNSCryptKeyInvalid,
+		},
+		"DNSCrypt with default port": {
+			settings: CustomSettings{
+				Name: "x",
+				DNSCrypt: DNSCryptServer{
+					IPv4:         []net.IP{{10, 0, 0, 53}},
+					ProviderName: "2.dnscrypt-cert.example.com",
+					PublicKey:    make([]byte, 32),
+				},
+			},
+			dnsCrypt: DNSCryptServer{
+				IPv4:         []net.IP{{10, 0, 0, 53}},
+				Port:         443,
+				ProviderName: "2.dnscrypt-cert.example.com",
+				PublicKey:    make([]byte, 32),
+			},
+		},
+		"DoT with default port": {
+			settings: CustomSettings{
+				Name: "x",
+				DoT: DoTServer{
+					IPv4: []net.IP{{10, 0, 0, 53}},
+					Name: "dns.example.com",
+				},
+				DoH: DoHServer{URL: dohURL},
+			},
+			dot: DoTServer{
+				IPv4: []net.IP{{10, 0, 0, 53}},
+				Name: "dns.example.com",
+				Port: 853,
+			},
+		},
+		"DoT with custom port": {
+			settings: CustomSettings{
+				Name: "x",
+				DoT: DoTServer{
+					IPv6: []net.IP{net.ParseIP("2001:db8::53")},
+					Name: "dns.example.com",
+					Port: 8853,
+				},
+			},
+			dot: DoTServer{
+				IPv6: []net.IP{net.ParseIP("2001:db8::53")},
+				Name: "dns.example.com",
+				Port: 8853,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			provider, err := NewCustom(testCase.settings)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.Nil(t, provider)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.settings.Name, provider.String())
+			assert.Equal(t, testCase.dot, provider.DoT())
+			assert.Equal(t, testCase.settings.DNS, provider.DNS())
+			assert.Equal(t, testCase.settings.DoH, provider.DoH())
+			assert.Equal(t, testCase.doq, provider.DoQ())
+			assert.Equal(t, testCase.dnsCrypt, provider.DNSCrypt())
+		})
+	}
+}
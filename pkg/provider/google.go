@@ -39,11 +39,25 @@ func (g *google) DoT() DoTServer {
 
 func (g *google) DoH() DoHServer {
 	// See https://developers.google.com/speed/public-dns/docs/doh
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := g.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "dns.google",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (g *google) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (g *google) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
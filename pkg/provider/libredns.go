@@ -45,3 +45,13 @@ func (l *libreDNS) DoH() DoHServer {
 		},
 	}
 }
+
+func (l *libreDNS) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (l *libreDNS) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
@@ -2,6 +2,7 @@ package provider
 
 func All() []Provider {
 	return []Provider{
+		AdGuard(),
 		CiraFamily(),
 		CiraPrivate(),
 		CiraProtected(),
@@ -9,7 +9,7 @@ import (
 func Test_All(t *testing.T) {
 	t.Parallel()
 	providers := All()
-	assert.Len(t, providers, 15)
+	assert.Len(t, providers, 16)
 
 	for _, provider := range providers {
 		errMessage := "for provider " + provider.DoT().Name
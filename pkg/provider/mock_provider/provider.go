@@ -48,6 +48,20 @@ func (mr *MockProviderMockRecorder) DNS() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DNS", reflect.TypeOf((*MockProvider)(nil).DNS))
 }
 
+// DNSCrypt mocks base method.
+func (m *MockProvider) DNSCrypt() provider.DNSCryptServer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DNSCrypt")
+	ret0, _ := ret[0].(provider.DNSCryptServer)
+	return ret0
+}
+
+// DNSCrypt indicates an expected call of DNSCrypt.
+func (mr *MockProviderMockRecorder) DNSCrypt() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DNSCrypt", reflect.TypeOf((*MockProvider)(nil).DNSCrypt))
+}
+
 // DoH mocks base method.
 func (m *MockProvider) DoH() provider.DoHServer {
 	m.ctrl.T.Helper()
@@ -62,6 +76,20 @@ func (mr *MockProviderMockRecorder) DoH() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoH", reflect.TypeOf((*MockProvider)(nil).DoH))
 }
 
+// DoQ mocks base method.
+func (m *MockProvider) DoQ() provider.DoQServer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoQ")
+	ret0, _ := ret[0].(provider.DoQServer)
+	return ret0
+}
+
+// DoQ indicates an expected call of DoQ.
+func (mr *MockProviderMockRecorder) DoQ() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoQ", reflect.TypeOf((*MockProvider)(nil).DoQ))
+}
+
 // DoT mocks base method.
 func (m *MockProvider) DoT() provider.DoTServer {
 	m.ctrl.T.Helper()
@@ -8,8 +8,23 @@ import (
 
 var ErrParse = errors.New("cannot parse provider")
 
-func Parse(s string) (provider Provider, err error) {
-	for _, provider := range All() {
+// Parse returns the provider for the DNS stamp given, or the provider
+// matching the string given, searching the custom providers given
+// first and then the built-in providers.
+func Parse(s string, customProviders ...Provider) (provider Provider, err error) {
+	if strings.HasPrefix(s, stampScheme) {
+		stamp, err := ParseStamp(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrParse, err)
+		}
+		return stamp.Provider()
+	}
+
+	builtIn := All()
+	providers := make([]Provider, 0, len(customProviders)+len(builtIn))
+	providers = append(providers, customProviders...)
+	providers = append(providers, builtIn...)
+	for _, provider := range providers {
 		if strings.EqualFold(s, provider.String()) {
 			return provider, nil
 		}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"errors"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,10 +12,27 @@ import (
 func Test_Parse(t *testing.T) {
 	t.Parallel()
 
+	customProvider, err := NewCustom(CustomSettings{
+		Name: "My DNS",
+		DNS:  DNSServer{IPv4: []net.IP{{10, 0, 0, 53}}},
+	})
+	require.NoError(t, err)
+
+	stampProvider, err := NewCustom(CustomSettings{
+		Name: "cloudflare-dns.com",
+		DoT: DoTServer{
+			IPv4: []net.IP{{1, 1, 1, 1}},
+			Name: "cloudflare-dns.com",
+			Port: 853,
+		},
+	})
+	require.NoError(t, err)
+
 	testCases := map[string]struct {
-		s        string
-		provider Provider
-		err      error
+		s               string
+		customProviders []Provider
+		provider        Provider
+		err             error
 	}{
 		"empty string": {
 			err: errors.New(`cannot parse provider: ""`),
@@ -23,6 +41,10 @@ func Test_Parse(t *testing.T) {
 			s:   "invalid",
 			err: errors.New(`cannot parse provider: "invalid"`),
 		},
+		"adguard": {
+			s:        "adguard",
+			provider: AdGuard(),
+		},
 		"cirafamily": {
 			s:        "cira family",
 			provider: CiraFamily(),
@@ -83,6 +105,23 @@ func Test_Parse(t *testing.T) {
 			s:        "quadrant",
 			provider: Quadrant(),
 		},
+		"custom": {
+			s:               "my dns",
+			customProviders: []Provider{customProvider},
+			provider:        customProvider,
+		},
+		"DoT stamp": {
+			s:        "sdns://AwcAAAAAAAAABzEuMS4xLjEAEmNsb3VkZmxhcmUtZG5zLmNvbQ",
+			provider: stampProvider,
+		},
+		"invalid stamp": {
+			s:   "sdns://AAcA",
+			err: errors.New(`cannot parse provider: DNS stamp is too short: 3 bytes`),
+		},
+		"custom not given": {
+			s:   "my dns",
+			err: errors.New(`cannot parse provider: "my dns"`),
+		},
 	}
 
 	for name, testCase := range testCases {
@@ -90,7 +129,7 @@ func Test_Parse(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			provider, err := Parse(testCase.s)
+			provider, err := Parse(testCase.s, testCase.customProviders...)
 
 			if testCase.err != nil {
 				require.Error(t, err)
@@ -1,18 +1,30 @@
 package provider
 
 import (
+	"crypto/x509"
 	"net"
 	"net/url"
 )
 
 //go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Provider
 
-const defaultDoTPort uint16 = 853
+const (
+	defaultDoTPort uint16 = 853
+	defaultDoQPort uint16 = 853
+
+	defaultDNSCryptPort uint16 = 443
+)
 
 type Provider interface {
 	DNS() DNSServer
 	DoT() DoTServer
 	DoH() DoHServer
+	// DoQ returns the DNS over QUIC server, which has
+	// no IP address if the provider does not offer it.
+	DoQ() DoQServer
+	// DNSCrypt returns the DNSCrypt server, which has
+	// no IP address if the provider does not offer it.
+	DNSCrypt() DNSCryptServer
 	String() string
 }
 
@@ -26,8 +38,60 @@ type DoTServer struct {
 	IPv6 []net.IP
 	Name string // for TLS verification
 	Port uint16
+	// CertificateHashes are optional SHA256 digests of the to be
+	// signed part of certificates in the chain, from DNS stamps.
+	CertificateHashes [][]byte
+	// SPKIPins are optional SHA256 digests of the subject public
+	// key info of certificates in the chain, see SPKIPin.
+	SPKIPins [][]byte
+	// RootCAs are optional certificate authorities used instead
+	// of the system certificate authorities.
+	RootCAs *x509.CertPool
 }
 
 type DoHServer struct {
 	URL *url.URL
+	// IPv4 and IPv6 are the bootstrap IP addresses of the URL
+	// hostname, if known, to connect without resolving it.
+	IPv4 []net.IP
+	IPv6 []net.IP
+	// CertificateHashes are optional SHA256 digests of the to be
+	// signed part of certificates in the chain, from DNS stamps.
+	CertificateHashes [][]byte
+	// SPKIPins are optional SHA256 digests of the subject public
+	// key info of certificates in the chain, see SPKIPin.
+	SPKIPins [][]byte
+	// RootCAs are optional certificate authorities used instead
+	// of the system certificate authorities.
+	RootCAs *x509.CertPool
+}
+
+type DoQServer struct {
+	IPv4 []net.IP
+	IPv6 []net.IP
+	Name string // for TLS verification
+	Port uint16
+	// CertificateHashes are optional SHA256 digests of the to be
+	// signed part of certificates in the chain, from DNS stamps.
+	CertificateHashes [][]byte
+	// SPKIPins are optional SHA256 digests of the subject public
+	// key info of certificates in the chain, see SPKIPin.
+	SPKIPins [][]byte
+	// RootCAs are optional certificate authorities used instead
+	// of the system certificate authorities.
+	RootCAs *x509.CertPool
+}
+
+// DNSCryptServer is a DNSCrypt version 2 server,
+// see https://dnscrypt.info/protocol
+type DNSCryptServer struct {
+	IPv4 []net.IP
+	IPv6 []net.IP
+	Port uint16
+	// ProviderName is the DNSCrypt provider name, such as
+	// 2.dnscrypt-cert.example.com, queried for the resolver certificates.
+	ProviderName string
+	// PublicKey is the Ed25519 public key of the provider,
+	// used to verify the signature of the resolver certificates.
+	PublicKey []byte
 }
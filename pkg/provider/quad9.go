@@ -39,11 +39,25 @@ func (q *quad9) DoT() DoTServer {
 
 func (q *quad9) DoH() DoHServer {
 	// See https://developers.quad9.com/speed/public-dns/docs/doh
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := q.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "dns.quad9.net",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (q *quad9) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (q *quad9) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is offered but not described, use a DNS stamp instead
+	return DNSCryptServer{}
+}
@@ -37,11 +37,25 @@ func (q *quadrant) DoT() DoTServer {
 
 func (q *quadrant) DoH() DoHServer {
 	// See https://quadrantsec.com/quadrants_public_dns_resolver_with_tls_https_support/
+	// The DoH hostname resolves to the DoT IP addresses
+	dot := q.DoT()
 	return DoHServer{
 		URL: &url.URL{
 			Scheme: "https",
 			Host:   "doh.qis.io",
 			Path:   "/dns-query",
 		},
+		IPv4: dot.IPv4,
+		IPv6: dot.IPv6,
 	}
 }
+
+func (q *quadrant) DoQ() DoQServer {
+	// DNS over QUIC is not offered
+	return DoQServer{}
+}
+
+func (q *quadrant) DNSCrypt() DNSCryptServer {
+	// DNSCrypt is not offered
+	return DNSCryptServer{}
+}
@@ -0,0 +1,484 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// StampProtocol is the protocol identifier of a DNS stamp, as defined
+// in the DNS stamps specification at https://dnscrypt.info/stamps-specifications
+type StampProtocol byte
+
+const (
+	StampPlain    StampProtocol = 0x00
+	StampDNSCrypt StampProtocol = 0x01
+	StampDoH      StampProtocol = 0x02
+	StampDoT      StampProtocol = 0x03
+	StampDoQ      StampProtocol = 0x04
+)
+
+// StampProps are the informal properties of a DNS stamp.
+type StampProps uint64
+
+const (
+	StampDNSSEC   StampProps = 1 << 0
+	StampNoLog    StampProps = 1 << 1
+	StampNoFilter StampProps = 1 << 2
+)
+
+const stampScheme = "sdns://"
+
+// Stamp is a DNS stamp for a plaintext DNS, DNSCrypt, DNS over TLS,
+// DNS over HTTPS or DNS over QUIC server.
+type Stamp struct {
+	Protocol StampProtocol
+	Props    StampProps
+	// Address is the IP address of the server with an optional port,
+	// such as 1.1.1.1 or [2606:4700:4700::1111]:853. It can be empty
+	// or only a port such as :853 for DNS over TLS, DNS over HTTPS and
+	// DNS over QUIC, where the hostname is then used instead.
+	Address string
+	// Hashes are the SHA256 digests of the to be signed part of
+	// certificates in the chain of the server, used for pinning.
+	Hashes [][]byte
+	// Hostname is the TLS server name, with an optional port,
+	// or the provider name for DNSCrypt.
+	Hostname string
+	// PublicKey is the Ed25519 public key of the DNSCrypt provider.
+	PublicKey []byte
+	// Path is the DNS over HTTPS URL path, such as /dns-query.
+	Path string
+	// BootstrapIPs are IP addresses of resolvers to use to resolve the hostname.
+	BootstrapIPs []string
+}
+
+var (
+	ErrStampScheme              = errors.New("DNS stamp does not start with " + stampScheme)
+	ErrStampEncoding            = errors.New("DNS stamp is not base64 URL encoded")
+	ErrStampTooShort            = errors.New("DNS stamp is too short")
+	ErrStampProtocolUnsupported = errors.New("DNS stamp protocol is not supported")
+	ErrStampGarbage             = errors.New("DNS stamp has trailing bytes")
+)
+
+// ParseStamp parses a DNS stamp of the form sdns://... for a plaintext
+// DNS, DNSCrypt, DNS over TLS, DNS over HTTPS or DNS over QUIC server.
+func ParseStamp(s string) (stamp Stamp, err error) {
+	if !strings.HasPrefix(s, stampScheme) {
+		return stamp, fmt.Errorf("%w: %s", ErrStampScheme, s)
+	}
+
+	bin, err := base64.RawURLEncoding.DecodeString(s[len(stampScheme):])
+	if err != nil {
+		return stamp, fmt.Errorf("%w: %s", ErrStampEncoding, err)
+	}
+
+	const minLength = 1 + 8 // protocol and props
+	if len(bin) < minLength {
+		return stamp, fmt.Errorf("%w: %d bytes", ErrStampTooShort, len(bin))
+	}
+
+	stamp.Protocol = StampProtocol(bin[0])
+	stamp.Props = StampProps(binary.LittleEndian.Uint64(bin[1:9]))
+	decoder := &stampDecoder{bin: bin[minLength:]}
+
+	stamp.Address = decoder.lp()
+	switch stamp.Protocol {
+	case StampPlain:
+	case StampDNSCrypt:
+		stamp.PublicKey = []byte(decoder.lp())
+		stamp.Hostname = decoder.lp()
+	case StampDoT, StampDoQ:
+		stamp.Hashes = decoder.vlp()
+		stamp.Hostname = decoder.lp()
+		if decoder.remaining() {
+			stamp.BootstrapIPs = bytesToStrings(decoder.vlp())
+		}
+	case StampDoH:
+		stamp.Hashes = decoder.vlp()
+		stamp.Hostname = decoder.lp()
+		stamp.Path = decoder.lp()
+		if decoder.remaining() {
+			stamp.BootstrapIPs = bytesToStrings(decoder.vlp())
+		}
+	default:
+		return stamp, fmt.Errorf("%w: 0x%02x", ErrStampProtocolUnsupported, byte(stamp.Protocol))
+	}
+
+	if decoder.err != nil {
+		return stamp, decoder.err
+	} else if decoder.remaining() {
+		return stamp, fmt.Errorf("%w: %d bytes", ErrStampGarbage, len(decoder.bin))
+	}
+
+	return stamp, nil
+}
+
+// String returns the stamp encoded as sdns://...
+func (s Stamp) String() string {
+	bin := []byte{byte(s.Protocol)}
+	props := make([]byte, 8) //nolint:gomnd
+	binary.LittleEndian.PutUint64(props, uint64(s.Props))
+	bin = append(bin, props...)
+
+	bin = appendLP(bin, s.Address)
+	switch s.Protocol {
+	case StampDNSCrypt:
+		bin = appendLP(bin, string(s.PublicKey))
+		bin = appendLP(bin, s.Hostname)
+	case StampDoT, StampDoQ:
+		bin = appendVLP(bin, s.Hashes)
+		bin = appendLP(bin, s.Hostname)
+		if len(s.BootstrapIPs) > 0 {
+			bin = appendVLP(bin, stringsToBytes(s.BootstrapIPs))
+		}
+	case StampDoH:
+		bin = appendVLP(bin, s.Hashes)
+		bin = appendLP(bin, s.Hostname)
+		bin = appendLP(bin, s.Path)
+		if len(s.BootstrapIPs) > 0 {
+			bin = appendVLP(bin, stringsToBytes(s.BootstrapIPs))
+		}
+	}
+
+	return stampScheme + base64.RawURLEncoding.EncodeToString(bin)
+}
+
+type stampDecoder struct {
+	bin []byte
+	err error
+}
+
+func (d *stampDecoder) remaining() bool {
+	return d.err == nil && len(d.bin) > 0
+}
+
+// lp decodes a length prefixed string.
+func (d *stampDecoder) lp() string {
+	if d.err != nil {
+		return ""
+	} else if len(d.bin) == 0 {
+		d.err = fmt.Errorf("%w: missing length byte", ErrStampTooShort)
+		return ""
+	}
+	length := int(d.bin[0])
+	if len(d.bin) < 1+length {
+		d.err = fmt.Errorf("%w: missing %d bytes", ErrStampTooShort, 1+length-len(d.bin))
+		return ""
+	}
+	s := string(d.bin[1 : 1+length])
+	d.bin = d.bin[1+length:]
+	return s
+}
+
+// vlp decodes a variable length prefixed set, where the high bit of
+// each length byte is set if another element follows.
+func (d *stampDecoder) vlp() (elements [][]byte) {
+	const moreFlag = 0x80
+	for d.err == nil {
+		if len(d.bin) == 0 {
+			d.err = fmt.Errorf("%w: missing length byte", ErrStampTooShort)
+			return nil
+		}
+		length := int(d.bin[0] &^ moreFlag)
+		more := d.bin[0]&moreFlag != 0
+		if len(d.bin) < 1+length {
+			d.err = fmt.Errorf("%w: missing %d bytes", ErrStampTooShort, 1+length-len(d.bin))
+			return nil
+		}
+		if length > 0 {
+			element := make([]byte, length)
+			copy(element, d.bin[1:1+length])
+			elements = append(elements, element)
+		}
+		d.bin = d.bin[1+length:]
+		if !more {
+			break
+		}
+	}
+	return elements
+}
+
+func appendLP(bin []byte, s string) []byte {
+	bin = append(bin, byte(len(s)))
+	return append(bin, s...)
+}
+
+func appendVLP(bin []byte, elements [][]byte) []byte {
+	if len(elements) == 0 {
+		return append(bin, 0)
+	}
+	const moreFlag = 0x80
+	for i, element := range elements {
+		length := byte(len(element))
+		if i < len(elements)-1 {
+			length |= moreFlag
+		}
+		bin = append(bin, length)
+		bin = append(bin, element...)
+	}
+	return bin
+}
+
+func bytesToStrings(elements [][]byte) (values []string) {
+	values = make([]string, len(elements))
+	for i := range elements {
+		values[i] = string(elements[i])
+	}
+	return values
+}
+
+func stringsToBytes(values []string) (elements [][]byte) {
+	elements = make([][]byte, len(values))
+	for i := range values {
+		elements[i] = []byte(values[i])
+	}
+	return elements
+}
+
+var (
+	ErrStampAddressInvalid = errors.New("DNS stamp address is invalid")
+	ErrStampPortNotDNS     = errors.New("DNS stamp port is not 53 for plaintext DNS")
+)
+
+// Provider returns a provider for the server of the stamp, named after
+// the stamp hostname or DNSCrypt provider name, or after its address
+// for plaintext DNS.
+func (s Stamp) Provider() (provider Provider, err error) {
+	var settings CustomSettings
+
+	switch s.Protocol {
+	case StampPlain:
+		settings.Name = s.Address
+		ip, port, err := splitStampAddress(s.Address, "", 53) //nolint:gomnd
+		if err != nil {
+			return nil, err
+		} else if port != 53 { //nolint:gomnd
+			return nil, fmt.Errorf("%w: %d", ErrStampPortNotDNS, port)
+		}
+		settings.DNS.IPv4, settings.DNS.IPv6 = appendIP(nil, nil, ip)
+	case StampDNSCrypt:
+		ip, port, err := splitStampAddress(s.Address, "", defaultDNSCryptPort)
+		if err != nil {
+			return nil, err
+		}
+		settings.Name = s.Hostname
+		settings.DNSCrypt.IPv4, settings.DNSCrypt.IPv6 = appendIP(nil, nil, ip)
+		settings.DNSCrypt.Port = port
+		settings.DNSCrypt.ProviderName = s.Hostname
+		settings.DNSCrypt.PublicKey = s.PublicKey
+	case StampDoT:
+		ip, port, err := splitStampAddress(s.Address, s.Hostname, defaultDoTPort)
+		if err != nil {
+			return nil, err
+		}
+		settings.Name = hostnameWithoutPort(s.Hostname)
+		settings.DoT.IPv4, settings.DoT.IPv6 = appendIP(nil, nil, ip)
+		settings.DoT.Name = settings.Name
+		settings.DoT.Port = port
+		settings.DoT.CertificateHashes = s.Hashes
+	case StampDoQ:
+		ip, port, err := splitStampAddress(s.Address, s.Hostname, defaultDoQPort)
+		if err != nil {
+			return nil, err
+		}
+		settings.Name = hostnameWithoutPort(s.Hostname)
+		settings.DoQ.IPv4, settings.DoQ.IPv6 = appendIP(nil, nil, ip)
+		settings.DoQ.Name = settings.Name
+		settings.DoQ.Port = port
+		settings.DoQ.CertificateHashes = s.Hashes
+	case StampDoH:
+		const defaultDoHPort = 443
+		ip, port, err := splitStampAddress(s.Address, s.Hostname, defaultDoHPort)
+		if err != nil {
+			return nil, err
+		}
+		settings.Name = hostnameWithoutPort(s.Hostname)
+		settings.DoH.IPv4, settings.DoH.IPv6 = appendIP(nil, nil, ip)
+		host := s.Hostname
+		if port != defaultDoHPort {
+			host = net.JoinHostPort(settings.Name, strconv.Itoa(int(port)))
+		}
+		settings.DoH.URL = &url.URL{
+			Scheme: "https",
+			Host:   host,
+			Path:   s.Path,
+		}
+		settings.DoH.CertificateHashes = s.Hashes
+	default:
+		return nil, fmt.Errorf("%w: 0x%02x", ErrStampProtocolUnsupported, byte(s.Protocol))
+	}
+
+	return NewCustom(settings)
+}
+
+func hostnameWithoutPort(hostname string) string {
+	host, _, err := net.SplitHostPort(hostname)
+	if err != nil {
+		return hostname
+	}
+	return host
+}
+
+// splitStampAddress returns the IP address and port of the stamp address
+// given. If the address is empty or only a port such as :853, the hostname
+// given is used instead, and the IP address returned is nil if the hostname
+// is not an IP address. The port defaults to the port of the hostname if
+// any, and then to the default port given.
+func splitStampAddress(address, hostname string, defaultPort uint16) (
+	ip net.IP, port uint16, err error) {
+	host, portString, err := splitHostOptionalPort(address)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrStampAddressInvalid, err)
+	}
+
+	if host != "" {
+		ip = net.ParseIP(host)
+		if ip == nil {
+			return nil, 0, fmt.Errorf("%w: %s", ErrStampAddressInvalid, address)
+		}
+	} else {
+		if hostname == "" {
+			return nil, 0, fmt.Errorf("%w: %q", ErrStampAddressInvalid, address)
+		}
+		host, hostnamePort, err := splitHostOptionalPort(hostname)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: hostname: %s", ErrStampAddressInvalid, err)
+		}
+		ip = net.ParseIP(host)
+		if portString == "" {
+			portString = hostnamePort
+		}
+	}
+
+	port = defaultPort
+	if portString != "" {
+		portUint, err := strconv.ParseUint(portString, 10, 16) //nolint:gomnd
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %s", ErrStampAddressInvalid, err)
+		}
+		port = uint16(portUint)
+	}
+	return ip, port, nil
+}
+
+// splitHostOptionalPort splits an address such as 1.1.1.1, 1.1.1.1:853,
+// 2001:db8::1, [2001:db8::1], [2001:db8::1]:853 or :853 in its host and port.
+func splitHostOptionalPort(address string) (host, port string, err error) {
+	switch {
+	case strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]"):
+		return address[1 : len(address)-1], "", nil
+	case strings.HasPrefix(address, "[") || strings.Count(address, ":") == 1:
+		return net.SplitHostPort(address)
+	default:
+		return address, "", nil
+	}
+}
+
+func appendIP(ipv4, ipv6 []net.IP, ip net.IP) ([]net.IP, []net.IP) {
+	if ip == nil {
+		return ipv4, ipv6
+	}
+	if ipv4Only := ip.To4(); ipv4Only != nil {
+		return append(ipv4, ipv4Only), ipv6
+	}
+	return ipv4, append(ipv6, ip)
+}
+
+// NewDNSStamp returns a stamp for the first IP address of the DNS server.
+func NewDNSStamp(server DNSServer, props StampProps) Stamp {
+	return Stamp{
+		Protocol: StampPlain,
+		Props:    props,
+		Address:  firstIP(server.IPv4, server.IPv6, 0),
+	}
+}
+
+// NewDNSCryptStamp returns a stamp for the first IP address of the DNSCrypt server.
+func NewDNSCryptStamp(server DNSCryptServer, props StampProps) Stamp {
+	port := server.Port
+	if port == defaultDNSCryptPort {
+		port = 0
+	}
+	return Stamp{
+		Protocol:  StampDNSCrypt,
+		Props:     props,
+		Address:   firstIP(server.IPv4, server.IPv6, port),
+		PublicKey: server.PublicKey,
+		Hostname:  server.ProviderName,
+	}
+}
+
+// NewDoTStamp returns a stamp for the first IP address of the DoT server.
+func NewDoTStamp(server DoTServer, props StampProps) Stamp {
+	port := server.Port
+	if port == defaultDoTPort {
+		port = 0
+	}
+	return Stamp{
+		Protocol: StampDoT,
+		Props:    props,
+		Address:  firstIP(server.IPv4, server.IPv6, port),
+		Hashes:   server.CertificateHashes,
+		Hostname: server.Name,
+	}
+}
+
+// NewDoQStamp returns a stamp for the first IP address of the DoQ server.
+func NewDoQStamp(server DoQServer, props StampProps) Stamp {
+	port := server.Port
+	if port == defaultDoQPort {
+		port = 0
+	}
+	return Stamp{
+		Protocol: StampDoQ,
+		Props:    props,
+		Address:  firstIP(server.IPv4, server.IPv6, port),
+		Hashes:   server.CertificateHashes,
+		Hostname: server.Name,
+	}
+}
+
+// NewDoHStamp returns a stamp for the DoH server, using its first
+// bootstrap IP address if any.
+func NewDoHStamp(server DoHServer, props StampProps) Stamp {
+	stamp := Stamp{
+		Protocol: StampDoH,
+		Props:    props,
+		Address:  firstIP(server.IPv4, server.IPv6, 0),
+		Hashes:   server.CertificateHashes,
+	}
+	if server.URL != nil {
+		stamp.Hostname = server.URL.Host
+		stamp.Path = server.URL.Path
+	}
+	return stamp
+}
+
+// firstIP returns the first IP address as a stamp address, with
+// the port given unless it is 0, and empty if there is no address.
+func firstIP(ipv4, ipv6 []net.IP, port uint16) string {
+	var ip net.IP
+	switch {
+	case len(ipv4) > 0:
+		ip = ipv4[0]
+	case len(ipv6) > 0:
+		ip = ipv6[0]
+	default:
+		return ""
+	}
+
+	switch {
+	case port != 0:
+		return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+	case ip.To4() == nil:
+		return "[" + ip.String() + "]"
+	default:
+		return ip.String()
+	}
+}
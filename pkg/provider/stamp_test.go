@@ -0,0 +1,380 @@
+package provider
+
+import (
+	"bytes"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseStamp(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		s     string
+		stamp Stamp
+		err   error
+	}{
+		"plain DNS": {
+			s: "sdns://AAcAAAAAAAAABzguOC44Ljg",
+			stamp: Stamp{
+				Protocol: StampPlain,
+				Props:    StampDNSSEC | StampNoLog | StampNoFilter,
+				Address:  "8.8.8.8",
+			},
+		},
+		"DoH cloudflare": {
+			s: "sdns://AgcAAAAAAAAABzEuMC4wLjEAEmRucy5jbG91ZGZsYXJlLmNvbQovZG5zLXF1ZXJ5",
+			stamp: Stamp{
+				Protocol: StampDoH,
+				Props:    StampDNSSEC | StampNoLog | StampNoFilter,
+				Address:  "1.0.0.1",
+				Hostname: "dns.cloudflare.com",
+				Path:     "/dns-query",
+			},
+		},
+		"DNSCrypt": {
+			s: "sdns://AQMAAAAAAAAAE1syMDAxOmRiODo6NTNdOjg0NDMgAQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyAbMi5kbnNjcnlwdC1jZXJ0LmV4YW1wbGUuY29t",
+			stamp: Stamp{
+				Protocol: StampDNSCrypt,
+				Props:    StampDNSSEC | StampNoLog,
+				Address:  "[2001:db8::53]:8443",
+				PublicKey: []byte{
+					1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+					17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32,
+				},
+				Hostname: "2.dnscrypt-cert.example.com",
+			},
+		},
+		"DoT with hashes": {
+			s: "sdns://AwcAAAAAAAAABzEuMS4xLjGgqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqogu7u7u7u7u7u7u7u7u7u7u7u7u7u7u7u7u7u7u7u7u7sSY2xvdWRmbGFyZS1kbnMuY29t",
+			stamp: Stamp{
+				Protocol: StampDoT,
+				Props:    StampDNSSEC | StampNoLog | StampNoFilter,
+				Address:  "1.1.1.1",
+				Hashes: [][]byte{
+					bytes.Repeat([]byte{0xaa}, 32),
+					bytes.Repeat([]byte{0xbb}, 32),
+				},
+				Hostname: "cloudflare-dns.com",
+			},
+		},
+		"DoH with path and port": {
+			s: "sdns://AgEAAAAAAAAAAAAUZG9oLmV4YW1wbGUuY29tOjQ0NDMRL2N1c3RvbS9kbnMtcXVlcnk",
+			stamp: Stamp{
+				Protocol: StampDoH,
+				Props:    StampDNSSEC,
+				Hostname: "doh.example.com:4443",
+				Path:     "/custom/dns-query",
+			},
+		},
+		"no scheme": {
+			s:   "AAcAAAAAAAAABzguOC44Ljg",
+			err: ErrStampScheme,
+		},
+		"bad encoding": {
+			s:   "sdns://AAcA+AA",
+			err: ErrStampEncoding,
+		},
+		"too short": {
+			s:   "sdns://AAcA",
+			err: ErrStampTooShort,
+		},
+		"truncated address": {
+			s:   "sdns://AAcAAAAAAAAABzguOC44",
+			err: ErrStampTooShort,
+		},
+		"DNSCrypt without public key": {
+			s:   "sdns://AQcAAAAAAAAABzguOC44Ljg",
+			err: ErrStampTooShort,
+		},
+		"DNSCrypt relay": {
+			s:   "sdns://gQcAAAAAAAAABzguOC44Ljg",
+			err: ErrStampProtocolUnsupported,
+		},
+		"trailing bytes": {
+			s:   "sdns://AAcAAAAAAAAABzguOC44LjgA",
+			err: ErrStampGarbage,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := ParseStamp(testCase.s)
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.stamp, stamp)
+			assert.Equal(t, testCase.s, stamp.String())
+		})
+	}
+}
+
+func Test_Stamp_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	stamps := map[string]Stamp{
+		"DoT with hashes and bootstrap": {
+			Protocol:     StampDoT,
+			Props:        StampNoLog,
+			Address:      "[2001:db8::53]:8853",
+			Hashes:       [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}},
+			Hostname:     "dns.example.com",
+			BootstrapIPs: []string{"9.9.9.9", "1.1.1.1"},
+		},
+		"DNSCrypt": {
+			Protocol:  StampDNSCrypt,
+			Props:     StampDNSSEC | StampNoLog,
+			Address:   "10.0.0.53:8443",
+			PublicKey: []byte{1, 2, 3, 4},
+			Hostname:  "2.dnscrypt-cert.example.com",
+		},
+		"DoQ with port": {
+			Protocol: StampDoQ,
+			Address:  "10.0.0.53:8853",
+			Hostname: "dns.example.com",
+		},
+		"DoH without address": {
+			Protocol: StampDoH,
+			Hashes:   [][]byte{{0xff}},
+			Hostname: "dns.example.com:4443",
+			Path:     "/dns-query",
+		},
+	}
+
+	for name, stamp := range stamps {
+		stamp := stamp
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			parsed, err := ParseStamp(stamp.String())
+
+			require.NoError(t, err)
+			assert.Equal(t, stamp, parsed)
+		})
+	}
+}
+
+func Test_Stamp_Provider(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stamp Stamp
+		name  string
+		dns   DNSServer
+		dot   DoTServer
+		doh   DoHServer
+		doq   DoQServer
+		crypt DNSCryptServer
+		err   error
+	}{
+		"plain DNS": {
+			stamp: Stamp{Protocol: StampPlain, Address: "8.8.8.8"},
+			name:  "8.8.8.8",
+			dns:   DNSServer{IPv4: []net.IP{{8, 8, 8, 8}}},
+		},
+		"plain DNS with other port": {
+			stamp: Stamp{Protocol: StampPlain, Address: "8.8.8.8:5353"},
+			err:   ErrStampPortNotDNS,
+		},
+		"DoT": {
+			stamp: Stamp{
+				Protocol: StampDoT,
+				Address:  "[2001:db8::53]:8853",
+				Hashes:   [][]byte{{1, 2}},
+				Hostname: "dns.example.com",
+			},
+			name: "dns.example.com",
+			dot: DoTServer{
+				IPv6:              []net.IP{net.ParseIP("2001:db8::53")},
+				Name:              "dns.example.com",
+				Port:              8853,
+				CertificateHashes: [][]byte{{1, 2}},
+			},
+		},
+		"DoT with invalid address": {
+			stamp: Stamp{Protocol: StampDoT, Address: "dns.example.com", Hostname: "dns.example.com"},
+			err:   ErrStampAddressInvalid,
+		},
+		"DoT without address": {
+			stamp: Stamp{Protocol: StampDoT, Hostname: "1.1.1.1"},
+			name:  "1.1.1.1",
+			dot: DoTServer{
+				IPv4: []net.IP{{1, 1, 1, 1}},
+				Name: "1.1.1.1",
+				Port: 853,
+			},
+		},
+		"DoT without address and IP": {
+			stamp: Stamp{Protocol: StampDoT, Hostname: "dns.example.com"},
+			err:   ErrCustomDoTNoIP,
+		},
+		"DoQ with port only address": {
+			stamp: Stamp{Protocol: StampDoQ, Address: ":8853", Hostname: "[2001:db8::53]:784"},
+			name:  "2001:db8::53",
+			doq: DoQServer{
+				IPv6: []net.IP{net.ParseIP("2001:db8::53")},
+				Name: "2001:db8::53",
+				Port: 8853,
+			},
+		},
+		"DNSCrypt without address": {
+			stamp: Stamp{
+				Protocol:  StampDNSCrypt,
+				PublicKey: make([]byte, 32),
+				Hostname:  "2.dnscrypt-cert.example.com",
+			},
+			err: ErrStampAddressInvalid,
+		},
+		"DoQ": {
+			stamp: Stamp{
+				Protocol: StampDoQ,
+				Address:  "94.140.14.14",
+				Hostname: "dns.adguard-dns.com",
+			},
+			name: "dns.adguard-dns.com",
+			doq: DoQServer{
+				IPv4: []net.IP{{94, 140, 14, 14}},
+				Name: "dns.adguard-dns.com",
+				Port: 853,
+			},
+		},
+		"DNSCrypt": {
+			stamp: Stamp{
+				Protocol:  StampDNSCrypt,
+				Address:   "10.0.0.53",
+				PublicKey: make([]byte, 32),
+				Hostname:  "2.dnscrypt-cert.example.com",
+			},
+			name: "2.dnscrypt-cert.example.com",
+			crypt: DNSCryptServer{
+				IPv4:         []net.IP{{10, 0, 0, 53}},
+				Port:         443,
+				ProviderName: "2.dnscrypt-cert.example.com",
+				PublicKey:    make([]byte, 32),
+			},
+		},
+		"DNSCrypt with invalid public key": {
+			stamp: Stamp{
+				Protocol:  StampDNSCrypt,
+				Address:   "10.0.0.53",
+				PublicKey: []byte{1},
+				Hostname:  "2.dnscrypt-cert.example.com",
+			},
+			err: ErrCustomDNSCryptKeyInvalid,
+		},
+		"DoH": {
+			stamp: Stamp{
+				Protocol: StampDoH,
+				Address:  "1.0.0.1",
+				Hostname: "dns.cloudflare.com",
+				Path:     "/dns-query",
+			},
+			name: "dns.cloudflare.com",
+			doh: DoHServer{
+				URL:  &url.URL{Scheme: "https", Host: "dns.cloudflare.com", Path: "/dns-query"},
+				IPv4: []net.IP{{1, 0, 0, 1}},
+			},
+		},
+		"DoH without address": {
+			stamp: Stamp{
+				Protocol: StampDoH,
+				Hostname: "doh.example.com:4443",
+				Path:     "/custom/dns-query",
+			},
+			name: "doh.example.com",
+			doh: DoHServer{
+				URL: &url.URL{Scheme: "https", Host: "doh.example.com:4443", Path: "/custom/dns-query"},
+			},
+		},
+		"DoH with port only address": {
+			stamp: Stamp{
+				Protocol: StampDoH,
+				Address:  ":8443",
+				Hostname: "doh.example.com",
+				Path:     "/dns-query",
+			},
+			name: "doh.example.com",
+			doh: DoHServer{
+				URL: &url.URL{Scheme: "https", Host: "doh.example.com:8443", Path: "/dns-query"},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			provider, err := testCase.stamp.Provider()
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.name, provider.String())
+			assert.Equal(t, testCase.dns, provider.DNS())
+			assert.Equal(t, testCase.dot, provider.DoT())
+			assert.Equal(t, testCase.doh, provider.DoH())
+			assert.Equal(t, testCase.doq, provider.DoQ())
+			assert.Equal(t, testCase.crypt, provider.DNSCrypt())
+		})
+	}
+}
+
+func Test_NewStamps(t *testing.T) {
+	t.Parallel()
+
+	cloudflare := Cloudflare()
+
+	assert.Equal(t, "sdns://AAcAAAAAAAAABzEuMS4xLjE",
+		NewDNSStamp(cloudflare.DNS(), StampDNSSEC|StampNoLog|StampNoFilter).String())
+
+	dotStamp := NewDoTStamp(cloudflare.DoT(), 0)
+	assert.Equal(t, Stamp{
+		Protocol: StampDoT,
+		Address:  "1.1.1.1",
+		Hostname: "cloudflare-dns.com",
+	}, dotStamp)
+
+	doqStamp := NewDoQStamp(AdGuard().DoQ(), StampDNSSEC)
+	assert.Equal(t, Stamp{
+		Protocol: StampDoQ,
+		Props:    StampDNSSEC,
+		Address:  "94.140.14.14",
+		Hostname: "dns.adguard-dns.com",
+	}, doqStamp)
+
+	dnsCryptStamp := NewDNSCryptStamp(DNSCryptServer{
+		IPv4:         []net.IP{{10, 0, 0, 53}},
+		Port:         443,
+		ProviderName: "2.dnscrypt-cert.example.com",
+		PublicKey:    []byte{1, 2},
+	}, 0)
+	assert.Equal(t, Stamp{
+		Protocol:  StampDNSCrypt,
+		Address:   "10.0.0.53",
+		PublicKey: []byte{1, 2},
+		Hostname:  "2.dnscrypt-cert.example.com",
+	}, dnsCryptStamp)
+
+	dohStamp := NewDoHStamp(cloudflare.DoH(), 0)
+	assert.Equal(t, Stamp{
+		Protocol: StampDoH,
+		Address:  "1.1.1.1",
+		Hostname: "cloudflare-dns.com",
+		Path:     "/dns-query",
+	}, dohStamp)
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// ErrPinMismatch is returned by the TLS handshake if no certificate
+// of the chain matches the pins of the server, which can indicate
+// a man in the middle attack using a compromised certificate authority.
+var ErrPinMismatch = errors.New("certificate pin mismatch")
+
+// SPKIPin returns the SHA256 digest of the subject public key info
+// of the certificate given, to be used in the SPKIPins fields.
+func SPKIPin(certificate *x509.Certificate) (pin []byte) {
+	digest := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return digest[:]
+}
+
+// TLSConfig returns the TLS configuration to connect to the DoT server,
+// verifying its certificate with its root certificate authorities and
+// its pins if any are set.
+func (s DoTServer) TLSConfig() *tls.Config {
+	return newTLSConfig(s.Name, s.RootCAs, s.SPKIPins, s.CertificateHashes)
+}
+
+// TLSConfig returns the TLS configuration to connect to the DoH server,
+// verifying its certificate with its root certificate authorities and
+// its pins if any are set.
+func (s DoHServer) TLSConfig() *tls.Config {
+	var serverName string
+	if s.URL != nil {
+		serverName = s.URL.Hostname()
+	}
+	return newTLSConfig(serverName, s.RootCAs, s.SPKIPins, s.CertificateHashes)
+}
+
+// TLSConfig returns the TLS configuration to connect to the DoQ server,
+// verifying its certificate with its root certificate authorities and
+// its pins if any are set. It requires TLS 1.3 and negotiates the doq
+// application protocol, as required by RFC 9250.
+func (s DoQServer) TLSConfig() *tls.Config {
+	tlsConfig := newTLSConfig(s.Name, s.RootCAs, s.SPKIPins, s.CertificateHashes)
+	tlsConfig.MinVersion = tls.VersionTLS13
+	tlsConfig.NextProtos = []string{DoQALPN}
+	return tlsConfig
+}
+
+// DoQALPN is the application layer protocol negotiation
+// token for DNS over QUIC, defined in RFC 9250.
+const DoQALPN = "doq"
+
+func newTLSConfig(serverName string, rootCAs *x509.CertPool,
+	spkiPins, certificateHashes [][]byte) *tls.Config {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		RootCAs:    rootCAs,
+	}
+	if len(spkiPins) > 0 || len(certificateHashes) > 0 {
+		tlsConfig.VerifyPeerCertificate = newPinsVerifier(serverName, spkiPins, certificateHashes)
+	}
+	return tlsConfig
+}
+
+type verifyPeerCertificateFunc func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+
+// newPinsVerifier returns a function verifying that at least one certificate
+// of the verified chains matches one of the SPKI pins or certificate hashes.
+// It is called after the chains are verified with the root certificate authorities.
+func newPinsVerifier(serverName string, spkiPins, certificateHashes [][]byte) verifyPeerCertificateFunc {
+	return func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			for _, certificate := range chain {
+				if containsDigest(spkiPins, SPKIPin(certificate)) {
+					return nil
+				}
+				tbsDigest := sha256.Sum256(certificate.RawTBSCertificate)
+				if containsDigest(certificateHashes, tbsDigest[:]) {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%w: for %s", ErrPinMismatch, serverName)
+	}
+}
+
+func containsDigest(digests [][]byte, digest []byte) bool {
+	for _, d := range digests {
+		if bytes.Equal(d, digest) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DoTServer_TLSConfig(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	certificate := server.Certificate()
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(certificate)
+	tbsDigest := sha256.Sum256(certificate.RawTBSCertificate)
+
+	testCases := map[string]struct {
+		server DoTServer
+		err    error
+	}{
+		"no pin": {
+			server: DoTServer{Name: "example.com", RootCAs: rootCAs},
+		},
+		"matching SPKI pin": {
+			server: DoTServer{
+				Name:     "example.com",
+				RootCAs:  rootCAs,
+				SPKIPins: [][]byte{{1}, SPKIPin(certificate)},
+			},
+		},
+		"matching certificate hash": {
+			server: DoTServer{
+				Name:              "example.com",
+				RootCAs:           rootCAs,
+				CertificateHashes: [][]byte{tbsDigest[:]},
+			},
+		},
+		"pin mismatch": {
+			server: DoTServer{
+				Name:     "example.com",
+				RootCAs:  rootCAs,
+				SPKIPins: [][]byte{make([]byte, sha256.Size)},
+			},
+			err: ErrPinMismatch,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			conn, err := tls.Dial("tcp", server.Listener.Addr().String(),
+				testCase.server.TLSConfig())
+
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NoError(t, conn.Close())
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package safesearch rewrites DNS requests for search engines and
+// YouTube hostnames to their safe search or restricted mode hostnames.
+package safesearch
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	googleTarget     = "forcesafesearch.google.com."
+	youtubeTarget    = "restrict.youtube.com."
+	bingTarget       = "strict.bing.com."
+	duckduckgoTarget = "safe.duckduckgo.com."
+)
+
+// googleDomains are the Google search domains supported
+// by forcesafesearch.google.com, separated by white spaces.
+const googleDomains = `
+google.com google.ad google.ae google.com.af google.com.ag google.com.ai google.al
+google.am google.co.ao google.com.ar google.as google.at google.com.au google.az google.ba
+google.com.bd google.be google.bf google.bg google.com.bh google.bi google.bj
+google.com.bn google.com.bo google.com.br google.bs google.bt google.co.bw google.by
+google.com.bz google.ca google.cat google.cd google.cf google.cg google.ch google.ci
+google.co.ck google.cl google.cm google.cn google.com.co google.co.cr google.com.cu
+google.cv google.com.cy google.cz google.de google.dj google.dk google.dm google.com.do
+google.dz google.com.ec google.ee google.com.eg google.es google.com.et google.fi
+google.com.fj google.fm google.fr google.ga google.ge google.gg google.com.gh
+google.com.gi google.gl google.gm google.gr google.com.gt google.gy google.com.hk
+google.hn google.hr google.ht google.hu google.co.id google.ie google.co.il google.im
+google.co.in google.iq google.is google.it google.je google.com.jm google.jo google.co.jp
+google.co.ke google.com.kh google.ki google.kg google.co.kr google.com.kw google.kz
+google.la google.com.lb google.li google.lk google.co.ls google.lt google.lu google.lv
+google.com.ly google.co.ma google.md google.me google.mg google.mk google.ml google.com.mm
+google.mn google.ms google.com.mt google.mu google.mv google.mw google.com.mx
+google.com.my google.co.mz google.com.na google.com.ng google.com.ni google.ne google.nl
+google.no google.com.np google.nr google.nu google.co.nz google.com.om google.com.pa
+google.com.pe google.com.pg google.com.ph google.com.pk google.pl google.pn google.com.pr
+google.ps google.pt google.com.py google.com.qa google.ro google.ru google.rw
+google.com.sa google.com.sb google.sc google.se google.com.sg google.sh google.si
+google.sk google.com.sl google.sn google.so google.sm google.sr google.st google.com.sv
+google.td google.tg google.co.th google.com.tj google.tl google.tm google.tn google.to
+google.com.tr google.tt google.com.tw google.co.tz google.com.ua google.co.ug google.co.uk
+google.com.uy google.co.uz google.com.vc google.co.ve google.vg google.co.vi google.com.vn
+google.vu google.ws google.rs google.co.za google.co.zm google.co.zw`
+
+// Rewrites returns a map from FQDN hostnames to the FQDN
+// hostname they should be rewritten to using a CNAME record.
+func Rewrites() (rewrites map[string]string) {
+	domains := strings.Fields(googleDomains)
+	const youtubeHostnames, bingHostnames, duckduckgoHostnames = 5, 2, 3
+	rewrites = make(map[string]string, 2*len(domains)+ //nolint:gomnd
+		youtubeHostnames+bingHostnames+duckduckgoHostnames)
+
+	for _, domain := range domains {
+		rewrites[domain+"."] = googleTarget
+		rewrites["www."+domain+"."] = googleTarget
+	}
+
+	rewrites["www.youtube.com."] = youtubeTarget
+	rewrites["m.youtube.com."] = youtubeTarget
+	rewrites["youtubei.googleapis.com."] = youtubeTarget
+	rewrites["youtube.googleapis.com."] = youtubeTarget
+	rewrites["www.youtube-nocookie.com."] = youtubeTarget
+
+	rewrites["bing.com."] = bingTarget
+	rewrites["www.bing.com."] = bingTarget
+
+	rewrites["duckduckgo.com."] = duckduckgoTarget
+	rewrites["www.duckduckgo.com."] = duckduckgoTarget
+	rewrites["start.duckduckgo.com."] = duckduckgoTarget
+
+	return rewrites
+}
+
+// Rewriter rewrites DNS requests to use safe search hostnames.
+type Rewriter struct {
+	rewrites map[string]string
+}
+
+// NewRewriter creates a Rewriter for the hostnames returned by Rewrites.
+func NewRewriter() *Rewriter {
+	return &Rewriter{
+		rewrites: Rewrites(),
+	}
+}
+
+// Rewrite returns a copy of the request for the safe search hostname
+// together with the CNAME record to prepend to the answer of the
+// response, if the request is for a hostname to rewrite.
+// Otherwise, it returns the request given and a nil CNAME record.
+func (r *Rewriter) Rewrite(request *dns.Msg) (rewritten *dns.Msg, cname *dns.CNAME) {
+	if len(request.Question) != 1 {
+		return request, nil
+	}
+
+	question := request.Question[0]
+	target, ok := r.rewrites[strings.ToLower(question.Name)]
+	if !ok {
+		return request, nil
+	}
+
+	rewritten = request.Copy()
+	rewritten.Question[0].Name = target
+
+	const ttl = 300
+	cname = &dns.CNAME{
+		Hdr: dns.RR_Header{
+			Name:   question.Name,
+			Rrtype: dns.TypeCNAME,
+			Class:  question.Qclass,
+			Ttl:    ttl,
+		},
+		Target: target,
+	}
+
+	return rewritten, cname
+}
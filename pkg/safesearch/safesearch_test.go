@@ -0,0 +1,67 @@
+package safesearch
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Rewriter_Rewrite(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		name   string
+		target string
+	}{
+		"not rewritten": {
+			name: "github.com.",
+		},
+		"google": {
+			name:   "www.google.com.",
+			target: "forcesafesearch.google.com.",
+		},
+		"google country domain": {
+			name:   "google.co.uk.",
+			target: "forcesafesearch.google.com.",
+		},
+		"upper case": {
+			name:   "WWW.Bing.com.",
+			target: "strict.bing.com.",
+		},
+		"youtube": {
+			name:   "m.youtube.com.",
+			target: "restrict.youtube.com.",
+		},
+		"duckduckgo": {
+			name:   "duckduckgo.com.",
+			target: "safe.duckduckgo.com.",
+		},
+	}
+
+	rewriter := NewRewriter()
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			request := new(dns.Msg).SetQuestion(testCase.name, dns.TypeA)
+
+			rewritten, cname := rewriter.Rewrite(request)
+
+			assert.Equal(t, testCase.name, request.Question[0].Name)
+
+			if testCase.target == "" {
+				assert.Same(t, request, rewritten)
+				assert.Nil(t, cname)
+				return
+			}
+
+			assert.Equal(t, testCase.target, rewritten.Question[0].Name)
+			assert.Equal(t, dns.TypeA, rewritten.Question[0].Qtype)
+			assert.Equal(t, testCase.name, cname.Hdr.Name)
+			assert.Equal(t, testCase.target, cname.Target)
+		})
+	}
+}
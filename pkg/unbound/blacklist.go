@@ -1,15 +1,177 @@
 package unbound
 
-import "github.com/qdm12/dns/pkg/blacklist"
+import (
+	"strings"
+
+	"github.com/qdm12/dns/pkg/accesscontrol"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"inet.af/netaddr"
+)
+
+// convertBlockedToConfigLines returns the Unbound server configuration
+// lines for the blacklist. If rpz is true, blocked hostnames are omitted
+// since they are in response policy zone files instead. The access control
+// settings are used to keep the access of the netblocks tagged for client
+// groups unchanged.
+func convertBlockedToConfigLines(settings blacklist.Settings,
+	accessControl accesscontrol.Settings, rpz bool) (configLines []string) {
+	if len(settings.Groups) > 0 {
+		return convertGroupsBlockedToConfigLines(settings, accessControl, rpz)
+	}
 
-func convertBlockedToConfigLines(settings blacklist.Settings) (configLines []string) {
 	size := len(settings.FqdnHostnames) + len(settings.IPs) + len(settings.IPPrefixes)
 	configLines = make([]string, 0, size)
 
-	for _, blockedHostname := range settings.FqdnHostnames {
-		configLines = append(configLines, "  local-zone: \""+blockedHostname+"\" static")
+	if !rpz {
+		for _, blockedHostname := range settings.FqdnHostnames {
+			configLines = append(configLines, "  local-zone: \""+blockedHostname+"\" static")
+		}
+	}
+
+	configLines = append(configLines, convertBlockedIPsToConfigLines(settings)...)
+
+	if settings.SafeSearch {
+		blocked := make(map[string]struct{}, len(settings.FqdnHostnames))
+		for _, hostname := range settings.FqdnHostnames {
+			blocked[hostname] = struct{}{}
+		}
+		configLines = append(configLines, convertSafeSearchToConfigLines(nil,
+			func(hostname string) bool {
+				_, ok := blocked[hostname]
+				return ok
+			})...)
+	}
+
+	return configLines
+}
+
+// defaultTag is the Unbound tag used for clients not in any client group.
+const defaultTag = "default"
+
+// convertGroupsBlockedToConfigLines uses Unbound tags to block hostnames
+// for each client group. Clients not matching any group subnet get the
+// default tag through the 0.0.0.0/0 and ::/0 access-control-tag lines,
+// since Unbound uses the most specific subnet matching the client.
+// Client groups MAC addresses cannot be used by Unbound, and Unbound
+// private-address options cannot be tagged so only the top level blocked
+// IP addresses and IP networks are used, for all clients.
+// Unbound implicitly allows a tagged netblock without access-control
+// entry, so an access-control line with the action of the user rules
+// is added for each tagged netblock not already having one.
+func convertGroupsBlockedToConfigLines(settings blacklist.Settings,
+	accessControl accesscontrol.Settings, rpz bool) (configLines []string) {
+	tags := make([]string, 0, len(settings.Groups)+1)
+	tags = append(tags, defaultTag)
+	for _, group := range settings.Groups {
+		tags = append(tags, group.Clients.Name)
+	}
+
+	configLines = append(configLines, "  define-tag: \""+strings.Join(tags, " ")+"\"")
+	addTag := func(subnet netaddr.IPPrefix, tag string) {
+		subnet = subnet.Masked()
+		if !hasAccessControlRule(accessControl, subnet) {
+			configLines = append(configLines, "  access-control: "+subnet.String()+" "+
+				string(netblockAction(accessControl, subnet)))
+		}
+		configLines = append(configLines,
+			"  access-control-tag: "+subnet.String()+" \""+tag+"\"")
+	}
+	addTag(netaddr.IPPrefix{IP: netaddr.IPv4(0, 0, 0, 0)}, defaultTag)
+	addTag(netaddr.IPPrefix{IP: netaddr.IPv6Unspecified()}, defaultTag)
+	for _, group := range settings.Groups {
+		for _, subnet := range group.Clients.Subnets {
+			addTag(subnet, group.Clients.Name)
+		}
+	}
+
+	// hostnames keeps the order in which hostnames are first found
+	// so the configuration generated is deterministic.
+	var hostnames []string
+	hostnameToTags := make(map[string][]string)
+	addHostnames := func(fqdnHostnames []string, tag string) {
+		for _, hostname := range fqdnHostnames {
+			hostnameTags, ok := hostnameToTags[hostname]
+			if !ok {
+				hostnames = append(hostnames, hostname)
+			}
+			hostnameToTags[hostname] = append(hostnameTags, tag)
+		}
+	}
+	addHostnames(settings.FqdnHostnames, defaultTag)
+	for _, group := range settings.Groups {
+		addHostnames(group.Blacklist.FqdnHostnames, group.Clients.Name)
+	}
+
+	if !rpz {
+		for _, hostname := range hostnames {
+			configLines = append(configLines,
+				"  local-zone: \""+hostname+"\" static",
+				"  local-zone-tag: \""+hostname+"\" \""+strings.Join(hostnameToTags[hostname], " ")+"\"",
+			)
+		}
 	}
 
+	configLines = append(configLines, convertBlockedIPsToConfigLines(settings)...)
+
+	var safeSearchTags []string
+	if settings.SafeSearch {
+		safeSearchTags = append(safeSearchTags, defaultTag)
+	}
+	for _, group := range settings.Groups {
+		if group.Blacklist.SafeSearch {
+			safeSearchTags = append(safeSearchTags, group.Clients.Name)
+		}
+	}
+	if len(safeSearchTags) > 0 {
+		configLines = append(configLines, convertSafeSearchToConfigLines(safeSearchTags,
+			func(hostname string) bool {
+				_, ok := hostnameToTags[hostname]
+				return ok
+			})...)
+	}
+
+	return configLines
+}
+
+// hasAccessControlRule returns true if an access control
+// rule exists for exactly the subnet given.
+func hasAccessControlRule(settings accesscontrol.Settings, subnet netaddr.IPPrefix) bool {
+	for _, rule := range settings.Rules {
+		if rule.Subnet.Masked() == subnet {
+			return true
+		}
+	}
+	return false
+}
+
+// netblockAction returns the action of the most specific access control
+// rule containing the whole subnet given. If no rule contains it, loopback
+// subnets are allowed and other subnets are refused, as Unbound does.
+func netblockAction(settings accesscontrol.Settings, subnet netaddr.IPPrefix) (
+	action accesscontrol.Action) {
+	bits := -1
+	for _, rule := range settings.Rules {
+		if rule.Subnet.Bits <= subnet.Bits && int(rule.Subnet.Bits) > bits &&
+			rule.Subnet.Contains(subnet.IP) {
+			action = rule.Action
+			bits = int(rule.Subnet.Bits)
+		}
+	}
+
+	if bits == -1 {
+		if subnet.IP.IsLoopback() {
+			return accesscontrol.Allow
+		}
+		return accesscontrol.Refuse
+	}
+
+	return action
+}
+
+func convertBlockedIPsToConfigLines(settings blacklist.Settings) (configLines []string) {
+	configLines = make([]string, 0, len(settings.IPs)+len(settings.IPPrefixes)+
+		len(settings.PrivateFqdnHostnames))
+
 	for _, blockedIP := range settings.IPs {
 		configLines = append(configLines, "  private-address: "+blockedIP.String())
 	}
@@ -18,5 +180,9 @@ func convertBlockedToConfigLines(settings blacklist.Settings) (configLines []str
 		configLines = append(configLines, "  private-address: "+blockedIPPrefix.String())
 	}
 
+	for _, privateHostname := range settings.PrivateFqdnHostnames {
+		configLines = append(configLines, "  private-domain: \""+privateHostname+"\"")
+	}
+
 	return configLines
 }
@@ -3,6 +3,7 @@ package unbound
 import (
 	"testing"
 
+	"github.com/qdm12/dns/pkg/accesscontrol"
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/stretchr/testify/assert"
 	"inet.af/netaddr"
@@ -11,9 +12,14 @@ import (
 func Test_convertBlockedToConfigLines(t *testing.T) {
 	t.Parallel()
 
+	allowAll := accesscontrol.Settings{}
+	allowAll.SetDefaults()
+
 	tests := map[string]struct {
-		settings    blacklist.Settings
-		configLines []string
+		settings      blacklist.Settings
+		accessControl accesscontrol.Settings
+		rpz           bool
+		configLines   []string
 	}{
 		"none blocked": {
 			configLines: []string{},
@@ -29,6 +35,7 @@ func Test_convertBlockedToConfigLines(t *testing.T) {
 					IP:   netaddr.IPv4(5, 5, 5, 5),
 					Bits: 16,
 				}},
+				PrivateFqdnHostnames: []string{"home.lan."},
 			},
 			configLines: []string{
 				"  local-zone: \"sitea\" static",
@@ -36,6 +43,96 @@ func Test_convertBlockedToConfigLines(t *testing.T) {
 				"  private-address: 1.2.3.4",
 				"  private-address: 4.3.2.1",
 				"  private-address: 5.5.5.5/16",
+				"  private-domain: \"home.lan.\"",
+			},
+		},
+		"client groups": {
+			settings: blacklist.Settings{
+				FqdnHostnames: []string{"sitea", "siteb"},
+				IPs:           []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)},
+				Groups: []blacklist.GroupSettings{
+					{
+						Clients: blacklist.ClientGroup{
+							Name: "kids",
+							Subnets: []netaddr.IPPrefix{
+								{IP: netaddr.IPv4(10, 0, 0, 0), Bits: 24},
+								{IP: netaddr.IPv4(10, 0, 1, 0), Bits: 24},
+							},
+						},
+						Blacklist: blacklist.Settings{
+							FqdnHostnames: []string{"siteb", "sitec"},
+						},
+					},
+					{
+						Clients: blacklist.ClientGroup{
+							Name: "servers",
+							Subnets: []netaddr.IPPrefix{
+								{IP: netaddr.IPv4(10, 0, 2, 0), Bits: 24},
+							},
+						},
+					},
+				},
+			},
+			accessControl: allowAll,
+			configLines: []string{
+				"  define-tag: \"default kids servers\"",
+				"  access-control-tag: 0.0.0.0/0 \"default\"",
+				"  access-control-tag: ::/0 \"default\"",
+				"  access-control: 10.0.0.0/24 allow",
+				"  access-control-tag: 10.0.0.0/24 \"kids\"",
+				"  access-control: 10.0.1.0/24 allow",
+				"  access-control-tag: 10.0.1.0/24 \"kids\"",
+				"  access-control: 10.0.2.0/24 allow",
+				"  access-control-tag: 10.0.2.0/24 \"servers\"",
+				"  local-zone: \"sitea\" static",
+				"  local-zone-tag: \"sitea\" \"default\"",
+				"  local-zone: \"siteb\" static",
+				"  local-zone-tag: \"siteb\" \"default kids\"",
+				"  local-zone: \"sitec\" static",
+				"  local-zone-tag: \"sitec\" \"kids\"",
+				"  private-address: 1.2.3.4",
+			},
+		},
+		"client groups with restrictive access control": {
+			settings: blacklist.Settings{
+				Groups: []blacklist.GroupSettings{
+					{
+						Clients: blacklist.ClientGroup{
+							Name: "kids",
+							Subnets: []netaddr.IPPrefix{
+								{IP: netaddr.IPv4(192, 168, 1, 0), Bits: 24},
+								{IP: netaddr.IPv4(10, 0, 0, 0), Bits: 24},
+							},
+						},
+					},
+				},
+			},
+			accessControl: accesscontrol.Settings{
+				Rules: []accesscontrol.Rule{{
+					Subnet: netaddr.IPPrefix{IP: netaddr.IPv4(192, 168, 0, 0), Bits: 16},
+					Action: accesscontrol.Allow,
+				}},
+			},
+			configLines: []string{
+				"  define-tag: \"default kids\"",
+				"  access-control: 0.0.0.0/0 refuse",
+				"  access-control-tag: 0.0.0.0/0 \"default\"",
+				"  access-control: ::/0 refuse",
+				"  access-control-tag: ::/0 \"default\"",
+				"  access-control: 192.168.1.0/24 allow",
+				"  access-control-tag: 192.168.1.0/24 \"kids\"",
+				"  access-control: 10.0.0.0/24 refuse",
+				"  access-control-tag: 10.0.0.0/24 \"kids\"",
+			},
+		},
+		"response policy zones": {
+			settings: blacklist.Settings{
+				FqdnHostnames: []string{"sitea"},
+				IPs:           []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)},
+			},
+			rpz: true,
+			configLines: []string{
+				"  private-address: 1.2.3.4",
 			},
 		},
 	}
@@ -44,7 +141,7 @@ func Test_convertBlockedToConfigLines(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			configLines := convertBlockedToConfigLines(tc.settings)
+			configLines := convertBlockedToConfigLines(tc.settings, tc.accessControl, tc.rpz)
 
 			assert.Equal(t, tc.configLines, configLines)
 		})
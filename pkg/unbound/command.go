@@ -48,3 +48,41 @@ func (c *configurator) Version(ctx context.Context) (version string, err error)
 	}
 	return version, nil
 }
+
+// Reload reloads the configuration of the running Unbound instance
+// using unbound-control, which must be in the same directory as the
+// unbound program. It tries to keep the cache with reload_keep_cache,
+// and falls back to reload for Unbound versions not supporting it.
+func (c *configurator) Reload(ctx context.Context) (err error) {
+	controlPath := filepath.Join(filepath.Dir(c.unboundPath), unboundControlFilename)
+	configFilepath := filepath.Join(c.unboundEtcDir, unboundConfigFilename)
+
+	var output string
+	for _, command := range []string{"reload_keep_cache", "reload"} {
+		cmd := exec.CommandContext(ctx, controlPath, "-c", configFilepath, command) //nolint:gosec
+		output, err = c.cmder.Run(cmd)
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("unbound-control reload: %w: %s", err, output)
+}
+
+// ReloadZones reloads the response policy zones of the running Unbound
+// instance from their files using unbound-control auth_zone_reload,
+// without reloading its configuration nor flushing its cache.
+func (c *configurator) ReloadZones(ctx context.Context, settings Settings) (err error) {
+	controlPath := filepath.Join(filepath.Dir(c.unboundPath), unboundControlFilename)
+	configFilepath := filepath.Join(c.unboundEtcDir, unboundConfigFilename)
+
+	for _, zone := range getRPZZones(settings.Blacklist) {
+		zoneName := rpzZoneName(zone)
+		cmd := exec.CommandContext(ctx, controlPath, "-c", configFilepath, //nolint:gosec
+			"auth_zone_reload", zoneName)
+		output, err := c.cmder.Run(cmd)
+		if err != nil {
+			return fmt.Errorf("unbound-control auth_zone_reload %s: %w: %s", zoneName, err, output)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/golibs/command"
 	"github.com/qdm12/golibs/command/mock_command"
 	"github.com/stretchr/testify/assert"
@@ -99,3 +100,95 @@ func Test_Version(t *testing.T) {
 		})
 	}
 }
+
+func Test_Reload(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		keepCacheErr error
+		reloadCalled bool
+		reloadOutput string
+		reloadErr    error
+		err          error
+	}{
+		"reload keeping cache": {},
+		"reload keeping cache not supported": {
+			keepCacheErr: fmt.Errorf("exit status 1"),
+			reloadCalled: true,
+		},
+		"reload error": {
+			keepCacheErr: fmt.Errorf("exit status 1"),
+			reloadCalled: true,
+			reloadOutput: "error: connect: No such file or directory",
+			reloadErr:    fmt.Errorf("exit status 1"),
+			err: fmt.Errorf("unbound-control reload: exit status 1: " +
+				"error: connect: No such file or directory"),
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			cmder := mock_command.NewMockRunStarter(mockCtrl)
+			ctx := context.Background()
+
+			const unboundEtcDir = "/unbound"
+			const unboundPath = "/usr/sbin/unbound"
+
+			cmd := exec.CommandContext(ctx, "/usr/sbin/unbound-control",
+				"-c", "/unbound/unbound.conf", "reload_keep_cache")
+			cmder.EXPECT().Run(cmd).Return("", tc.keepCacheErr)
+			if tc.reloadCalled {
+				cmd := exec.CommandContext(ctx, "/usr/sbin/unbound-control",
+					"-c", "/unbound/unbound.conf", "reload")
+				cmder.EXPECT().Run(cmd).Return(tc.reloadOutput, tc.reloadErr)
+			}
+
+			c := &configurator{
+				cmder:         cmder,
+				unboundEtcDir: unboundEtcDir,
+				unboundPath:   unboundPath,
+			}
+			err := c.Reload(ctx)
+			if tc.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_ReloadZones(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+	cmder := mock_command.NewMockRunStarter(mockCtrl)
+	ctx := context.Background()
+
+	const unboundEtcDir = "/unbound"
+	const unboundPath = "/usr/sbin/unbound"
+
+	settings := Settings{
+		Blacklist: blacklist.Settings{
+			Groups: []blacklist.GroupSettings{
+				{Clients: blacklist.ClientGroup{Name: "kids"}},
+			},
+		},
+	}
+
+	for _, zoneName := range []string{"rpz.default.", "rpz.kids."} {
+		cmd := exec.CommandContext(ctx, "/usr/sbin/unbound-control",
+			"-c", "/unbound/unbound.conf", "auth_zone_reload", zoneName)
+		cmder.EXPECT().Run(cmd).Return("ok", nil)
+	}
+
+	c := &configurator{
+		cmder:         cmder,
+		unboundEtcDir: unboundEtcDir,
+		unboundPath:   unboundPath,
+	}
+	err := c.ReloadZones(ctx, settings)
+
+	assert.NoError(t, err)
+}
@@ -1,35 +1,119 @@
 package unbound
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+
+	"inet.af/netaddr"
 )
 
-func (c *configurator) MakeUnboundConf(settings Settings) (err error) {
+// MakeUnboundConf generates the Unbound configuration and the response
+// policy zone files in temporary files, validates the configuration with
+// unbound-checkconf and only then moves them in place of the current
+// files, which are left untouched on error.
+// confChanged is false if the configuration file content is unchanged, in
+// which case only the response policy zone files may have changed and
+// ReloadZones can be used instead of Reload.
+func (c *configurator) MakeUnboundConf(ctx context.Context, settings Settings) (
+	confChanged bool, err error) {
+	settings.SetDefaults()
+
 	configFilepath := filepath.Join(c.unboundEtcDir, unboundConfigFilename)
-	file, err := os.OpenFile(configFilepath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+	tempFilepath := configFilepath + ".tmp"
+
+	var tempFilepaths []string
+	if settings.RPZ {
+		tempFilepaths, err = c.writeRPZFiles(settings.Blacklist)
+		if err != nil {
+			return false, err
+		}
 	}
 
-	blacklistLines := convertBlockedToConfigLines(settings.Blacklist)
+	blacklistLines := convertBlockedToConfigLines(settings.Blacklist,
+		settings.AccessControl, settings.RPZ)
 
 	lines := generateUnboundConf(settings, blacklistLines,
 		c.unboundEtcDir, c.cacertsPath, settings.Username)
-	_, err = file.WriteString(strings.Join(lines, "\n"))
+	content := strings.Join(lines, "\n")
+	tempFilepaths = append(tempFilepaths, tempFilepath)
+	err = ioutil.WriteFile(tempFilepath, []byte(content), 0644) //nolint:gosec
 	if err != nil {
-		_ = file.Close()
-		return err
+		removeFiles(tempFilepaths)
+		return false, err
 	}
 
-	return file.Close()
+	err = c.checkConf(ctx, tempFilepath)
+	if err != nil {
+		removeFiles(tempFilepaths)
+		return false, err
+	}
+
+	previousContent, err := ioutil.ReadFile(configFilepath)
+	confChanged = err != nil || string(previousContent) != content
+
+	// The configuration file is moved last so it never
+	// references zone files not yet in place.
+	for i, path := range tempFilepaths {
+		err = os.Rename(path, strings.TrimSuffix(path, ".tmp"))
+		if err != nil {
+			removeFiles(tempFilepaths[i:])
+			return false, err
+		}
+	}
+	return confChanged, nil
+}
+
+// checkConf validates the Unbound configuration file given using
+// unbound-checkconf, which must be in the same directory as the
+// unbound program.
+func (c *configurator) checkConf(ctx context.Context, configFilepath string) (err error) {
+	checkconfPath := filepath.Join(filepath.Dir(c.unboundPath), unboundCheckconfFilename)
+	cmd := exec.CommandContext(ctx, checkconfPath, configFilepath) //nolint:gosec
+	output, err := c.cmder.Run(cmd)
+	if err != nil {
+		return fmt.Errorf("unbound-checkconf: %w: %s", err, output)
+	}
+	return nil
 }
 
 // generateUnboundConf generates an Unbound configuration from the user provided settings.
+// interfaceAddresses returns the listening addresses of the settings,
+// adding the loopback address of each enabled IP version if it is not
+// listened on already, since the container resolves its own queries,
+// such as for the block lists, through Unbound on a loopback address.
+func interfaceAddresses(settings Settings) (addresses []netaddr.IP) {
+	addresses = append(addresses, settings.ListeningAddresses...)
+	loopbacks := make([]netaddr.IP, 0, 2) //nolint:gomnd
+	if settings.IPv4 {
+		loopbacks = append(loopbacks, netaddr.IPv4(127, 0, 0, 1)) //nolint:gomnd
+	}
+	if settings.IPv6 {
+		loopbacks = append(loopbacks, netaddr.IPv6Raw([16]byte{15: 1}))
+	}
+
+	for _, loopback := range loopbacks {
+		listened := false
+		for _, address := range settings.ListeningAddresses {
+			if address == loopback ||
+				(address.IsUnspecified() && address.Is4() == loopback.Is4()) {
+				listened = true
+				break
+			}
+		}
+		if !listened {
+			addresses = append(addresses, loopback)
+		}
+	}
+	return addresses
+}
+
 func generateUnboundConf(settings Settings, blacklistLines []string,
 	unboundDir, cacertsPath, username string) (
 	lines []string) {
@@ -44,23 +128,38 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 	if settings.IPv6 {
 		ipv6 = yes
 	}
+	qnameMinimisation, aggressiveNSEC, serveExpired, hardened := no, no, no, no
+	if settings.QNAMEMinimisation {
+		qnameMinimisation = yes
+	}
+	if settings.AggressiveNSEC {
+		aggressiveNSEC = yes
+	}
+	if settings.ServeExpired {
+		serveExpired = yes
+	}
+	if settings.Hardened {
+		hardened = yes
+	}
+	slabs := strconv.Itoa(int(slabsCount(settings.Threads)))
 	serverLines := []string{
 		// Logging
 		"verbosity: " + strconv.Itoa(int(settings.VerbosityLevel)),
 		"val-log-level: " + strconv.Itoa(int(settings.ValidationLogLevel)),
 		"use-syslog: no",
 		// Performance
-		"num-threads: 2",
+		"num-threads: " + strconv.Itoa(int(settings.Threads)),
 		"prefetch: yes",
 		"prefetch-key: yes",
-		"key-cache-size: 32m",
-		"key-cache-slabs: 4",
-		"msg-cache-size: 8m",
-		"msg-cache-slabs: 4",
-		"rrset-cache-size: 8m",
-		"rrset-cache-slabs: 4",
+		"key-cache-size: " + formatByteSize(settings.KeyCacheSize),
+		"key-cache-slabs: " + slabs,
+		"msg-cache-size: " + formatByteSize(settings.MsgCacheSize),
+		"msg-cache-slabs: " + slabs,
+		"rrset-cache-size: " + formatByteSize(settings.RRSetCacheSize),
+		"rrset-cache-slabs: " + slabs,
 		"cache-min-ttl: 0",
-		"cache-max-ttl: 9000",
+		"cache-max-ttl: " + strconv.Itoa(int(settings.CacheMaxTTL.Seconds())),
+		"serve-expired: " + serveExpired,
 		// Privacy
 		"rrset-roundrobin: yes",
 		"hide-identity: yes",
@@ -68,13 +167,14 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 		// Security
 		`tls-cert-bundle: "` + cacertsPath + `"`,
 		`root-hints: "` + filepath.Join(unboundDir, rootHints) + `"`,
-		"harden-below-nxdomain: yes",
-		"harden-referral-path: yes",
-		"harden-algo-downgrade: yes",
+		"harden-below-nxdomain: " + hardened,
+		"harden-referral-path: " + hardened,
+		"harden-algo-downgrade: " + hardened,
+		"qname-minimisation: " + qnameMinimisation,
+		"aggressive-nsec: " + aggressiveNSEC,
 		// Network
 		"do-ip4: " + ipv4,
 		"do-ip6: " + ipv6,
-		"interface: 0.0.0.0",
 		"port: " + strconv.Itoa(int(settings.ListeningPort)),
 		// Other
 		`username: "` + username + `"`,
@@ -82,22 +182,47 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 		`include: "` + filepath.Join(unboundDir, includeConfFilename) + `"`,
 	}
 
+	for _, address := range interfaceAddresses(settings) {
+		serverLines = append(serverLines, "interface: "+address.String())
+	}
+
+	modules := []string{"validator", "iterator"}
+	if settings.DNS64.Enabled {
+		modules = append([]string{"dns64"}, modules...)
+		serverLines = append(serverLines, "dns64-prefix: "+settings.DNS64.Prefix.String())
+		for _, fqdnHostname := range settings.DNS64.IgnoreAAAAFqdnHostnames {
+			serverLines = append(serverLines, `dns64-ignore-aaaa: "`+fqdnHostname+`"`)
+		}
+	}
+	if settings.RPZ {
+		modules = append([]string{"respip"}, modules...)
+	}
+	if settings.DNS64.Enabled || settings.RPZ {
+		serverLines = append(serverLines, `module-config: "`+strings.Join(modules, " ")+`"`)
+	}
+
 	// Access control
-	for _, subnet := range settings.AccessControl.Allowed {
-		line := "access-control: " + subnet.String() + " allow"
+	for _, rule := range settings.AccessControl.Rules {
+		line := "access-control: " + rule.Subnet.String() + " " + string(rule.Action)
 		serverLines = append(serverLines, line)
 	}
 
+	forwardServerLines, forwardZonesLines := convertForwardZonesToConfigLines(
+		settings.Forward, settings.Caching)
+	serverLines = append(serverLines, forwardServerLines...)
+
 	serverLines = ensureIndentLines(serverLines)
 	sort.Slice(serverLines, func(i, j int) bool {
 		return serverLines[i] < serverLines[j]
 	})
 
 	blacklistLines = ensureIndentLines(blacklistLines)
+	localDataLines := convertLocalDataToConfigLines(settings.LocalData)
 
 	lines = append(lines, "server:")
 	lines = append(lines, serverLines...)
 	lines = append(lines, blacklistLines...)
+	lines = append(lines, localDataLines...)
 
 	// Forward zone
 	lines = append(lines, "forward-zone:")
@@ -121,13 +246,28 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 		ips = append(ips, dotServer.IPv6...)
 		for _, IP := range ips {
 			forwardZoneLines = append(forwardZoneLines,
-				fmt.Sprintf("forward-addr: %s@853#%s", IP.String(), dotServer.Name))
+				fmt.Sprintf("forward-addr: %s@%d#%s", IP.String(), dotServer.Port, dotServer.Name))
 		}
 	}
 
 	forwardZoneLines = ensureIndentLines(forwardZoneLines)
 
 	lines = append(lines, forwardZoneLines...)
+
+	// Forward and stub zones for internal domains
+	lines = append(lines, forwardZonesLines...)
+
+	// Remote control through a local socket, used to reload Unbound
+	lines = append(lines,
+		"remote-control:",
+		"  control-enable: yes",
+		`  control-interface: "`+filepath.Join(unboundDir, controlSocketFilename)+`"`,
+	)
+
+	if settings.RPZ {
+		lines = append(lines, convertRPZToConfigLines(settings.Blacklist, unboundDir)...)
+	}
+
 	return lines
 }
 
@@ -141,3 +281,28 @@ func ensureIndentLines(lines []string) []string {
 	}
 	return lines
 }
+
+// slabsCount returns the number of slabs to use for the caches, which
+// is the smallest power of 2 greater or equal to twice the number of
+// threads, to reduce lock contention between the threads.
+func slabsCount(threads uint16) (slabs uint) {
+	slabs = 1
+	for slabs < 2*uint(threads) {
+		slabs *= 2
+	}
+	return slabs
+}
+
+// formatByteSize formats the size in bytes given using the
+// largest of the g, m and k Unbound suffixes dividing it.
+func formatByteSize(size uint64) string {
+	const kilobyte = 1 << 10
+	suffixes := []string{"g", "m", "k"}
+	for i, suffix := range suffixes {
+		multiple := uint64(kilobyte) << (10 * (len(suffixes) - 1 - i)) //nolint:gomnd
+		if size > 0 && size%multiple == 0 {
+			return strconv.FormatUint(size/multiple, 10) + suffix
+		}
+	}
+	return strconv.FormatUint(size, 10)
+}
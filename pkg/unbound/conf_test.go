@@ -1,11 +1,23 @@
 package unbound
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
+	"github.com/qdm12/dns/pkg/accesscontrol"
+	"github.com/qdm12/dns/pkg/dns64"
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/golibs/command/mock_command"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"inet.af/netaddr"
 )
 
@@ -21,8 +33,24 @@ func Test_generateUnboundConf(t *testing.T) {
 		ListeningPort:      53,
 		IPv4:               true,
 		IPv6:               true,
-		AccessControl: AccessControlSettings{
-			Allowed: []netaddr.IPPrefix{{IP: netaddr.IPv4(0, 0, 0, 0)}},
+		AccessControl: accesscontrol.Settings{
+			Rules: []accesscontrol.Rule{
+				{Subnet: netaddr.MustParseIPPrefix("0.0.0.0/0"), Action: accesscontrol.Refuse},
+				{Subnet: netaddr.MustParseIPPrefix("10.0.0.0/8"), Action: accesscontrol.AllowSnoop},
+			},
+		},
+		Threads:            2,
+		MsgCacheSize:       8 * 1024 * 1024,
+		RRSetCacheSize:     8 * 1024 * 1024,
+		KeyCacheSize:       32 * 1024 * 1024,
+		CacheMaxTTL:        9000 * time.Second,
+		ListeningAddresses: []netaddr.IP{netaddr.IPv4(0, 0, 0, 0)},
+		QNAMEMinimisation:  true,
+		Hardened:           true,
+		DNS64: dns64.Settings{
+			Enabled:                 true,
+			Prefix:                  netaddr.MustParseIPPrefix("64:ff9b::/96"),
+			IgnoreAAAAFqdnHostnames: []string{"ipv6.example.com."},
 		},
 	}
 	lines := generateUnboundConf(settings,
@@ -38,9 +66,13 @@ func Test_generateUnboundConf(t *testing.T) {
 	)
 	expected := `
 server:
-  access-control: 0.0.0.0/0 allow
+  access-control: 0.0.0.0/0 refuse
+  access-control: 10.0.0.0/8 allow_snoop
+  aggressive-nsec: no
   cache-max-ttl: 9000
   cache-min-ttl: 0
+  dns64-ignore-aaaa: "ipv6.example.com."
+  dns64-prefix: 64:ff9b::/96
   do-ip4: yes
   do-ip6: yes
   harden-algo-downgrade: yes
@@ -50,18 +82,22 @@ server:
   hide-version: yes
   include: "/unbound/include.conf"
   interface: 0.0.0.0
+  interface: ::1
   key-cache-size: 32m
   key-cache-slabs: 4
+  module-config: "dns64 validator iterator"
   msg-cache-size: 8m
   msg-cache-slabs: 4
   num-threads: 2
   port: 53
   prefetch-key: yes
   prefetch: yes
+  qname-minimisation: yes
   root-hints: "/unbound/root.hints"
   rrset-cache-size: 8m
   rrset-cache-slabs: 4
   rrset-roundrobin: yes
+  serve-expired: no
   tls-cert-bundle: "/unbound/ca-certificates.crt"
   trust-anchor-file: "/unbound/root.key"
   use-syslog: no
@@ -83,6 +119,197 @@ forward-zone:
   forward-addr: 9.9.9.9@853#dns.quad9.net
   forward-addr: 149.112.112.112@853#dns.quad9.net
   forward-addr: 2620:fe::fe@853#dns.quad9.net
-  forward-addr: 2620:fe::9@853#dns.quad9.net`
+  forward-addr: 2620:fe::9@853#dns.quad9.net
+remote-control:
+  control-enable: yes
+  control-interface: "/unbound/unbound.sock"`
 	assert.Equal(t, expected, "\n"+strings.Join(lines, "\n"))
 }
+
+func Test_interfaceAddresses(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings  Settings
+		addresses []netaddr.IP
+	}{
+		"unspecified addresses": {
+			settings: Settings{
+				IPv4: true,
+				IPv6: true,
+				ListeningAddresses: []netaddr.IP{
+					netaddr.IPv4(0, 0, 0, 0), netaddr.IPv6Unspecified(),
+				},
+			},
+			addresses: []netaddr.IP{
+				netaddr.IPv4(0, 0, 0, 0), netaddr.IPv6Unspecified(),
+			},
+		},
+		"LAN address": {
+			settings: Settings{
+				IPv4:               true,
+				ListeningAddresses: []netaddr.IP{netaddr.IPv4(192, 168, 1, 2)},
+			},
+			addresses: []netaddr.IP{
+				netaddr.IPv4(192, 168, 1, 2), netaddr.IPv4(127, 0, 0, 1),
+			},
+		},
+		"IPv6 unspecified address with IPv4": {
+			settings: Settings{
+				IPv4:               true,
+				IPv6:               true,
+				ListeningAddresses: []netaddr.IP{netaddr.IPv6Unspecified()},
+			},
+			addresses: []netaddr.IP{
+				netaddr.IPv6Unspecified(), netaddr.IPv4(127, 0, 0, 1),
+			},
+		},
+		"IPv6 only": {
+			settings: Settings{
+				IPv6:               true,
+				ListeningAddresses: []netaddr.IP{netaddr.MustParseIP("fd00::2")},
+			},
+			addresses: []netaddr.IP{
+				netaddr.MustParseIP("fd00::2"), netaddr.MustParseIP("::1"),
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			addresses := interfaceAddresses(testCase.settings)
+
+			assert.Equal(t, testCase.addresses, addresses)
+		})
+	}
+}
+
+func Test_MakeUnboundConf(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		rpz         bool
+		checkOutput string
+		checkErr    error
+		err         error
+		replaced    bool
+	}{
+		"valid configuration": {
+			replaced: true,
+		},
+		"invalid configuration": {
+			checkOutput: "unbound.conf.tmp:3: error: syntax error",
+			checkErr:    fmt.Errorf("exit status 1"),
+			err: fmt.Errorf("unbound-checkconf: exit status 1: " +
+				"unbound.conf.tmp:3: error: syntax error"),
+		},
+		"valid configuration with response policy zones": {
+			rpz:      true,
+			replaced: true,
+		},
+		"invalid configuration with response policy zones": {
+			rpz:         true,
+			checkOutput: "unbound.conf.tmp:3: error: syntax error",
+			checkErr:    fmt.Errorf("exit status 1"),
+			err: fmt.Errorf("unbound-checkconf: exit status 1: " +
+				"unbound.conf.tmp:3: error: syntax error"),
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			cmder := mock_command.NewMockRunStarter(mockCtrl)
+			ctx := context.Background()
+
+			unboundEtcDir := t.TempDir()
+			const unboundPath = "/usr/sbin/unbound"
+			configFilepath := filepath.Join(unboundEtcDir, "unbound.conf")
+			const oldConfig = "server:"
+			err := ioutil.WriteFile(configFilepath, []byte(oldConfig), 0600)
+			require.NoError(t, err)
+			rpzFilepath := filepath.Join(unboundEtcDir, "rpz.default.zone")
+			const oldRPZ = "old zone"
+			err = ioutil.WriteFile(rpzFilepath, []byte(oldRPZ), 0600)
+			require.NoError(t, err)
+
+			cmd := exec.CommandContext(ctx, "/usr/sbin/unbound-checkconf", configFilepath+".tmp")
+			cmder.EXPECT().Run(cmd).Return(tc.checkOutput, tc.checkErr)
+
+			c := &configurator{
+				cmder:         cmder,
+				unboundEtcDir: unboundEtcDir,
+				unboundPath:   unboundPath,
+			}
+			confChanged, err := c.MakeUnboundConf(ctx, Settings{RPZ: tc.rpz})
+			if tc.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.replaced, confChanged)
+
+			for _, path := range []string{configFilepath, rpzFilepath} {
+				_, err = os.Stat(path + ".tmp")
+				assert.True(t, os.IsNotExist(err))
+			}
+
+			data, err := ioutil.ReadFile(configFilepath)
+			require.NoError(t, err)
+			assert.Equal(t, !tc.replaced, string(data) == oldConfig)
+
+			data, err = ioutil.ReadFile(rpzFilepath)
+			require.NoError(t, err)
+			assert.Equal(t, !(tc.replaced && tc.rpz), string(data) == oldRPZ)
+		})
+	}
+}
+
+func Test_slabsCount(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		threads uint16
+		slabs   uint
+	}{
+		"0 thread":  {slabs: 1},
+		"1 thread":  {threads: 1, slabs: 2},
+		"2 threads": {threads: 2, slabs: 4},
+		"3 threads": {threads: 3, slabs: 8},
+		"8 threads": {threads: 8, slabs: 16},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			slabs := slabsCount(testCase.threads)
+			assert.Equal(t, testCase.slabs, slabs)
+		})
+	}
+}
+
+func Test_formatByteSize(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		size      uint64
+		formatted string
+	}{
+		"zero":      {formatted: "0"},
+		"bytes":     {size: 1000, formatted: "1000"},
+		"kilobytes": {size: 3 * 1024, formatted: "3k"},
+		"megabytes": {size: 8 * 1024 * 1024, formatted: "8m"},
+		"gigabytes": {size: 2 * 1024 * 1024 * 1024, formatted: "2g"},
+		"mixed":     {size: 1024*1024 + 1024, formatted: "1025k"},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			formatted := formatByteSize(testCase.size)
+			assert.Equal(t, testCase.formatted, formatted)
+		})
+	}
+}
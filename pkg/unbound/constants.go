@@ -1,7 +1,10 @@
 package unbound
 
 const (
-	unboundConfigFilename = "unbound.conf"
-	rootHints             = "root.hints"
-	rootKey               = "root.key"
+	unboundConfigFilename    = "unbound.conf"
+	unboundControlFilename   = "unbound-control"
+	unboundCheckconfFilename = "unbound-checkconf"
+	controlSocketFilename    = "unbound.sock"
+	rootHints                = "root.hints"
+	rootKey                  = "root.key"
 )
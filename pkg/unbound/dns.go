@@ -10,10 +10,12 @@ import (
 
 type Configurator interface {
 	SetupFiles(ctx context.Context) error
-	MakeUnboundConf(settings Settings) (err error)
+	MakeUnboundConf(ctx context.Context, settings Settings) (confChanged bool, err error)
 	Start(ctx context.Context, verbosityDetailsLevel uint8) (
 		stdoutLines, stderrLines chan string, waitError chan error, err error)
 	Version(ctx context.Context) (version string, err error)
+	Reload(ctx context.Context) (err error)
+	ReloadZones(ctx context.Context, settings Settings) (err error)
 }
 
 type configurator struct {
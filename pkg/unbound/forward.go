@@ -0,0 +1,58 @@
+package unbound
+
+import (
+	"strconv"
+
+	"github.com/qdm12/dns/pkg/forward"
+)
+
+// convertForwardZonesToConfigLines returns the server lines and the zone
+// clauses for the forwarding zones given. For insecure zones only, the server
+// lines turn off DNSSEC validation and the default local zones, such as
+// home.arpa. or private reverse zones, for their domains. A stub-zone clause
+// is used for authoritative upstream servers, and a forward-zone clause for
+// recursive upstream servers. DNS over HTTPS zones are skipped since Unbound
+// cannot forward queries over HTTPS.
+func convertForwardZonesToConfigLines(settings forward.Settings, caching bool) (
+	serverLines, zoneLines []string) {
+	noCache := "yes"
+	if caching {
+		noCache = "no"
+	}
+
+	for _, zone := range settings.Zones {
+		if zone.Protocol == forward.DoH {
+			continue
+		}
+
+		clause, prefix := "forward-zone:", "forward-"
+		if zone.Authoritative {
+			clause, prefix = "stub-zone:", "stub-"
+		}
+
+		for _, fqdnDomain := range zone.FqdnDomains {
+			if zone.Insecure {
+				serverLines = append(serverLines,
+					`  domain-insecure: "`+fqdnDomain+`"`,
+					`  local-zone: "`+fqdnDomain+`" transparent`)
+			}
+
+			zoneLines = append(zoneLines,
+				clause,
+				`  name: "`+fqdnDomain+`"`,
+				"  "+prefix+"no-cache: "+noCache)
+			if zone.Protocol == forward.DoT {
+				zoneLines = append(zoneLines, "  "+prefix+"tls-upstream: yes")
+			}
+			for _, upstream := range zone.Upstreams {
+				addr := upstream.IP.String() + "@" + strconv.Itoa(int(upstream.Port))
+				if zone.Protocol == forward.DoT {
+					addr += "#" + zone.TLSName
+				}
+				zoneLines = append(zoneLines, "  "+prefix+"addr: "+addr)
+			}
+		}
+	}
+
+	return serverLines, zoneLines
+}
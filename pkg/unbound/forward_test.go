@@ -0,0 +1,84 @@
+package unbound
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/qdm12/dns/pkg/forward"
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_convertForwardZonesToConfigLines(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		settings    forward.Settings
+		caching     bool
+		serverLines []string
+		zoneLines   []string
+	}{
+		"no zone": {},
+		"zones": {
+			settings: forward.Settings{
+				Zones: []forward.Zone{
+					{
+						Name:        "corp",
+						FqdnDomains: []string{"corp.example."},
+						Protocol:    forward.Plain,
+						Upstreams:   []forward.Upstream{{IP: netaddr.IPv4(10, 0, 0, 53), Port: 53}},
+					},
+					{
+						Name:          "home",
+						FqdnDomains:   []string{"home.arpa.", "168.192.in-addr.arpa."},
+						Protocol:      forward.DoT,
+						Upstreams:     []forward.Upstream{{IP: netaddr.IPv4(192, 168, 1, 1), Port: 853}},
+						TLSName:       "router.home.arpa",
+						Authoritative: true,
+						Insecure:      true,
+					},
+					{
+						Name:        "doh",
+						FqdnDomains: []string{"doh.example."},
+						Protocol:    forward.DoH,
+						URL:         &url.URL{Scheme: "https", Host: "doh.example", Path: "/dns-query"},
+					},
+				},
+			},
+			caching: true,
+			serverLines: []string{
+				`  domain-insecure: "home.arpa."`,
+				`  local-zone: "home.arpa." transparent`,
+				`  domain-insecure: "168.192.in-addr.arpa."`,
+				`  local-zone: "168.192.in-addr.arpa." transparent`,
+			},
+			zoneLines: []string{
+				"forward-zone:",
+				`  name: "corp.example."`,
+				"  forward-no-cache: no",
+				"  forward-addr: 10.0.0.53@53",
+				"stub-zone:",
+				`  name: "home.arpa."`,
+				"  stub-no-cache: no",
+				"  stub-tls-upstream: yes",
+				"  stub-addr: 192.168.1.1@853#router.home.arpa",
+				"stub-zone:",
+				`  name: "168.192.in-addr.arpa."`,
+				"  stub-no-cache: no",
+				"  stub-tls-upstream: yes",
+				"  stub-addr: 192.168.1.1@853#router.home.arpa",
+			},
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			serverLines, zoneLines := convertForwardZonesToConfigLines(tc.settings, tc.caching)
+
+			assert.Equal(t, tc.serverLines, serverLines)
+			assert.Equal(t, tc.zoneLines, zoneLines)
+		})
+	}
+}
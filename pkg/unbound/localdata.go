@@ -0,0 +1,19 @@
+package unbound
+
+import (
+	"strings"
+
+	"github.com/qdm12/dns/pkg/localdata"
+)
+
+// convertLocalDataToConfigLines converts the local records to Unbound
+// local-data lines. The records are quoted with single quotes since
+// TXT records contain double quotes.
+func convertLocalDataToConfigLines(settings localdata.Settings) (lines []string) {
+	lines = make([]string, len(settings.Records))
+	for i, record := range settings.Records {
+		rr := strings.ReplaceAll(record.String(), "\t", " ")
+		lines[i] = "  local-data: '" + rr + "'"
+	}
+	return lines
+}
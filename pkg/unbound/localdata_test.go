@@ -0,0 +1,35 @@
+package unbound
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/localdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_convertLocalDataToConfigLines(t *testing.T) {
+	t.Parallel()
+
+	records, err := localdata.ParseRecords([]string{
+		"nas.lan. 300 IN A 192.168.1.10",
+		`nas.lan. 300 IN TXT "hello world"`,
+		"_http._tcp.lan. 300 IN SRV 0 5 80 nas.lan.",
+	})
+	require.NoError(t, err)
+	records = append(records, &dns.PTR{
+		Hdr: dns.RR_Header{Name: "10.1.168.192.in-addr.arpa.", Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 300},
+		Ptr: "nas.lan.",
+	})
+
+	lines := convertLocalDataToConfigLines(localdata.Settings{Records: records})
+
+	expected := []string{
+		"  local-data: 'nas.lan. 300 IN A 192.168.1.10'",
+		`  local-data: 'nas.lan. 300 IN TXT "hello world"'`,
+		"  local-data: '_http._tcp.lan. 300 IN SRV 0 5 80 nas.lan.'",
+		"  local-data: '10.1.168.192.in-addr.arpa. 300 IN PTR nas.lan.'",
+	}
+	assert.Equal(t, expected, lines)
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/qdm12/dns/pkg/dnssec"
 )
 
 func (c *configurator) SetupFiles(ctx context.Context) error {
@@ -40,11 +42,7 @@ func (c *configurator) downloadRootHints(ctx context.Context) error {
 }
 
 func (c *configurator) downloadRootKeys(ctx context.Context) error {
-	rootAnchorsXML, err := c.dnscrypto.DownloadRootAnchorsXML(ctx)
-	if err != nil {
-		return err
-	}
-	rootKeys, err := c.dnscrypto.ConvertRootAnchorsToRootKeys(rootAnchorsXML)
+	rootKeys, err := dnssec.DownloadRootKeys(ctx, c.dnscrypto)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,108 @@
+package unbound
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+)
+
+// rpzZone is a response policy zone holding the blocked hostnames
+// of clients with its tag, or of all clients if its tag is empty.
+type rpzZone struct {
+	name          string
+	tag           string
+	fqdnHostnames []string
+}
+
+// getRPZZones returns one zone for the top level blocked hostnames
+// and, if there are client groups, one zone per client group using
+// the tags defined by convertGroupsBlockedToConfigLines.
+func getRPZZones(settings blacklist.Settings) (zones []rpzZone) {
+	if len(settings.Groups) == 0 {
+		return []rpzZone{{name: defaultTag, fqdnHostnames: settings.FqdnHostnames}}
+	}
+
+	zones = make([]rpzZone, 0, len(settings.Groups)+1)
+	zones = append(zones, rpzZone{
+		name:          defaultTag,
+		tag:           defaultTag,
+		fqdnHostnames: settings.FqdnHostnames,
+	})
+	for _, group := range settings.Groups {
+		zones = append(zones, rpzZone{
+			name:          group.Clients.Name,
+			tag:           group.Clients.Name,
+			fqdnHostnames: group.Blacklist.FqdnHostnames,
+		})
+	}
+	return zones
+}
+
+func rpzZoneName(zone rpzZone) string {
+	return "rpz." + zone.name + "."
+}
+
+func rpzZoneFilename(zone rpzZone) string {
+	return "rpz." + zone.name + ".zone"
+}
+
+// convertRPZToConfigLines returns the Unbound rpz clauses
+// for the zone files written by writeRPZFiles.
+func convertRPZToConfigLines(settings blacklist.Settings, unboundDir string) (lines []string) {
+	for _, zone := range getRPZZones(settings) {
+		lines = append(lines,
+			"rpz:",
+			`  name: "`+rpzZoneName(zone)+`"`,
+			`  zonefile: "`+filepath.Join(unboundDir, rpzZoneFilename(zone))+`"`,
+		)
+		if zone.tag != "" {
+			lines = append(lines, `  tags: "`+zone.tag+`"`)
+		}
+	}
+	return lines
+}
+
+// writeRPZFiles writes the blocked hostnames as response policy zone
+// files, IP addresses being blocked using private-address instead.
+// Each file is written next to its zone file path with a .tmp suffix,
+// and is only meant to be moved in place once the configuration is
+// validated. On error, the temporary files written are removed.
+func (c *configurator) writeRPZFiles(settings blacklist.Settings) (
+	tempFilepaths []string, err error) {
+	serial := uint32(time.Now().Unix())
+	for _, zone := range getRPZZones(settings) {
+		tempFilepath := filepath.Join(c.unboundEtcDir, rpzZoneFilename(zone)) + ".tmp"
+		err = writeRPZFile(tempFilepath, zone, serial)
+		if err != nil {
+			_ = os.Remove(tempFilepath)
+			removeFiles(tempFilepaths)
+			return nil, err
+		}
+		tempFilepaths = append(tempFilepaths, tempFilepath)
+	}
+	return tempFilepaths, nil
+}
+
+func writeRPZFile(path string, zone rpzZone, serial uint32) (err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	zoneSettings := blacklist.Settings{FqdnHostnames: zone.fqdnHostnames}
+	err = blacklist.WriteRPZ(file, zoneSettings, serial)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		_ = os.Remove(path)
+	}
+}
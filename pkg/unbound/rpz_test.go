@@ -0,0 +1,52 @@
+package unbound
+
+import (
+	"testing"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_convertRPZToConfigLines(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		settings blacklist.Settings
+		lines    []string
+	}{
+		"no client group": {
+			lines: []string{
+				"rpz:",
+				`  name: "rpz.default."`,
+				`  zonefile: "/unbound/rpz.default.zone"`,
+			},
+		},
+		"client groups": {
+			settings: blacklist.Settings{
+				Groups: []blacklist.GroupSettings{
+					{Clients: blacklist.ClientGroup{Name: "kids"}},
+				},
+			},
+			lines: []string{
+				"rpz:",
+				`  name: "rpz.default."`,
+				`  zonefile: "/unbound/rpz.default.zone"`,
+				`  tags: "default"`,
+				"rpz:",
+				`  name: "rpz.kids."`,
+				`  zonefile: "/unbound/rpz.kids.zone"`,
+				`  tags: "kids"`,
+			},
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lines := convertRPZToConfigLines(tc.settings, "/unbound")
+
+			assert.Equal(t, tc.lines, lines)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package unbound
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/safesearch"
+)
+
+// convertSafeSearchToConfigLines rewrites the safe search hostnames using
+// CNAME local data, which Unbound resolves. The local zones are transparent
+// so other hostnames in these zones are resolved normally.
+// If tags is not empty, the local zones only apply to clients with one of
+// the tags given. Hostnames for which isBlocked returns true are skipped
+// since Unbound does not accept duplicate local zones.
+func convertSafeSearchToConfigLines(tags []string,
+	isBlocked func(hostname string) bool) (configLines []string) {
+	rewrites := safesearch.Rewrites()
+	hostnames := make([]string, 0, len(rewrites))
+	for hostname := range rewrites {
+		if !isBlocked(hostname) {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	sort.Strings(hostnames)
+
+	for _, hostname := range hostnames {
+		configLines = append(configLines,
+			"  local-zone: \""+hostname+"\" transparent",
+			"  local-data: \""+hostname+" CNAME "+rewrites[hostname]+"\"",
+		)
+		if len(tags) > 0 {
+			configLines = append(configLines,
+				"  local-zone-tag: \""+hostname+"\" \""+strings.Join(tags, " ")+"\"")
+		}
+	}
+
+	return configLines
+}
@@ -0,0 +1,22 @@
+package unbound
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_convertSafeSearchToConfigLines(t *testing.T) {
+	t.Parallel()
+
+	isBlocked := func(hostname string) bool {
+		return hostname == "bing.com."
+	}
+
+	configLines := convertSafeSearchToConfigLines([]string{"default", "kids"}, isBlocked)
+
+	assert.NotContains(t, configLines, "  local-zone: \"bing.com.\" transparent")
+	assert.Contains(t, configLines, "  local-zone: \"www.bing.com.\" transparent")
+	assert.Contains(t, configLines, "  local-data: \"www.bing.com. CNAME strict.bing.com.\"")
+	assert.Contains(t, configLines, "  local-zone-tag: \"www.bing.com.\" \"default kids\"")
+}
@@ -1,10 +1,17 @@
 package unbound
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/qdm12/dns/pkg/accesscontrol"
 	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/dns/pkg/forward"
+	"github.com/qdm12/dns/pkg/localdata"
 	"github.com/qdm12/dns/pkg/provider"
 	"inet.af/netaddr"
 )
@@ -24,9 +31,97 @@ type Settings struct {
 	VerbosityLevel        uint8
 	VerbosityDetailsLevel uint8
 	ValidationLogLevel    uint8
-	AccessControl         AccessControlSettings
-	Username              string
-	Blacklist             blacklist.Settings
+	AccessControl         accesscontrol.Settings
+	// Forward contains the zones forwarded to other upstream servers,
+	// where DNS over HTTPS zones are not supported by Unbound.
+	Forward  forward.Settings
+	Username string
+	// Threads is the number of threads, and defaults to the number
+	// of CPUs available, capped to 8, if left to 0.
+	Threads uint16
+	// MsgCacheSize, RRSetCacheSize and KeyCacheSize are the
+	// cache sizes in bytes, and default to 8MB, 8MB and 32MB.
+	MsgCacheSize   uint64
+	RRSetCacheSize uint64
+	KeyCacheSize   uint64
+	// CacheMaxTTL is the maximum time to live for cached
+	// records, and defaults to 9000 seconds.
+	CacheMaxTTL time.Duration
+	// ListeningAddresses are the addresses to listen on,
+	// and default to 0.0.0.0.
+	ListeningAddresses []netaddr.IP
+	QNAMEMinimisation  bool
+	AggressiveNSEC     bool
+	ServeExpired       bool
+	// Hardened is true to enable the harden-below-nxdomain,
+	// harden-referral-path and harden-algo-downgrade options.
+	Hardened  bool
+	Blacklist blacklist.Settings
+	LocalData localdata.Settings
+	DNS64     dns64.Settings
+	// RPZ is true to write the blocked hostnames to response
+	// policy zone files instead of the Unbound configuration.
+	RPZ bool
+}
+
+// SetDefaults sets the defaults for the unset fields of the settings.
+func (s *Settings) SetDefaults() {
+	if s.Threads == 0 {
+		s.Threads = defaultThreads()
+	}
+
+	const megabyte = 1 << 20
+	if s.MsgCacheSize == 0 {
+		const defaultMsgCacheSize = 8 * megabyte
+		s.MsgCacheSize = defaultMsgCacheSize
+	}
+
+	if s.RRSetCacheSize == 0 {
+		const defaultRRSetCacheSize = 8 * megabyte
+		s.RRSetCacheSize = defaultRRSetCacheSize
+	}
+
+	if s.KeyCacheSize == 0 {
+		const defaultKeyCacheSize = 32 * megabyte
+		s.KeyCacheSize = defaultKeyCacheSize
+	}
+
+	if s.CacheMaxTTL == 0 {
+		const defaultCacheMaxTTL = 9000 * time.Second
+		s.CacheMaxTTL = defaultCacheMaxTTL
+	}
+
+	if len(s.ListeningAddresses) == 0 {
+		s.ListeningAddresses = []netaddr.IP{netaddr.IPv4(0, 0, 0, 0)}
+	}
+
+	s.AccessControl.SetDefaults()
+
+	s.DNS64.SetDefaults()
+}
+
+var (
+	ErrCacheMaxTTLNegative          = errors.New("cache maximum TTL cannot be negative")
+	ErrListeningAddressIPv4Disabled = errors.New("cannot listen on an IPv4 address with IPv4 disabled")
+	ErrListeningAddressIPv6Disabled = errors.New("cannot listen on an IPv6 address with IPv6 disabled")
+)
+
+// Validate returns an error if the settings cannot be used by Unbound.
+func (s *Settings) Validate() (err error) {
+	if s.CacheMaxTTL < 0 {
+		return fmt.Errorf("%w: %s", ErrCacheMaxTTLNegative, s.CacheMaxTTL)
+	}
+
+	for _, address := range s.ListeningAddresses {
+		switch {
+		case address.Is4() && !s.IPv4:
+			return fmt.Errorf("%w: %s", ErrListeningAddressIPv4Disabled, address)
+		case address.Is6() && !s.IPv6:
+			return fmt.Errorf("%w: %s", ErrListeningAddressIPv6Disabled, address)
+		}
+	}
+
+	return nil
 }
 
 func (s *Settings) String() string {
@@ -47,11 +142,25 @@ func (s *Settings) Lines() (lines []string) {
 	lines = append(lines,
 		subIndent+"Listening port: "+strconv.Itoa(int(s.ListeningPort)))
 
+	listeningAddresses := make([]string, len(s.ListeningAddresses))
+	for i, address := range s.ListeningAddresses {
+		listeningAddresses[i] = address.String()
+	}
+	lines = append(lines, subIndent+
+		"Listening addresses: "+strings.Join(listeningAddresses, ", "))
+
 	lines = append(lines, subIndent+"Access control:")
-	for _, line := range s.AccessControl.Lines() {
+	for _, line := range s.AccessControl.Lines(indent, subIndent) {
 		lines = append(lines, indent+line)
 	}
 
+	if len(s.Forward.Zones) > 0 {
+		lines = append(lines, subIndent+"Forwarding zones:")
+		for _, line := range s.Forward.Lines(indent, subIndent) {
+			lines = append(lines, indent+line)
+		}
+	}
+
 	caching := disabled
 	if s.Caching {
 		caching = enabled
@@ -59,6 +168,45 @@ func (s *Settings) Lines() (lines []string) {
 	lines = append(lines, subIndent+
 		"Caching: "+caching)
 
+	lines = append(lines, subIndent+"Cache sizes:")
+	lines = append(lines, indent+subIndent+"Messages: "+formatByteSize(s.MsgCacheSize))
+	lines = append(lines, indent+subIndent+"Resource record sets: "+formatByteSize(s.RRSetCacheSize))
+	lines = append(lines, indent+subIndent+"DNSSEC keys: "+formatByteSize(s.KeyCacheSize))
+
+	lines = append(lines, subIndent+
+		"Cache maximum TTL: "+s.CacheMaxTTL.String())
+
+	serveExpired := disabled
+	if s.ServeExpired {
+		serveExpired = enabled
+	}
+	lines = append(lines, subIndent+
+		"Serve expired: "+serveExpired)
+
+	lines = append(lines, subIndent+
+		"Threads: "+strconv.Itoa(int(s.Threads)))
+
+	qnameMinimisation := disabled
+	if s.QNAMEMinimisation {
+		qnameMinimisation = enabled
+	}
+	lines = append(lines, subIndent+
+		"QNAME minimisation: "+qnameMinimisation)
+
+	aggressiveNSEC := disabled
+	if s.AggressiveNSEC {
+		aggressiveNSEC = enabled
+	}
+	lines = append(lines, subIndent+
+		"Aggressive NSEC: "+aggressiveNSEC)
+
+	hardened := disabled
+	if s.Hardened {
+		hardened = enabled
+	}
+	lines = append(lines, subIndent+
+		"Hardening: "+hardened)
+
 	ipv4 := disabled
 	if s.IPv4 {
 		ipv4 = enabled
@@ -84,18 +232,15 @@ func (s *Settings) Lines() (lines []string) {
 
 	lines = append(lines, subIndent+"Username: "+s.Username)
 
-	return lines
-}
+	lines = append(lines, s.LocalData.Lines(indent, subIndent)...)
 
-type AccessControlSettings struct {
-	Allowed []netaddr.IPPrefix
-}
+	lines = append(lines, s.DNS64.Lines(indent, subIndent)...)
 
-func (s *AccessControlSettings) Lines() (lines []string) {
-	lines = append(lines, subIndent+"Allowed:")
-	for _, subnet := range s.Allowed {
-		lines = append(lines,
-			indent+subIndent+subnet.String())
+	rpz := disabled
+	if s.RPZ {
+		rpz = enabled
 	}
+	lines = append(lines, subIndent+"Response policy zone files: "+rpz)
+
 	return lines
 }
@@ -2,7 +2,11 @@ package unbound
 
 import (
 	"testing"
+	"time"
 
+	"github.com/qdm12/dns/pkg/accesscontrol"
+	"github.com/qdm12/dns/pkg/dns64"
+	"github.com/qdm12/dns/pkg/forward"
 	"github.com/qdm12/dns/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"inet.af/netaddr"
@@ -18,15 +22,28 @@ func Test_Settings_Lines(t *testing.T) {
 			lines: []string{
 				" |--DNS over TLS providers:",
 				" |--Listening port: 0",
+				" |--Listening addresses: ",
 				" |--Access control:",
-				"     |--Allowed:",
 				" |--Caching: disabled",
+				" |--Cache sizes:",
+				"     |--Messages: 0",
+				"     |--Resource record sets: 0",
+				"     |--DNSSEC keys: 0",
+				" |--Cache maximum TTL: 0s",
+				" |--Serve expired: disabled",
+				" |--Threads: 0",
+				" |--QNAME minimisation: disabled",
+				" |--Aggressive NSEC: disabled",
+				" |--Hardening: disabled",
 				" |--IPv4 resolution: disabled",
 				" |--IPv6 resolution: disabled",
 				" |--Verbosity level: 0/5",
 				" |--Verbosity details level: 0/4",
 				" |--Validation log level: 0/2",
 				" |--Username: ",
+				" |--Local records: 0",
+				" |--DNS64: disabled",
+				" |--Response policy zone files: disabled",
 			},
 		},
 		"full settings": {
@@ -42,26 +59,74 @@ func Test_Settings_Lines(t *testing.T) {
 				VerbosityLevel:        1,
 				VerbosityDetailsLevel: 2,
 				ValidationLogLevel:    3,
-				AccessControl: AccessControlSettings{
-					Allowed: []netaddr.IPPrefix{{IP: netaddr.IPv4(0, 0, 0, 0)}},
+				AccessControl: accesscontrol.Settings{
+					Rules: []accesscontrol.Rule{
+						{Subnet: netaddr.MustParseIPPrefix("0.0.0.0/0"), Action: accesscontrol.Allow},
+					},
 				},
-				Username: "username",
+				Forward: forward.Settings{
+					Zones: []forward.Zone{{
+						Name:        "corp",
+						FqdnDomains: []string{"corp.example."},
+						Protocol:    forward.Plain,
+						Upstreams:   []forward.Upstream{{IP: netaddr.IPv4(10, 0, 0, 53), Port: 53}},
+					}},
+					Timeout: time.Second,
+				},
+				DNS64: dns64.Settings{
+					Enabled:                 true,
+					Prefix:                  netaddr.MustParseIPPrefix("64:ff9b::/96"),
+					IgnoreAAAAFqdnHostnames: []string{"ipv6.example.com."},
+				},
+				Username:           "username",
+				Threads:            4,
+				MsgCacheSize:       16 * 1024 * 1024,
+				RRSetCacheSize:     32 * 1024 * 1024,
+				KeyCacheSize:       512 * 1024,
+				CacheMaxTTL:        time.Hour,
+				ListeningAddresses: []netaddr.IP{netaddr.IPv4(0, 0, 0, 0), netaddr.IPv6Unspecified()},
+				QNAMEMinimisation:  true,
+				AggressiveNSEC:     true,
+				ServeExpired:       true,
+				Hardened:           true,
+				RPZ:                true,
 			},
 			lines: []string{
 				" |--DNS over TLS providers:",
 				"     |--Quad9",
 				"     |--Cloudflare",
 				" |--Listening port: 53",
+				" |--Listening addresses: 0.0.0.0, ::",
 				" |--Access control:",
-				"     |--Allowed:",
-				"         |--0.0.0.0/0",
+				"     |--0.0.0.0/0: allow",
+				" |--Forwarding zones:",
+				"     |--corp:",
+				"         |--Domains: corp.example.",
+				"         |--Protocol: plain",
+				"         |--Upstreams: 10.0.0.53:53",
+				"     |--Query timeout: 1s",
 				" |--Caching: enabled",
+				" |--Cache sizes:",
+				"     |--Messages: 16m",
+				"     |--Resource record sets: 32m",
+				"     |--DNSSEC keys: 512k",
+				" |--Cache maximum TTL: 1h0m0s",
+				" |--Serve expired: enabled",
+				" |--Threads: 4",
+				" |--QNAME minimisation: enabled",
+				" |--Aggressive NSEC: enabled",
+				" |--Hardening: enabled",
 				" |--IPv4 resolution: enabled",
 				" |--IPv6 resolution: enabled",
 				" |--Verbosity level: 1/5",
 				" |--Verbosity details level: 2/4",
 				" |--Validation log level: 3/2",
 				" |--Username: username",
+				" |--Local records: 0",
+				" |--DNS64: enabled",
+				"     |--Prefix: 64:ff9b::/96",
+				"     |--AAAA records ignored for: ipv6.example.com.",
+				" |--Response policy zone files: enabled",
 			},
 		},
 	}
@@ -74,3 +139,59 @@ func Test_Settings_Lines(t *testing.T) {
 		})
 	}
 }
+
+func Test_Settings_Validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings Settings
+		err      error
+		errMsg   string
+	}{
+		"valid": {
+			settings: Settings{
+				IPv4:               true,
+				IPv6:               true,
+				CacheMaxTTL:        time.Hour,
+				ListeningAddresses: []netaddr.IP{netaddr.IPv4(0, 0, 0, 0), netaddr.IPv6Unspecified()},
+			},
+		},
+		"negative cache max TTL": {
+			settings: Settings{
+				CacheMaxTTL: -time.Second,
+			},
+			err:    ErrCacheMaxTTLNegative,
+			errMsg: "cache maximum TTL cannot be negative: -1s",
+		},
+		"IPv6 address with IPv6 disabled": {
+			settings: Settings{
+				IPv4:               true,
+				ListeningAddresses: []netaddr.IP{netaddr.IPv4(0, 0, 0, 0), netaddr.MustParseIP("::1")},
+			},
+			err:    ErrListeningAddressIPv6Disabled,
+			errMsg: "cannot listen on an IPv6 address with IPv6 disabled: ::1",
+		},
+		"IPv4 address with IPv4 disabled": {
+			settings: Settings{
+				IPv6:               true,
+				ListeningAddresses: []netaddr.IP{netaddr.IPv4(0, 0, 0, 0)},
+			},
+			err:    ErrListeningAddressIPv4Disabled,
+			errMsg: "cannot listen on an IPv4 address with IPv4 disabled: 0.0.0.0",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := testCase.settings.Validate()
+
+			assert.ErrorIs(t, err, testCase.err)
+			if testCase.err != nil {
+				assert.EqualError(t, err, testCase.errMsg)
+			}
+		})
+	}
+}
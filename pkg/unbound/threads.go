@@ -0,0 +1,75 @@
+package unbound
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxDefaultThreads caps the default number of threads, since each
+// thread comes with its own cache slabs and more threads than this
+// rarely improve the throughput of a single resolver.
+const maxDefaultThreads = 8
+
+const cgroupDir = "/sys/fs/cgroup"
+
+// defaultThreads returns the number of CPUs available to the process,
+// limited by its cgroup CPU quota if any, and capped to maxDefaultThreads.
+func defaultThreads() (threads uint16) {
+	return computeDefaultThreads(runtime.NumCPU(), cgroupDir)
+}
+
+func computeDefaultThreads(numCPU int, cgroupDir string) (threads uint16) {
+	cpus := numCPU
+	if quotaCPUs, ok := cgroupCPUQuota(cgroupDir); ok && quotaCPUs < cpus {
+		cpus = quotaCPUs
+	}
+	if cpus > maxDefaultThreads {
+		cpus = maxDefaultThreads
+	}
+	if cpus < 1 {
+		cpus = 1
+	}
+	return uint16(cpus)
+}
+
+// cgroupCPUQuota returns the number of CPUs, rounded up, allowed by the
+// CPU quota of the cgroup directory given, using the cgroup v2 cpu.max
+// file or the cgroup v1 cpu.cfs_quota_us and cpu.cfs_period_us files.
+// It returns false if no quota is set or if it cannot be read.
+func cgroupCPUQuota(cgroupDir string) (cpus int, ok bool) {
+	var quota, period string
+	data, err := ioutil.ReadFile(filepath.Join(cgroupDir, "cpu.max"))
+	if err == nil {
+		fields := strings.Fields(string(data))
+		const expectedFields = 2
+		if len(fields) != expectedFields {
+			return 0, false
+		}
+		quota, period = fields[0], fields[1]
+	} else {
+		quotaData, err := ioutil.ReadFile(filepath.Join(cgroupDir, "cpu", "cpu.cfs_quota_us"))
+		if err != nil {
+			return 0, false
+		}
+		periodData, err := ioutil.ReadFile(filepath.Join(cgroupDir, "cpu", "cpu.cfs_period_us"))
+		if err != nil {
+			return 0, false
+		}
+		quota, period = strings.TrimSpace(string(quotaData)), strings.TrimSpace(string(periodData))
+	}
+
+	quotaMicroseconds, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil || quotaMicroseconds <= 0 { // "max" or -1 for no quota
+		return 0, false
+	}
+	periodMicroseconds, err := strconv.ParseInt(period, 10, 64)
+	if err != nil || periodMicroseconds <= 0 {
+		return 0, false
+	}
+
+	cpus = int((quotaMicroseconds + periodMicroseconds - 1) / periodMicroseconds)
+	return cpus, true
+}
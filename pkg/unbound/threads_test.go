@@ -0,0 +1,86 @@
+package unbound
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_computeDefaultThreads(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		numCPU  int
+		files   map[string]string
+		threads uint16
+	}{
+		"no cgroup": {
+			numCPU:  4,
+			threads: 4,
+		},
+		"capped": {
+			numCPU:  64,
+			threads: 8,
+		},
+		"cgroup v2 no quota": {
+			numCPU:  4,
+			files:   map[string]string{"cpu.max": "max 100000\n"},
+			threads: 4,
+		},
+		"cgroup v2 quota": {
+			numCPU:  64,
+			files:   map[string]string{"cpu.max": "100000 100000\n"},
+			threads: 1,
+		},
+		"cgroup v2 fractional quota": {
+			numCPU:  64,
+			files:   map[string]string{"cpu.max": "150000 100000\n"},
+			threads: 2,
+		},
+		"cgroup v2 malformed": {
+			numCPU:  2,
+			files:   map[string]string{"cpu.max": "100000\n"},
+			threads: 2,
+		},
+		"cgroup v1 no quota": {
+			numCPU: 4,
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":  "-1\n",
+				"cpu/cpu.cfs_period_us": "100000\n",
+			},
+			threads: 4,
+		},
+		"cgroup v1 quota": {
+			numCPU: 64,
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":  "300000\n",
+				"cpu/cpu.cfs_period_us": "100000\n",
+			},
+			threads: 3,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for name, content := range testCase.files {
+				path := filepath.Join(dir, name)
+				err := os.MkdirAll(filepath.Dir(path), 0700)
+				require.NoError(t, err)
+				err = ioutil.WriteFile(path, []byte(content), 0600)
+				require.NoError(t, err)
+			}
+
+			threads := computeDefaultThreads(testCase.numCPU, dir)
+
+			assert.Equal(t, testCase.threads, threads)
+		})
+	}
+}